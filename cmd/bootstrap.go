@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+// runBootstrapAdmin creates the first admin of a new installation and makes it
+// a member of the households nobody belongs to yet. It only runs on the machine
+// with access to the database, and only while there are no users. The password
+// is read from FINANCE_ADMIN_PASSWORD or from stdin:
+//
+//	go run . bootstrap-admin -email admin@home -name Admin
+func runBootstrapAdmin(svc service.AuthService, households service.HouseholdService, args []string) error {
+	flags := flag.NewFlagSet("bootstrap-admin", flag.ContinueOnError)
+
+	email := flags.String("email", "", "email of the admin")
+	name := flags.String("name", "Admin", "name of the admin")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	password := os.Getenv("FINANCE_ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("error reading password: %w", err)
+		}
+
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := svc.BootstrapAdmin(models.RegisterRequest{Email: *email, Name: *name, Password: password})
+	if err != nil {
+		return err
+	}
+
+	if err := households.AdoptOrphans(user.ID); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(os.Stdout, "admin %s created with id %s\n", user.Email, user.ID)
+
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+// runExport writes the journal of a household to stdout, or to the file given
+// by -out. The household defaults to the one the data from before households
+// was moved to:
+//
+//	go run . export -format beancount -from 2026-01-01 -to 2026-12-31
+func runExport(svc service.ExportService, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+
+	format := flags.String("format", models.ExportFormatLedger, "ledger, hledger or beancount")
+	from := flags.String("from", "", "first date exported (YYYY-MM-DD)")
+	to := flags.String("to", "", "last date exported (YYYY-MM-DD)")
+	out := flags.String("out", "", "file to write, stdout when empty")
+	household := flags.String("household", models.DefaultHouseholdID.String(), "id of the household exported")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	start, end, err := models.ExportPeriod(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	householdID, err := models.ValidateID(*household)
+	if err != nil {
+		return err
+	}
+
+	journal, err := svc.Export(models.WithHousehold(context.Background(), householdID), *format, start, end)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = fmt.Fprint(os.Stdout, journal)
+		return err
+	}
+
+	return os.WriteFile(*out, []byte(journal), 0o644)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/me/finance/internal/config"
 	"github.com/me/finance/internal/config/logger"
@@ -27,6 +28,35 @@ func main() {
 		return
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		exportService := service.NewExportService(repository.NewExportRepository(db), repository.NewRepositoryPurchaseType(db))
+
+		if err := runExport(exportService, os.Args[2:]); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	authService := service.NewAuthService(userRepo, apiKeyRepo, config.Auth())
+
+	householdRepo := repository.NewHouseholdRepository(db)
+	householdService := service.NewHouseholdService(householdRepo, userRepo)
+
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), config.Idempotency().TTL)
+
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		if err := runBootstrapAdmin(authService, householdService, os.Args[2:]); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -36,42 +66,113 @@ func main() {
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"}, // Allow your frontend origin
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"ETag", "Location", "X-Household-ID", "Idempotent-Replayed"},
 		AllowCredentials: false, // Important for cookies, authorization headers with CORS
 		Debug:            false, // Enable for debugging CORS issues
 	})
 
+	authHandler := handler.NewAuthHandler(authService)
+	authHandler.RegisterRoutes(mux)
+
+	householdHandler := handler.NewHouseholdHandler(householdService)
+	householdHandler.RegisterRoutes(mux)
+
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	apiKeyHandler.RegisterRoutes(mux)
+
+	auditRepo := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepo)
+	auditHandler := handler.NewAuditHandler(auditService)
+	auditHandler.RegisterRoutes(mux)
+
+	referenceRepo := repository.NewReferenceRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+
 	personRepo := repository.NewRepositoryPerson(db)
-	personService := service.NewPersonService(personRepo)
+	personService := service.NewPersonService(personRepo, referenceRepo, auditRepo)
 	personHandler := handler.NewPersonHandler(personService)
 	personHandler.RegisterRoutes(mux)
 
 	creditcardRepo := repository.NewRepositoryCreditCard(db)
-	creditcardService := service.NewCreditCardService(creditcardRepo)
+	creditcardService := service.NewCreditCardService(creditcardRepo, referenceRepo, ledgerRepo, auditRepo)
 	creditcardHandler := handler.NewCreditCardHandler(creditcardService)
 	creditcardHandler.RegisterRoutes(mux)
 
 	paymentTypeRepo := repository.NewRepositoryPaymentType(db)
-	paymentTypeService := service.NewPaymentTypeService(paymentTypeRepo)
+	paymentTypeService := service.NewPaymentTypeService(paymentTypeRepo, referenceRepo, auditRepo)
 	paymentTypeHandler := handler.NewPaymentTypeHandler(paymentTypeService)
 	paymentTypeHandler.RegisterRoutes(mux)
 
 	purchaseTypeRepo := repository.NewRepositoryPurchaseType(db)
-	purchaseTypeService := service.NewPurchaseTypeService(purchaseTypeRepo)
+	purchaseTypeService := service.NewPurchaseTypeService(purchaseTypeRepo, referenceRepo, ledgerRepo, auditRepo)
 	purchaseTypeHandler := handler.NewPurchaseTypeHandler(purchaseTypeService)
 	purchaseTypeHandler.RegisterRoutes(mux)
 
+	ledgerService := service.NewLedgerService(ledgerRepo)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+	ledgerHandler.RegisterRoutes(mux)
+
+	accountRepo := repository.NewAccountRepository(db)
+	accountService := service.NewAccountService(accountRepo, ledgerRepo, auditRepo)
+	accountHandler := handler.NewAccountHandler(accountService)
+	accountHandler.RegisterRoutes(mux)
+
+	tagRepo := repository.NewRepositoryTag(db)
+	tagService := service.NewTagService(tagRepo, auditRepo)
+	tagHandler := handler.NewTagHandler(tagService)
+	tagHandler.RegisterRoutes(mux)
+
 	installmentRepo := repository.NewInstallmentRepository(db)
-	installmentService := service.NewInstallmentService(installmentRepo, creditcardRepo)
+	installmentService := service.NewInstallmentService(installmentRepo, creditcardRepo, ledgerRepo, auditRepo)
 	installmentHandler := handler.NewInstallmentHandler(installmentService)
 	installmentHandler.RegisterRoutes(mux)
 
 	purchaseRepo := repository.NewRepositoryPurchase(db)
-	purchaseService := service.NewPurchaseService(purchaseRepo, installmentRepo, creditcardRepo)
+	purchaseShareRepo := repository.NewPurchaseShareRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	purchaseService := service.NewPurchaseService(purchaseRepo, installmentRepo, creditcardRepo, tagRepo, purchaseShareRepo, ledgerRepo, refundRepo, auditRepo)
 	purchaseHandler := handler.NewPurchaseHandler(purchaseService)
 	purchaseHandler.RegisterRoutes(mux)
 
+	trashRepo := repository.NewTrashRepository(db)
+	trashService := service.NewTrashService(trashRepo, purchaseService, auditRepo, householdRepo)
+	trashHandler := handler.NewTrashHandler(trashService, config.Trash().Retention)
+	trashHandler.RegisterRoutes(mux)
+
+	go trashService.RunPurge(config.Trash().Retention, config.Trash().PurgeInterval)
+	go idempotencyService.RunCleanup(config.Idempotency().CleanupInterval)
+
+	settlementRepo := repository.NewSettlementRepository(db)
+	settlementService := service.NewSettlementService(settlementRepo, auditRepo)
+	settlementHandler := handler.NewSettlementHandler(settlementService)
+	settlementHandler.RegisterRoutes(mux)
+
+	incomeRepo := repository.NewIncomeRepository(db)
+	incomeService := service.NewIncomeService(incomeRepo, auditRepo)
+	incomeHandler := handler.NewIncomeHandler(incomeService)
+	incomeHandler.RegisterRoutes(mux)
+
+	exportRepo := repository.NewExportRepository(db)
+	exportService := service.NewExportService(exportRepo, purchaseTypeRepo)
+	exportHandler := handler.NewExportHandler(exportService)
+	exportHandler.RegisterRoutes(mux)
+
+	reportRepo := repository.NewReportRepository(db)
+	reportService := service.NewReportService(reportRepo, incomeRepo)
+	reportHandler := handler.NewReportHandler(reportService)
+	reportHandler.RegisterRoutes(mux)
+
+	openAPIHandler, err := handler.NewOpenAPIHandler()
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+
+	openAPIHandler.RegisterRoutes(mux)
+
 	slog.Info(fmt.Sprintf("Server running on port %s - env: %s", config.ServerPort(), config.Env()))
-	http.ListenAndServe(fmt.Sprintf(":%s", config.ServerPort()), c.Handler(mux))
+	http.ListenAndServe(fmt.Sprintf(":%s", config.ServerPort()), c.Handler(handler.V2(handler.RequestMetadata(handler.Authenticate(authService, handler.Tenant(householdService, handler.Policy(handler.Idempotency(idempotencyService, mux))))))))
 }
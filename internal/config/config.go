@@ -1,10 +1,18 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type config struct {
-	API APIConfig
-	DB  DBConfig
+	API         APIConfig
+	DB          DBConfig
+	Trash       TrashConfig
+	Auth        AuthConfig
+	Idempotency IdempotencyConfig
 }
 
 type APIConfig struct {
@@ -16,6 +24,30 @@ type DBConfig struct {
 	StringConn string
 }
 
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// AuthConfig signs the tokens of the users. Secret must be at least 32 bytes;
+// AllowRegistration lets anyone register, otherwise only admins create users.
+type AuthConfig struct {
+	Secret            []byte
+	AccessTTL         time.Duration
+	RefreshTTL        time.Duration
+	AllowRegistration bool
+}
+
+// IdempotencyConfig keeps the responses to requests with an Idempotency-Key
+// for TTL, deleting the expired ones every CleanupInterval.
+type IdempotencyConfig struct {
+	TTL             time.Duration
+	CleanupInterval time.Duration
+}
+
+// devSecret is the secret committed in cfg.toml, fit only for development.
+const devSecret = "dev-only-secret-replace-in-production"
+
 var cfg *config
 
 func Load() error {
@@ -33,6 +65,47 @@ func Load() error {
 		Env:  viper.GetString("api.env"),
 	}
 
+	viper.SetDefault("trash.retentionDays", 30)
+	viper.SetDefault("trash.purgeIntervalHours", 24)
+
+	cfg.Trash = TrashConfig{
+		Retention:     time.Duration(viper.GetInt("trash.retentionDays")) * 24 * time.Hour,
+		PurgeInterval: time.Duration(viper.GetInt("trash.purgeIntervalHours")) * time.Hour,
+	}
+
+	// The secret is read from FINANCE_AUTH_SECRET when set, so it stays out of
+	// the repository.
+	if err := viper.BindEnv("auth.secret", "FINANCE_AUTH_SECRET"); err != nil {
+		return err
+	}
+
+	viper.SetDefault("auth.accessTokenMinutes", 15)
+	viper.SetDefault("auth.refreshTokenDays", 30)
+	viper.SetDefault("auth.allowRegistration", false)
+
+	cfg.Auth = AuthConfig{
+		Secret:            []byte(viper.GetString("auth.secret")),
+		AccessTTL:         time.Duration(viper.GetInt("auth.accessTokenMinutes")) * time.Minute,
+		RefreshTTL:        time.Duration(viper.GetInt("auth.refreshTokenDays")) * 24 * time.Hour,
+		AllowRegistration: viper.GetBool("auth.allowRegistration"),
+	}
+
+	viper.SetDefault("idempotency.ttlHours", 24)
+	viper.SetDefault("idempotency.cleanupIntervalHours", 1)
+
+	cfg.Idempotency = IdempotencyConfig{
+		TTL:             time.Duration(viper.GetInt("idempotency.ttlHours")) * time.Hour,
+		CleanupInterval: time.Duration(viper.GetInt("idempotency.cleanupIntervalHours")) * time.Hour,
+	}
+
+	if len(cfg.Auth.Secret) < 32 {
+		return errors.New("auth.secret must have at least 32 characters")
+	}
+
+	if cfg.API.Env == "prod" && string(cfg.Auth.Secret) == devSecret {
+		return errors.New("auth.secret is the development secret, set FINANCE_AUTH_SECRET in production")
+	}
+
 	cfg.DB = DBConfig{}
 
 	if cfg.API.Env != "prod" {
@@ -48,6 +121,18 @@ func DB() DBConfig {
 	return cfg.DB
 }
 
+func Trash() TrashConfig {
+	return cfg.Trash
+}
+
+func Auth() AuthConfig {
+	return cfg.Auth
+}
+
+func Idempotency() IdempotencyConfig {
+	return cfg.Idempotency
+}
+
 func ServerPort() string {
 	return cfg.API.Port
 }
@@ -1,8 +1,9 @@
 package logger
 
 import (
-	"log/slog"
 	"os"
+
+	"github.com/sagikazarmark/slog-shim"
 )
 
 func InitLogger() {
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tag (
+	id   UUID PRIMARY KEY,
+	name VARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS purchase_tag (
+	id_purchase UUID NOT NULL REFERENCES purchase (id) ON DELETE CASCADE,
+	id_tag      UUID NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+	PRIMARY KEY (id_purchase, id_tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_tag_tag ON purchase_tag (id_tag);
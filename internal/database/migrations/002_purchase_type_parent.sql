@@ -0,0 +1,4 @@
+ALTER TABLE purchase_type
+	ADD COLUMN IF NOT EXISTS id_parent UUID REFERENCES purchase_type (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_purchase_type_parent ON purchase_type (id_parent);
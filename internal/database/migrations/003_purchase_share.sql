@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS purchase_share (
+	id_purchase UUID           NOT NULL REFERENCES purchase (id) ON DELETE CASCADE,
+	id_person   UUID           NOT NULL REFERENCES person (id),
+	percentage  NUMERIC(7, 4)  NOT NULL,
+	amount      NUMERIC(12, 2) NOT NULL,
+	PRIMARY KEY (id_purchase, id_person)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_share_person ON purchase_share (id_person);
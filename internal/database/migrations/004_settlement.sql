@@ -0,0 +1,12 @@
+ALTER TABLE credit_card
+	ADD COLUMN IF NOT EXISTS id_person UUID REFERENCES person (id);
+
+CREATE TABLE IF NOT EXISTS settlement (
+	id          UUID PRIMARY KEY,
+	id_payer    UUID           NOT NULL REFERENCES person (id),
+	id_receiver UUID           NOT NULL REFERENCES person (id),
+	amount      NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+	"date"      DATE           NOT NULL,
+	description VARCHAR(255)   NOT NULL DEFAULT '',
+	CHECK (id_payer <> id_receiver)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS income (
+	id          UUID PRIMARY KEY,
+	description VARCHAR(255)   NOT NULL,
+	source      VARCHAR(100)   NOT NULL,
+	category    VARCHAR(100)   NOT NULL DEFAULT '',
+	amount      NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+	"date"      DATE           NOT NULL,
+	recurrence  VARCHAR(10)    NOT NULL DEFAULT 'none' CHECK (recurrence IN ('none', 'monthly', 'yearly')),
+	end_date    DATE,
+	id_person   UUID           NOT NULL REFERENCES person (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_income_person ON income (id_person);
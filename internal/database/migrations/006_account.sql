@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS account (
+	id              UUID PRIMARY KEY,
+	name            VARCHAR(100)   NOT NULL,
+	type            VARCHAR(20)    NOT NULL CHECK (type IN ('checking', 'savings', 'wallet', 'investment')),
+	id_person       UUID           NOT NULL REFERENCES person (id),
+	opening_balance NUMERIC(12, 2) NOT NULL DEFAULT 0,
+	opening_date    DATE           NOT NULL
+);
+
+ALTER TABLE purchase
+	ADD COLUMN IF NOT EXISTS id_account UUID REFERENCES account (id);
+
+CREATE TABLE IF NOT EXISTS account_transfer (
+	id          UUID PRIMARY KEY,
+	id_from     UUID           NOT NULL REFERENCES account (id),
+	id_to       UUID           NOT NULL REFERENCES account (id),
+	amount      NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+	"date"      DATE           NOT NULL,
+	description VARCHAR(255)   NOT NULL DEFAULT '',
+	CHECK (id_from <> id_to)
+);
+
+CREATE TABLE IF NOT EXISTS invoice_payment (
+	id             UUID PRIMARY KEY,
+	id_credit_card UUID           NOT NULL REFERENCES credit_card (id),
+	id_account     UUID           NOT NULL REFERENCES account (id),
+	month          CHAR(7)        NOT NULL,
+	amount         NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+	"date"         DATE           NOT NULL
+);
+
+ALTER TABLE purchase
+	ALTER COLUMN id_credit_card DROP NOT NULL;
@@ -0,0 +1,22 @@
+-- Existing purchases and installments have no journal entries; the integrity
+-- check at GET /v1/ledger/integrity lists them until they are backfilled.
+CREATE TABLE IF NOT EXISTS journal_entry (
+	id          UUID PRIMARY KEY,
+	"date"      DATE         NOT NULL,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	source_type VARCHAR(30)  NOT NULL,
+	source_id   UUID         NOT NULL,
+	created_at  TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entry_source ON journal_entry (source_type, source_id);
+
+CREATE TABLE IF NOT EXISTS posting (
+	id       UUID PRIMARY KEY,
+	id_entry UUID           NOT NULL REFERENCES journal_entry (id),
+	account  VARCHAR(100)   NOT NULL,
+	amount   NUMERIC(12, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_posting_entry ON posting (id_entry);
+CREATE INDEX IF NOT EXISTS idx_posting_account ON posting (account);
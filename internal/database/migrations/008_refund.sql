@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS purchase_refund (
+	id          UUID PRIMARY KEY,
+	id_purchase UUID           NOT NULL REFERENCES purchase (id) ON DELETE CASCADE,
+	amount      NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+	"date"      DATE           NOT NULL,
+	reason      VARCHAR(255)   NOT NULL DEFAULT '',
+	mode        VARCHAR(10)    NOT NULL CHECK (mode IN ('credit', 'cancel'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_refund_purchase ON purchase_refund (id_purchase);
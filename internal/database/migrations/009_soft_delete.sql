@@ -0,0 +1,13 @@
+ALTER TABLE person ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE credit_card ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE payment_type ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE purchase_type ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE purchase ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE installment ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_person_deleted_at ON person (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_credit_card_deleted_at ON credit_card (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payment_type_deleted_at ON payment_type (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_purchase_type_deleted_at ON purchase_type (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_purchase_deleted_at ON purchase (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_installment_deleted_at ON installment (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	id         UUID PRIMARY KEY,
+	entity     VARCHAR(50)  NOT NULL,
+	entity_id  UUID         NOT NULL,
+	action     VARCHAR(20)  NOT NULL,
+	actor      VARCHAR(255) NOT NULL,
+	request_id VARCHAR(100) NOT NULL DEFAULT '',
+	before     JSONB,
+	after      JSONB,
+	diff       JSONB,
+	created_at TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS app_user (
+	id            UUID PRIMARY KEY,
+	email         VARCHAR(255) NOT NULL,
+	name          VARCHAR(100) NOT NULL,
+	password_hash VARCHAR(100) NOT NULL,
+	admin         BOOLEAN      NOT NULL DEFAULT false,
+	created_at    TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_app_user_email ON app_user (lower(email));
+
+-- A session is opened by a login and lives as long as its refresh token.
+-- refresh_id is the id of the only refresh token that can still be used, so a
+-- refresh token used twice is detected and revokes the session.
+CREATE TABLE IF NOT EXISTS session (
+	id         UUID PRIMARY KEY,
+	id_user    UUID        NOT NULL REFERENCES app_user (id),
+	refresh_id UUID        NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_user ON session (id_user);
@@ -0,0 +1,87 @@
+CREATE TABLE IF NOT EXISTS household (
+	id         UUID PRIMARY KEY,
+	name       VARCHAR(100) NOT NULL,
+	created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS household_member (
+	id_household UUID        NOT NULL REFERENCES household (id) ON DELETE CASCADE,
+	id_user      UUID        NOT NULL REFERENCES app_user (id),
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (id_household, id_user)
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_member_user ON household_member (id_user);
+
+-- The data from before households moves to a default household, joined by the
+-- users that already exist. A bootstrapped admin joins it when it has no
+-- members yet.
+INSERT INTO household (id, name) VALUES ('00000000-0000-0000-0000-000000000001', 'Casa')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO household_member (id_household, id_user)
+	SELECT '00000000-0000-0000-0000-000000000001', id FROM app_user
+	ON CONFLICT DO NOTHING;
+
+DO $$
+DECLARE
+	t TEXT;
+BEGIN
+	FOREACH t IN ARRAY ARRAY[
+		'person', 'credit_card', 'payment_type', 'purchase_type', 'tag', 'account', 'purchase',
+		'installment', 'purchase_tag', 'purchase_share', 'purchase_refund', 'settlement', 'income',
+		'account_transfer', 'invoice_payment', 'journal_entry', 'audit_log'
+	] LOOP
+		EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS id_household UUID REFERENCES household (id)', t);
+		EXECUTE format('UPDATE %I SET id_household = %L WHERE id_household IS NULL', t, '00000000-0000-0000-0000-000000000001');
+		EXECUTE format('ALTER TABLE %I ALTER COLUMN id_household SET NOT NULL', t);
+		EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I (id_household)', 'idx_' || t || '_household', t);
+	END LOOP;
+END $$;
+
+-- Composite foreign keys make a reference to a record of another household
+-- impossible, whatever the query that writes it.
+DO $$
+DECLARE
+	t TEXT;
+	fk TEXT[];
+BEGIN
+	FOREACH t IN ARRAY ARRAY['person', 'credit_card', 'payment_type', 'purchase_type', 'tag', 'account', 'purchase'] LOOP
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = t || '_household_id_key') THEN
+			EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I UNIQUE (id_household, id)', t, t || '_household_id_key');
+		END IF;
+	END LOOP;
+
+	FOREACH fk SLICE 1 IN ARRAY ARRAY[
+		['purchase', 'id_person', 'person'],
+		['purchase', 'id_payment_type', 'payment_type'],
+		['purchase', 'id_purchase_type', 'purchase_type'],
+		['purchase', 'id_credit_card', 'credit_card'],
+		['purchase', 'id_account', 'account'],
+		['installment', 'purchase_id', 'purchase'],
+		['purchase_tag', 'id_purchase', 'purchase'],
+		['purchase_tag', 'id_tag', 'tag'],
+		['purchase_share', 'id_purchase', 'purchase'],
+		['purchase_share', 'id_person', 'person'],
+		['purchase_refund', 'id_purchase', 'purchase'],
+		['settlement', 'id_payer', 'person'],
+		['settlement', 'id_receiver', 'person'],
+		['income', 'id_person', 'person'],
+		['account', 'id_person', 'person'],
+		['credit_card', 'id_person', 'person'],
+		['purchase_type', 'id_parent', 'purchase_type'],
+		['account_transfer', 'id_from', 'account'],
+		['account_transfer', 'id_to', 'account'],
+		['invoice_payment', 'id_credit_card', 'credit_card'],
+		['invoice_payment', 'id_account', 'account']
+	] LOOP
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = fk[1] || '_' || fk[2] || '_household_fkey') THEN
+			EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY (id_household, %I) REFERENCES %I (id_household, id)',
+				fk[1], fk[1] || '_' || fk[2] || '_household_fkey', fk[2], fk[3]);
+		END IF;
+	END LOOP;
+END $$;
+
+-- Tag names are unique within a household only.
+ALTER TABLE tag DROP CONSTRAINT IF EXISTS tag_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_household_name ON tag (id_household, name);
@@ -0,0 +1,11 @@
+-- The members from before roles keep full control of their household. New
+-- members join as editors unless told otherwise.
+ALTER TABLE household_member ADD COLUMN IF NOT EXISTS role VARCHAR(10) NOT NULL DEFAULT 'owner';
+ALTER TABLE household_member ALTER COLUMN role SET DEFAULT 'editor';
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'household_member_role_check') THEN
+		ALTER TABLE household_member ADD CONSTRAINT household_member_role_check CHECK (role IN ('owner', 'editor', 'viewer'));
+	END IF;
+END $$;
@@ -0,0 +1,17 @@
+-- An API key authenticates a script as its user, limited to its scopes. Only
+-- the SHA-256 of the key is stored; prefix is kept to tell the keys apart.
+CREATE TABLE IF NOT EXISTS api_key (
+	id           UUID PRIMARY KEY,
+	id_user      UUID         NOT NULL REFERENCES app_user (id),
+	name         VARCHAR(100) NOT NULL,
+	prefix       VARCHAR(20)  NOT NULL,
+	key_hash     VARCHAR(64)  NOT NULL,
+	scopes       TEXT[]       NOT NULL,
+	expires_at   TIMESTAMPTZ,
+	last_used_at TIMESTAMPTZ,
+	revoked_at   TIMESTAMPTZ,
+	created_at   TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_key_hash ON api_key (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_key_user ON api_key (id_user);
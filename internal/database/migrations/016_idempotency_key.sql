@@ -0,0 +1,16 @@
+-- An idempotency key keeps the first response to a POST sent with it, so a
+-- retry of the request is answered with that response instead of being run
+-- again. status is NULL while the first request is still running.
+CREATE TABLE IF NOT EXISTS idempotency_key (
+	key          VARCHAR(255) NOT NULL,
+	id_user      UUID         NOT NULL REFERENCES app_user (id),
+	request_hash VARCHAR(64)  NOT NULL,
+	status       INTEGER,
+	headers      JSONB,
+	body         BYTEA,
+	created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+	expires_at   TIMESTAMPTZ  NOT NULL,
+	PRIMARY KEY (id_user, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_expires_at ON idempotency_key (expires_at);
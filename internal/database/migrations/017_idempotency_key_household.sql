@@ -0,0 +1,8 @@
+-- Keys are kept per household too, so the same key sent to two households
+-- runs in each instead of replaying the response of the first. The routes
+-- that are not scoped to a household keep their keys under the nil id.
+ALTER TABLE idempotency_key ADD COLUMN IF NOT EXISTS id_household UUID NOT NULL
+	DEFAULT '00000000-0000-0000-0000-000000000000';
+
+ALTER TABLE idempotency_key DROP CONSTRAINT IF EXISTS idempotency_key_pkey;
+ALTER TABLE idempotency_key ADD PRIMARY KEY (id_user, id_household, key);
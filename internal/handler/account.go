@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type AccountHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	CreateAccount(w http.ResponseWriter, r *http.Request)
+	UpdateAccount(w http.ResponseWriter, r *http.Request)
+	DeleteAccount(w http.ResponseWriter, r *http.Request)
+	FindAccountByID(w http.ResponseWriter, r *http.Request)
+	FindAllAccounts(w http.ResponseWriter, r *http.Request)
+	CreateTransfer(w http.ResponseWriter, r *http.Request)
+	PayInvoice(w http.ResponseWriter, r *http.Request)
+	FindAccountBalance(w http.ResponseWriter, r *http.Request)
+}
+
+type accountHandler struct {
+	service service.AccountService
+}
+
+func NewAccountHandler(svc service.AccountService) AccountHandler {
+	return &accountHandler{service: svc}
+}
+
+func (h *accountHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/accounts", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateAccount(w, r)
+	})
+
+	mux.HandleFunc("PUT /v1/accounts", func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateAccount(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.DeleteAccount(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAccountByID(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/accounts", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAllAccounts(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/accounts/{id}/balance", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAccountBalance(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/transfers", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateTransfer(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/invoicePayments", func(w http.ResponseWriter, r *http.Request) {
+		h.PayInvoice(w, r)
+	})
+}
+
+func (h *accountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
+	var account models.Account
+
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding account: %v", err))
+		return
+	}
+
+	if err := account.Validate(true); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	created, err := h.service.CreateAccount(r.Context(), account)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, created.Version)
+	HTTPCreated(w, location("accounts", created.ID), created)
+}
+
+func (h *accountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
+	var account models.Account
+
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding account: %v", err))
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	account.Version = version
+
+	if err := account.Validate(false); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.UpdateAccount(r.Context(), account); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Account was updated with success!", http.StatusOK)
+}
+
+func (h *accountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAccount(r.Context(), id, version); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Account was deleted with success!", http.StatusOK)
+}
+
+func (h *accountHandler) FindAccountByID(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	account, err := h.service.FindAccountByID(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, account.Version)
+
+	HTTPResponse(w, account, http.StatusOK)
+}
+
+func (h *accountHandler) FindAllAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.service.FindAllAccounts(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, accounts, http.StatusOK)
+}
+
+func (h *accountHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var transfer models.AccountTransfer
+
+	if err := json.NewDecoder(r.Body).Decode(&transfer); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding transfer: %v", err))
+		return
+	}
+
+	if err := transfer.Validate(); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	created, err := h.service.CreateTransfer(r.Context(), transfer)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, "", created)
+}
+
+func (h *accountHandler) PayInvoice(w http.ResponseWriter, r *http.Request) {
+	var payment models.InvoicePayment
+
+	if err := json.NewDecoder(r.Body).Decode(&payment); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding invoice payment: %v", err))
+		return
+	}
+
+	if err := payment.Validate(); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	created, err := h.service.PayInvoice(r.Context(), payment)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, "", created)
+}
+
+func (h *accountHandler) FindAccountBalance(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		if err := models.ValidateDate(date); err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+	}
+
+	statement, err := h.service.FindAccountStatement(r.Context(), id, date)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, statement, http.StatusOK)
+}
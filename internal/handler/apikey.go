@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type APIKeyHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	FindAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+}
+
+type apiKeyHandler struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(svc service.APIKeyService) APIKeyHandler {
+	return &apiKeyHandler{service: svc}
+}
+
+func (h *apiKeyHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/apiKeys", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateAPIKey(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/apiKeys", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAPIKeys(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/apiKeys/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.RevokeAPIKey(w, r)
+	})
+}
+
+func (h *apiKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request models.APIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding api key: %v", err))
+		return
+	}
+
+	apiKey, err := h.service.CreateAPIKey(r.Context(), request)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, "", apiKey)
+}
+
+func (h *apiKeyHandler) FindAPIKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := h.service.FindAPIKeys(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, apiKeys, http.StatusOK)
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), id); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "API key was revoked with success!", http.StatusOK)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type AuditHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	FindAuditEvents(w http.ResponseWriter, r *http.Request)
+}
+
+type auditHandler struct {
+	service service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) AuditHandler {
+	return &auditHandler{service: svc}
+}
+
+func (h *auditHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/audit", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAuditEvents(w, r)
+	})
+}
+
+// FindAuditEvents lists the latest audit events, filtered by the entity, id,
+// actor, from and to query parameters.
+func (h *auditHandler) FindAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.AuditFilter{
+		Entity: query.Get("entity"),
+		Actor:  query.Get("actor"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+	}
+
+	if id := query.Get("id"); id != "" {
+		entityID, err := models.ValidateID(id)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		filter.EntityID = uuid.NullUUID{UUID: entityID, Valid: true}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			HTTPError(w, r, models.InvalidField("limit", "the limit must be a number"))
+			return
+		}
+
+		filter.Limit = n
+	}
+
+	if err := filter.Validate(); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	events, err := h.service.FindAuditEvents(r.Context(), filter)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, events, http.StatusOK)
+}
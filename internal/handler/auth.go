@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type AuthHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	Register(w http.ResponseWriter, r *http.Request)
+	Login(w http.ResponseWriter, r *http.Request)
+	Refresh(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	Me(w http.ResponseWriter, r *http.Request)
+}
+
+type authHandler struct {
+	service service.AuthService
+}
+
+func NewAuthHandler(svc service.AuthService) AuthHandler {
+	return &authHandler{service: svc}
+}
+
+func (h *authHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/auth/register", func(w http.ResponseWriter, r *http.Request) {
+		h.Register(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		h.Login(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		h.Refresh(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		h.Logout(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/auth/me", func(w http.ResponseWriter, r *http.Request) {
+		h.Me(w, r)
+	})
+}
+
+func (h *authHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var request models.RegisterRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding user: %v", err))
+		return
+	}
+
+	user, err := h.service.Register(r.Context(), request)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, "", user)
+}
+
+func (h *authHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var request models.LoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding login: %v", err))
+		return
+	}
+
+	tokens, err := h.service.Login(request)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, tokens, http.StatusOK)
+}
+
+func (h *authHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request models.RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding refresh token: %v", err))
+		return
+	}
+
+	tokens, err := h.service.Refresh(request.RefreshToken)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, tokens, http.StatusOK)
+}
+
+func (h *authHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Logout(r.Context()); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Logged out with success!", http.StatusOK)
+}
+
+func (h *authHandler) Me(w http.ResponseWriter, r *http.Request) {
+	principal, ok := models.PrincipalFrom(r.Context())
+	if !ok {
+		HTTPError(w, r, models.Unauthorized("authentication required"))
+		return
+	}
+
+	HTTPResponse(w, principal.User, http.StatusOK)
+}
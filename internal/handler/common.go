@@ -11,12 +11,18 @@ func HTTPResponse(w http.ResponseWriter, message any, statusCode int) map[string
 		"Message": message,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-    w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
-    w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	setResponseHeaders(w)
 	
 	json.NewEncoder(w).Encode(resp)
 
 	return resp
+}
+
+// setResponseHeaders sets the headers of every JSON response, which have to
+// be set before a status is written.
+func setResponseHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 }
\ No newline at end of file
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPCreated answers a create with the record as it was saved and a 201,
+// pointing Location at the route that reads it back when there is one, so
+// the client does not need to query for what it just created.
+func HTTPCreated(w http.ResponseWriter, location string, record any) {
+	if location != "" {
+		w.Header().Set("Location", location)
+	}
+
+	// HTTPResponse leaves the status to the caller, and nothing set on the
+	// headers after it is written reaches the client.
+	setResponseHeaders(w)
+	w.WriteHeader(http.StatusCreated)
+
+	HTTPResponse(w, record, http.StatusCreated)
+}
+
+// location is the route of a record, as in /v1/persons/{id}.
+func location(collection string, id fmt.Stringer) string {
+	return fmt.Sprintf("/v1/%s/%s", collection, id)
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPCreatedSetsHeadersBeforeTheStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	HTTPCreated(w, "/v1/persons/1", map[string]string{"name": "Ana"})
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("got status %d, want 201", w.Code)
+	}
+
+	for name, want := range map[string]string{
+		"Location":                    "/v1/persons/1",
+		"Content-Type":                "application/json",
+		"Access-Control-Allow-Origin": "http://localhost:3000",
+	} {
+		if got := w.Result().Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
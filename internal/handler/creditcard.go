@@ -7,13 +7,13 @@ import (
 
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/service"
-	"github.com/sagikazarmark/slog-shim"
 )
 
 type CreditCardHandler interface {
 	RegisterRoutes(mux *http.ServeMux)
 	CreateCreditCard(w http.ResponseWriter, r *http.Request)
 	UpdateCreditCard(w http.ResponseWriter, r *http.Request)
+	PatchCreditCard(w http.ResponseWriter, r *http.Request)
 	DeleteCreditCard(w http.ResponseWriter, r *http.Request)
 	FindCreditCardByID(w http.ResponseWriter, r *http.Request)
 	FindAllCreditCards(w http.ResponseWriter, r *http.Request)
@@ -36,6 +36,10 @@ func (h *creditCardHandler) RegisterRoutes(mux *http.ServeMux) {
 		h.UpdateCreditCard(w, r)
 	})
 
+	mux.HandleFunc("PATCH /v1/creditCards/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.PatchCreditCard(w, r)
+	})
+
 	mux.HandleFunc("DELETE /v1/creditCards/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.DeleteCreditCard(w, r)
 	})
@@ -53,63 +57,115 @@ func (c *creditCardHandler) CreateCreditCard(w http.ResponseWriter, r *http.Requ
 	var creditCard models.CreditCard
 
 	if err := json.NewDecoder(r.Body).Decode(&creditCard); err != nil {
-		slog.Error(fmt.Sprintf("Error decoding credit card: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding credit card: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding credit card: %v", err))
 		return
 	}
 
 	if err := creditCard.Validate(true); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := c.service.CreateCreditCard(creditCard); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	created, err := c.service.CreateCreditCard(r.Context(), creditCard)
+	if err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
-	HTTPResponse(w, fmt.Sprintf("Credit card created to %s", creditCard.Owner), http.StatusOK)
+	setETag(w, created.Version)
+	HTTPCreated(w, location("creditCards", created.ID), created)
 }
 
 func (c *creditCardHandler) UpdateCreditCard(w http.ResponseWriter, r *http.Request) {
 	var creditCard models.CreditCard
 
 	if err := json.NewDecoder(r.Body).Decode(&creditCard); err != nil {
-		slog.Error(fmt.Sprintf("Error decoding credit card: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding credit card: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding credit card: %v", err))
 		return
 	}
 
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	creditCard.Version = version
+
 	if err := creditCard.Validate(false); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := c.service.UpdateCreditCard(creditCard); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := c.service.UpdateCreditCard(r.Context(), creditCard); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
 	HTTPResponse(w, "Credit card was updated with success!", http.StatusOK)
 }
 
+func (c *creditCardHandler) PatchCreditCard(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	patch, err := readMergePatch(r)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	creditCard, err := c.service.PatchCreditCard(r.Context(), id, patch, version)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, creditCard.Version)
+
+	HTTPResponse(w, creditCard, http.StatusOK)
+}
+
 func (c *creditCardHandler) DeleteCreditCard(w http.ResponseWriter, r *http.Request) {
 	idRequest := r.PathValue("id")
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := c.service.DeleteCreditCard(id); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if reassignTo := r.URL.Query().Get("reassignTo"); reassignTo != "" {
+		to, err := models.ValidateReassign(id, reassignTo)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		response, err := c.service.ReassignCreditCard(r.Context(), id, to, version)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		HTTPResponse(w, response, http.StatusOK)
+		return
+	}
+
+	if err := c.service.DeleteCreditCard(r.Context(), id, version); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -121,26 +177,25 @@ func (c *creditCardHandler) FindCreditCardByID(w http.ResponseWriter, r *http.Re
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
-	creditCard, err := c.service.FindCreditCardByID(id)
+	creditCard, err := c.service.FindCreditCardByID(r.Context(), id)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
+	setETag(w, creditCard.Version)
+
 	HTTPResponse(w, creditCard, http.StatusOK)
 }
 
 func (c *creditCardHandler) FindAllCreditCards(w http.ResponseWriter, r *http.Request) {
-	creditCard, err := c.service.FindAllCreditCards()
+	creditCard, err := c.service.FindAllCreditCards(r.Context())
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/me/finance/internal/models"
+)
+
+// V2 serves the /v2 routes with the handlers of /v1, rewriting what they
+// answer into the envelope of models.Envelope with snake_case keys. The /v1
+// responses are left as they always were.
+func V2(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/v2/")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		v1 := r.Clone(r.Context())
+		v1.URL.Path = "/v1/" + rest
+		v1.URL.RawPath = ""
+
+		recorder := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(recorder, v1)
+
+		recorder.writeEnvelope(w)
+	})
+}
+
+// responseRecorder holds the response of a /v1 handler until it is rewritten.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wrote {
+		rr.status, rr.wrote = status, true
+	}
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.WriteHeader(http.StatusOK)
+
+	return rr.body.Write(b)
+}
+
+func (rr *responseRecorder) writeEnvelope(w http.ResponseWriter) {
+	mediaType, _, _ := mime.ParseMediaType(rr.header.Get("Content-Type"))
+
+	for key, values := range rr.header {
+		w.Header()[key] = values
+	}
+
+	// Location points at the /v1 route of a created record, a /v2 client gets
+	// the /v2 one.
+	if rest, ok := strings.CutPrefix(w.Header().Get("Location"), "/v1/"); ok {
+		w.Header().Set("Location", "/v2/"+rest)
+	}
+
+	// What is not JSON, like the journal of /export, is not data the envelope
+	// could hold and goes out untouched.
+	if rr.status < http.StatusBadRequest && mediaType != "application/json" {
+		w.WriteHeader(rr.status)
+		w.Write(rr.body.Bytes())
+		return
+	}
+
+	var (
+		envelope models.Envelope
+		response struct {
+			StatusCode int
+			Message    json.RawMessage
+		}
+	)
+
+	// HTTPResponse only tells the status in the body, /v2 sends it as the
+	// status of the response too.
+	if mediaType == "application/json" && json.Unmarshal(rr.body.Bytes(), &response) == nil && response.StatusCode != 0 {
+		rr.status = response.StatusCode
+	}
+
+	envelope.Meta = models.EnvelopeMeta{Status: rr.status, RequestID: rr.header.Get("X-Request-ID")}
+
+	switch {
+	case mediaType == "application/problem+json":
+		envelope.Errors = problemErrors(rr.body.Bytes())
+	case mediaType == "application/json":
+		var message string
+		if json.Unmarshal(response.Message, &message) == nil {
+			envelope.Meta.Message = message
+		} else {
+			envelope.Data = snakeCaseKeys(decodeJSON(response.Message))
+		}
+
+		if rr.status >= http.StatusBadRequest {
+			if message == "" {
+				message = http.StatusText(rr.status)
+			}
+
+			envelope.Errors = []models.EnvelopeError{{Code: errorCode(http.StatusText(rr.status)), Message: message}}
+			envelope.Meta.Message = ""
+		}
+	default:
+		// The errors of the mux itself, like an unknown route, are plain text.
+		envelope.Errors = []models.EnvelopeError{{
+			Code:    errorCode(http.StatusText(rr.status)),
+			Message: strings.TrimSpace(rr.body.String()),
+		}}
+	}
+
+	if list, ok := envelope.Data.([]any); ok {
+		count := len(list)
+		envelope.Meta.Count = &count
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rr.status)
+
+	json.NewEncoder(w).Encode(envelope)
+}
+
+// problemErrors lists the errors of a problem: one for each field of a
+// validation problem, or the problem itself.
+func problemErrors(body []byte) []models.EnvelopeError {
+	var problem models.Problem
+
+	json.Unmarshal(body, &problem)
+
+	if len(problem.Errors) > 0 {
+		errors := make([]models.EnvelopeError, len(problem.Errors))
+		for i, field := range problem.Errors {
+			errors[i] = models.EnvelopeError{Code: field.Code, Message: field.Message, Field: field.Field, Params: field.Params}
+		}
+
+		return errors
+	}
+
+	envelopeError := models.EnvelopeError{
+		Code:    errorCode(strings.TrimPrefix(problem.Type, "/problems/")),
+		Message: problem.Detail,
+	}
+
+	if problem.Dependents != nil {
+		envelopeError.Params = map[string]any{"dependents": problem.Dependents}
+	}
+
+	return []models.EnvelopeError{envelopeError}
+}
+
+// errorCode turns the kind of a problem or a status text into a code, as in
+// not_found.
+func errorCode(kind string) string {
+	return strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(kind))
+}
+
+func decodeJSON(raw json.RawMessage) any {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// snakeCaseKeys renames the keys of every object in value to snake_case, for
+// the few fields whose JSON names were never given.
+func snakeCaseKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		renamed := make(map[string]any, len(v))
+		for key, item := range v {
+			renamed[snakeCase(key)] = snakeCaseKeys(item)
+		}
+
+		return renamed
+	case []any:
+		for i, item := range v {
+			v[i] = snakeCaseKeys(item)
+		}
+
+		return v
+	default:
+		return value
+	}
+}
+
+// snakeCase turns StatusCode into status_code and IDUser into id_user. Keys
+// without capitals, already snake_case or not, are kept.
+func snakeCase(key string) string {
+	runes := []rune(key)
+
+	var b strings.Builder
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+
+			if startsWord && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
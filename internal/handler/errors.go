@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+	"github.com/sagikazarmark/slog-shim"
+)
+
+// HTTPError writes err as an RFC 7807 problem, with the status picked from the
+// kind of domain error. Unexpected errors become a 500 whose details only go
+// to the log.
+func HTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.Error(err.Error())
+
+	problem := toProblem(err)
+	problem.Instance = r.URL.Path
+
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="finance"`)
+	}
+
+	setResponseHeaders(w)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+
+	json.NewEncoder(w).Encode(problem)
+}
+
+func toProblem(err error) models.Problem {
+	var (
+		validation *models.ValidationError
+		inUse      *models.InUseError
+	)
+
+	if reference := missingReference(err); reference != nil {
+		err = reference
+	}
+
+	switch {
+	case errors.As(err, &validation):
+		return newProblem("validation", http.StatusBadRequest, err.Error(), func(p *models.Problem) {
+			p.Errors = validation.Fields
+		})
+	case errors.Is(err, models.ErrUnauthorized):
+		return newProblem("unauthorized", http.StatusUnauthorized, err.Error(), nil)
+	case errors.Is(err, models.ErrForbidden):
+		return newProblem("forbidden", http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, errIfMatchRequired):
+		return newProblem("precondition-required", http.StatusPreconditionRequired, err.Error(), nil)
+	case errors.Is(err, models.ErrVersionConflict):
+		return newProblem("version-conflict", http.StatusPreconditionFailed, err.Error(), nil)
+	case errors.Is(err, errMergePatchType):
+		return newProblem("unsupported-media-type", http.StatusUnsupportedMediaType, err.Error(), nil)
+	case errors.Is(err, models.ErrIdempotencyKeyReused):
+		return newProblem("idempotency-key-reused", http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, models.ErrNotFound):
+		return newProblem("not-found", http.StatusNotFound, err.Error(), nil)
+	case errors.As(err, &inUse):
+		return newProblem("in-use", http.StatusConflict, err.Error(), func(p *models.Problem) {
+			p.Dependents = &inUse.Dependents
+		})
+	case errors.Is(err, models.ErrConflict):
+		return newProblem("conflict", http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, models.ErrUnavailable) || unreachable(err):
+		return newProblem("unavailable", http.StatusServiceUnavailable, "the service is temporarily unavailable, try again later", nil)
+	default:
+		return newProblem("internal", http.StatusInternalServerError, "an unexpected error happened, it was logged for investigation", nil)
+	}
+}
+
+func newProblem(kind string, status int, detail string, extend func(p *models.Problem)) models.Problem {
+	problem := models.Problem{
+		Type:   "/problems/" + kind,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+
+	if extend != nil {
+		extend(&problem)
+	}
+
+	return problem
+}
+
+// foreignKeyViolation is the Postgres error code raised when a write references
+// a record that does not exist.
+const foreignKeyViolation = "23503"
+
+// missingReference turns the foreign key violation of an insert or update into
+// a validation error on the column holding the id, or returns nil for any
+// other error. The keys include the household, so a record of another
+// household is answered as missing too. The column is read from the name of
+// the constraint, <table>_<column>_household_fkey or <table>_<column>_fkey;
+// deleting a referenced record raises the same code on the same constraint,
+// and is told apart by the message.
+func missingReference(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != foreignKeyViolation || pqErr.Table == "" ||
+		!strings.HasPrefix(pqErr.Message, "insert or update") ||
+		!strings.HasPrefix(pqErr.Constraint, pqErr.Table+"_") {
+		return nil
+	}
+
+	column := strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_")
+	column = strings.TrimSuffix(strings.TrimSuffix(column, "_household_fkey"), "_fkey")
+
+	return models.InvalidField(column, "there is no record with this id")
+}
+
+// unreachable tells whether err comes from losing the connection to the
+// database rather than from the query itself.
+func unreachable(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+func TestMissingReferenceNamesTheColumn(t *testing.T) {
+	tests := map[string]*pq.Error{
+		"composite key": {
+			Code:       foreignKeyViolation,
+			Message:    `insert or update on table "purchase" violates foreign key constraint "purchase_id_person_household_fkey"`,
+			Table:      "purchase",
+			Constraint: "purchase_id_person_household_fkey",
+		},
+		"single key": {
+			Code:       foreignKeyViolation,
+			Message:    `insert or update on table "purchase" violates foreign key constraint "purchase_id_person_fkey"`,
+			Table:      "purchase",
+			Constraint: "purchase_id_person_fkey",
+		},
+	}
+
+	for name, pqErr := range tests {
+		t.Run(name, func(t *testing.T) {
+			problem := toProblem(fmt.Errorf("error trying insert purchase: %w", pqErr))
+
+			if problem.Status != http.StatusBadRequest {
+				t.Fatalf("got status %d, want 400", problem.Status)
+			}
+
+			if len(problem.Errors) != 1 || problem.Errors[0].Field != "id_person" {
+				t.Errorf("got errors %+v, want one on id_person", problem.Errors)
+			}
+		})
+	}
+}
+
+func TestDeletingAReferencedRecordIsNotAMissingReference(t *testing.T) {
+	err := &pq.Error{
+		Code:       foreignKeyViolation,
+		Message:    `update or delete on table "person" violates foreign key constraint "purchase_id_person_household_fkey" on table "purchase"`,
+		Table:      "purchase",
+		Constraint: "purchase_id_person_household_fkey",
+	}
+
+	if problem := toProblem(err); problem.Status == http.StatusBadRequest {
+		t.Errorf("a delete was answered as a missing reference: %+v", problem)
+	}
+}
+
+func TestHTTPErrorKeepsTheCORSHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	HTTPError(w, httptest.NewRequest(http.MethodGet, "/v1/persons/1", nil), models.NotFound("person"))
+
+	if got := w.Result().Header.Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+
+	if got := w.Result().Header.Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", got)
+	}
+
+	var problem models.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || problem.Status != http.StatusNotFound {
+		t.Errorf("got %+v (%v), want a 404 problem", problem, err)
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type ExportHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	Export(w http.ResponseWriter, r *http.Request)
+}
+
+type exportHandler struct {
+	service service.ExportService
+}
+
+func NewExportHandler(svc service.ExportService) ExportHandler {
+	return &exportHandler{service: svc}
+}
+
+func (h *exportHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/export", func(w http.ResponseWriter, r *http.Request) {
+		h.Export(w, r)
+	})
+}
+
+// Export returns the journal as plain text. "format" is required and "from"
+// and "to" optionally limit the dates exported.
+func (h *exportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	var (
+		format = r.URL.Query().Get("format")
+		from   = r.URL.Query().Get("from")
+		to     = r.URL.Query().Get("to")
+	)
+
+	if !models.ValidateExportFormat(format) {
+		HTTPError(w, r, models.InvalidField("format", "format parameter must be ledger, hledger or beancount"))
+		return
+	}
+
+	from, to, err := models.ExportPeriod(from, to)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	journal, err := h.service.Export(r.Context(), format, from, to)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(journal))
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type HouseholdHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	CreateHousehold(w http.ResponseWriter, r *http.Request)
+	FindHouseholds(w http.ResponseWriter, r *http.Request)
+	FindMembers(w http.ResponseWriter, r *http.Request)
+	FindMember(w http.ResponseWriter, r *http.Request)
+	AddMember(w http.ResponseWriter, r *http.Request)
+	UpdateMemberRole(w http.ResponseWriter, r *http.Request)
+	RemoveMember(w http.ResponseWriter, r *http.Request)
+}
+
+type householdHandler struct {
+	service service.HouseholdService
+}
+
+func NewHouseholdHandler(svc service.HouseholdService) HouseholdHandler {
+	return &householdHandler{service: svc}
+}
+
+func (h *householdHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/households", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateHousehold(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/households", func(w http.ResponseWriter, r *http.Request) {
+		h.FindHouseholds(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/households/{id}/members", func(w http.ResponseWriter, r *http.Request) {
+		h.FindMembers(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/households/{id}/members/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		h.FindMember(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/households/{id}/members", func(w http.ResponseWriter, r *http.Request) {
+		h.AddMember(w, r)
+	})
+
+	mux.HandleFunc("PUT /v1/households/{id}/members/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateMemberRole(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/households/{id}/members/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		h.RemoveMember(w, r)
+	})
+}
+
+func (h *householdHandler) CreateHousehold(w http.ResponseWriter, r *http.Request) {
+	var household models.Household
+
+	if err := json.NewDecoder(r.Body).Decode(&household); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding household: %v", err))
+		return
+	}
+
+	household, err := h.service.CreateHousehold(r.Context(), household)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, "", household)
+}
+
+func (h *householdHandler) FindHouseholds(w http.ResponseWriter, r *http.Request) {
+	households, err := h.service.FindHouseholds(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, households, http.StatusOK)
+}
+
+func (h *householdHandler) FindMembers(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	members, err := h.service.FindMembers(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, members, http.StatusOK)
+}
+
+func (h *householdHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	var request models.MemberRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding member: %v", err))
+		return
+	}
+
+	member, err := h.service.AddMember(r.Context(), id, request)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, memberLocation(id, member.IDUser), member)
+}
+
+func (h *householdHandler) FindMember(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	userID, err := models.ValidateID(r.PathValue("userID"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	member, err := h.service.FindMember(r.Context(), id, userID)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, member, http.StatusOK)
+}
+
+// memberLocation is the route of a member, as in
+// /v1/households/{id}/members/{userID}.
+func memberLocation(id, userID uuid.UUID) string {
+	return location("households", id) + "/members/" + userID.String()
+}
+
+func (h *householdHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	userID, err := models.ValidateID(r.PathValue("userID"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	var request models.RoleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding role: %v", err))
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(r.Context(), id, userID, request); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Role was updated with success!", http.StatusOK)
+}
+
+func (h *householdHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	userID, err := models.ValidateID(r.PathValue("userID"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.RemoveMember(r.Context(), id, userID); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Member was removed with success!", http.StatusOK)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+	"github.com/sagikazarmark/slog-shim"
+)
+
+// replayedHeaders are the headers of a response kept to replay it.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency makes the POST requests sent with an Idempotency-Key safe to
+// retry: the first response is kept and every retry with the same key and
+// body gets it back, flagged by Idempotent-Replayed, instead of running again.
+// A response with a server error is not kept, so the retry runs.
+func Idempotency(idempotency service.IdempotencyService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+
+		if _, ok := models.PrincipalFrom(r.Context()); !ok || key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			HTTPError(w, r, models.Invalid("Error reading request: %v", err))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+		hash.Write(body)
+
+		stored, err := idempotency.Begin(r.Context(), key, hex.EncodeToString(hash.Sum(nil)))
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		if stored != nil {
+			for name, value := range stored.Headers {
+				w.Header().Set(name, value)
+			}
+
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+
+			return
+		}
+
+		recorder := &idempotentWriter{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			if p := recover(); p != nil {
+				idempotency.Release(r.Context(), key)
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError {
+			err = idempotency.Release(r.Context(), key)
+		} else {
+			err = idempotency.Complete(r.Context(), key, recorder.response())
+		}
+
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	})
+}
+
+// idempotentWriter writes the response through while keeping a copy of it.
+type idempotentWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+	body   bytes.Buffer
+}
+
+func (iw *idempotentWriter) WriteHeader(status int) {
+	if !iw.wrote {
+		iw.status, iw.wrote = status, true
+	}
+
+	iw.ResponseWriter.WriteHeader(status)
+}
+
+func (iw *idempotentWriter) Write(b []byte) (int, error) {
+	iw.wrote = true
+	iw.body.Write(b)
+
+	return iw.ResponseWriter.Write(b)
+}
+
+func (iw *idempotentWriter) response() models.IdempotentResponse {
+	headers := map[string]string{}
+
+	for _, name := range replayedHeaders {
+		if value := iw.Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	return models.IdempotentResponse{Status: iw.status, Headers: headers, Body: iw.body.Bytes()}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type idempotencyScope struct {
+	user, household uuid.UUID
+	key             string
+}
+
+// fakeIdempotencyRepository keeps the keys in memory.
+type fakeIdempotencyRepository struct {
+	keys map[idempotencyScope]models.IdempotencyKey
+}
+
+func (r *fakeIdempotencyRepository) Reserve(k models.IdempotencyKey) (bool, error) {
+	scope := idempotencyScope{k.IDUser, k.IDHousehold, k.Key}
+	if _, ok := r.keys[scope]; ok {
+		return false, nil
+	}
+
+	r.keys[scope] = k
+
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Find(userID, householdID uuid.UUID, key string) (models.IdempotencyKey, error) {
+	k, ok := r.keys[idempotencyScope{userID, householdID, key}]
+	if !ok {
+		return models.IdempotencyKey{}, models.NotFound("idempotency key")
+	}
+
+	return k, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(userID, householdID uuid.UUID, key string, response models.IdempotentResponse) error {
+	scope := idempotencyScope{userID, householdID, key}
+
+	k := r.keys[scope]
+	k.Response = &response
+	r.keys[scope] = k
+
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Release(userID, householdID uuid.UUID, key string) error {
+	delete(r.keys, idempotencyScope{userID, householdID, key})
+
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyKeysAreScopedToTheHousehold(t *testing.T) {
+	repo := &fakeIdempotencyRepository{keys: map[idempotencyScope]models.IdempotencyKey{}}
+
+	var created []uuid.UUID
+
+	handler := Idempotency(service.NewIdempotencyService(repo, time.Hour), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		membership, _ := models.MembershipFrom(r.Context())
+		created = append(created, membership.IDHousehold)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(membership.IDHousehold.String()))
+	}))
+
+	user := models.User{ID: uuid.New()}
+	home, other := uuid.New(), uuid.New()
+
+	post := func(household uuid.UUID) *httptest.ResponseRecorder {
+		ctx := models.WithPrincipal(context.Background(), models.Principal{User: user})
+		ctx = models.WithHousehold(ctx, household)
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/persons", strings.NewReader(`{"name":"Ana"}`)).WithContext(ctx)
+		r.Header.Set("Idempotency-Key", "create-ana")
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	post(home)
+
+	w := post(other)
+	if w.Header().Get("Idempotent-Replayed") != "" || w.Body.String() != other.String() {
+		t.Errorf("the key of another household was replayed: %s", w.Body.String())
+	}
+
+	w = post(home)
+	if w.Header().Get("Idempotent-Replayed") != "true" || w.Body.String() != home.String() {
+		t.Errorf("the retry in the same household was not replayed: %s", w.Body.String())
+	}
+
+	if len(created) != 2 || created[0] != home || created[1] != other {
+		t.Errorf("the request ran in %v, want once in each household", created)
+	}
+}
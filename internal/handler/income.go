@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type IncomeHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	CreateIncome(w http.ResponseWriter, r *http.Request)
+	UpdateIncome(w http.ResponseWriter, r *http.Request)
+	DeleteIncome(w http.ResponseWriter, r *http.Request)
+	FindIncomeByID(w http.ResponseWriter, r *http.Request)
+	FindIncomeByMonth(w http.ResponseWriter, r *http.Request)
+	FindAllIncomes(w http.ResponseWriter, r *http.Request)
+}
+
+type incomeHandler struct {
+	service service.IncomeService
+}
+
+func NewIncomeHandler(svc service.IncomeService) IncomeHandler {
+	return &incomeHandler{service: svc}
+}
+
+func (h *incomeHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/incomes", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateIncome(w, r)
+	})
+
+	mux.HandleFunc("PUT /v1/incomes", func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateIncome(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/incomes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.DeleteIncome(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/incomes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.FindIncomeByID(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/incomes", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("month") != "" {
+			h.FindIncomeByMonth(w, r)
+			return
+		}
+
+		h.FindAllIncomes(w, r)
+	})
+}
+
+func (h *incomeHandler) CreateIncome(w http.ResponseWriter, r *http.Request) {
+	var income models.Income
+
+	if err := json.NewDecoder(r.Body).Decode(&income); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding income: %v", err))
+		return
+	}
+
+	if err := income.Validate(true); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	created, err := h.service.CreateIncome(r.Context(), income)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, created.Version)
+	HTTPCreated(w, location("incomes", created.ID), created)
+}
+
+func (h *incomeHandler) UpdateIncome(w http.ResponseWriter, r *http.Request) {
+	var income models.Income
+
+	if err := json.NewDecoder(r.Body).Decode(&income); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding income: %v", err))
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	income.Version = version
+
+	if err := income.Validate(false); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.UpdateIncome(r.Context(), income); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Income was updated with success!", http.StatusOK)
+}
+
+func (h *incomeHandler) DeleteIncome(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteIncome(r.Context(), id, version); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Income was deleted with success!", http.StatusOK)
+}
+
+func (h *incomeHandler) FindIncomeByID(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	income, err := h.service.FindIncomeByID(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, income.Version)
+
+	HTTPResponse(w, income, http.StatusOK)
+}
+
+func (h *incomeHandler) FindIncomeByMonth(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+
+	if err := models.ValidateYearMonth(month); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	incomes, err := h.service.FindIncomeByMonth(r.Context(), month)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, incomes, http.StatusOK)
+}
+
+func (h *incomeHandler) FindAllIncomes(w http.ResponseWriter, r *http.Request) {
+	incomes, err := h.service.FindAllIncomes(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, incomes, http.StatusOK)
+}
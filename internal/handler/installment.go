@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"log/slog"
 	"net/http"
 
 	"github.com/me/finance/internal/models"
@@ -53,14 +52,12 @@ func (i *installmentHandler) UpdateInstallment(w http.ResponseWriter, r *http.Re
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := i.service.UpdateInstalment(id); err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusInternalServerError)
+	if err := i.service.UpdateInstalment(r.Context(), id); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -74,15 +71,13 @@ func (i *installmentHandler) FindInstallmentByPurchaseID(w http.ResponseWriter,
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	response, err = i.service.FindInstallmentByPurchaseID(id)
+	response, err = i.service.FindInstallmentByPurchaseID(r.Context(), id)
 	if err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -90,18 +85,37 @@ func (i *installmentHandler) FindInstallmentByPurchaseID(w http.ResponseWriter,
 }
 
 func (i *installmentHandler) FindInstallmentByMonth(w http.ResponseWriter, r *http.Request) {
-	month := r.URL.Query().Get("month")
+	var (
+		installments models.InstallmentResponse
+		month        = r.URL.Query().Get("month")
+		person       = r.URL.Query().Get("person")
+	)
 
 	if err := models.ValidateYearMonth(month); err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	installments, err := i.service.FindInstallmentByMonth(month)
+	if person != "" {
+		personID, err := models.ValidateID(person)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		installments, err = i.service.FindInstallmentByMonthAndPerson(r.Context(), month, personID)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		HTTPResponse(w, installments, http.StatusOK)
+		return
+	}
+
+	installments, err := i.service.FindInstallmentByMonth(r.Context(), month)
 	if err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -110,10 +124,9 @@ func (i *installmentHandler) FindInstallmentByMonth(w http.ResponseWriter, r *ht
 }
 
 func (i *installmentHandler) FindInstallmentByNotPaid(w http.ResponseWriter, r *http.Request) {
-	installments, err := i.service.FindInstallmentByNotPaid()
+	installments, err := i.service.FindInstallmentByNotPaid(r.Context())
 	if err != nil {
-		slog.Error(err.Error())
-		HTTPResponse(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
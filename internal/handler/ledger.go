@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/me/finance/internal/service"
+)
+
+type LedgerHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	TrialBalance(w http.ResponseWriter, r *http.Request)
+	CheckIntegrity(w http.ResponseWriter, r *http.Request)
+}
+
+type ledgerHandler struct {
+	service service.LedgerService
+}
+
+func NewLedgerHandler(svc service.LedgerService) LedgerHandler {
+	return &ledgerHandler{service: svc}
+}
+
+func (h *ledgerHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/ledger/trialBalance", func(w http.ResponseWriter, r *http.Request) {
+		h.TrialBalance(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/ledger/integrity", func(w http.ResponseWriter, r *http.Request) {
+		h.CheckIntegrity(w, r)
+	})
+}
+
+func (h *ledgerHandler) TrialBalance(w http.ResponseWriter, r *http.Request) {
+	trialBalance, err := h.service.TrialBalance(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, trialBalance, http.StatusOK)
+}
+
+func (h *ledgerHandler) CheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	integrity, err := h.service.CheckIntegrity(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, integrity, http.StatusOK)
+}
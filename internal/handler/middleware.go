@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+// publicRoutes can be reached without a token. A token sent to them is still
+// checked, so an admin can register users even when registration is closed.
+var publicRoutes = map[string]bool{
+	"/health":           true,
+	"/v1/auth/register": true,
+	"/v1/auth/login":    true,
+	"/v1/auth/refresh":  true,
+	"/openapi.json":     true,
+	"/docs":             true,
+
+	"/docs/swagger-ui.css":       true,
+	"/docs/swagger-ui-bundle.js": true,
+}
+
+// RequestMetadata tags every request with an ID, reusing the X-Request-ID sent
+// by the client, so changes can be traced in the audit log.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set("X-Request-ID", requestID)
+
+		ctx := models.WithAuditMetadata(r.Context(), models.AuditMetadata{
+			RequestID: requestID,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate requires a valid access token or API key in the Authorization
+// header, except on the public routes, and records its user as the principal
+// of the request and the actor of the changes it makes. An API key is refused
+// the requests its scopes do not allow.
+func Authenticate(auth service.AuthService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+
+		if header == "" && publicRoutes[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			HTTPError(w, r, models.Unauthorized("authentication required, send an access token or API key as Authorization: Bearer <token>"))
+			return
+		}
+
+		principal, err := auth.Authenticate(token)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		if !principal.Allows(r.Method, r.URL.Path) {
+			HTTPError(w, r, models.Forbidden("the API key %s has no scope for %s %s", principal.APIKey.Name, r.Method, r.URL.Path))
+			return
+		}
+
+		metadata := models.AuditMetadataFrom(r.Context())
+		metadata.Actor = principal.User.Email
+
+		if principal.APIKey != nil {
+			metadata.Actor = fmt.Sprintf("%s (API key %s)", principal.User.Email, principal.APIKey.Name)
+		}
+
+		ctx := models.WithPrincipal(r.Context(), principal)
+		ctx = models.WithAuditMetadata(ctx, metadata)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Tenant scopes the request to a household of its user, taken from the
+// X-Household-ID header or, when the user has a single household, that one.
+// Routes that manage the user or the households themselves are not scoped.
+func Tenant(households service.HouseholdService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := models.PrincipalFrom(r.Context()); !ok || !scopedRoute(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		membership, err := households.Resolve(r.Context(), r.Header.Get("X-Household-ID"))
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		w.Header().Set("X-Household-ID", membership.IDHousehold.String())
+
+		next.ServeHTTP(w, r.WithContext(models.WithMembership(r.Context(), membership)))
+	})
+}
+
+// Policy enforces the role of the member on the routes scoped to a household:
+// reading routes need the read permission and every other route the write
+// permission, as laid out by models.MethodPermission.
+func Policy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		membership, ok := models.MembershipFrom(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := models.Authorize(membership.Role, models.MethodPermission(r.Method)); err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func scopedRoute(path string) bool {
+	return !publicRoutes[path] && !strings.HasPrefix(path, "/v1/auth/") &&
+		!strings.HasPrefix(path, "/v1/households") && !strings.HasPrefix(path, "/v1/apiKeys")
+}
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+// operation documents a route of the API. The schemas of Request and Response
+// are taken from their types, so the document follows the models as they
+// change; a nil Response is the success message most routes answer with.
+type operation struct {
+	Method   string
+	Path     string
+	Tag      string
+	Summary  string
+	Query    []parameter
+	Request  any
+	Response any
+	Status   int
+	IfMatch  bool
+	ETag     bool
+	Location bool
+	Text     bool
+}
+
+type parameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// pathParameter finds the wildcards of a route pattern, as in /v1/purchases/{id}.
+var pathParameter = regexp.MustCompile(`{([^}]+)}`)
+
+// swaggerUI holds the page of the Swagger UI and the swagger-ui-dist assets it
+// loads, served with the API so the docs work offline. Run go generate to
+// fetch the assets of the pinned version.
+//
+//go:generate sh -c "curl -sSfL https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-5.17.14.tgz | tar -xzf - -C swaggerui --strip-components=1 package/swagger-ui.css package/swagger-ui-bundle.js"
+//go:embed swaggerui
+var swaggerUI embed.FS
+
+// OpenAPIHandler serves the OpenAPI document of the API at /openapi.json and a
+// Swagger UI to browse it at /docs.
+type OpenAPIHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+}
+
+type openAPIHandler struct {
+	document []byte
+	assets   fs.FS
+}
+
+func NewOpenAPIHandler() (OpenAPIHandler, error) {
+	document, err := json.Marshal(OpenAPI())
+	if err != nil {
+		return nil, fmt.Errorf("error encoding openapi document: %w", err)
+	}
+
+	assets, err := fs.Sub(swaggerUI, "swaggerui")
+	if err != nil {
+		return nil, fmt.Errorf("error opening swagger ui assets: %w", err)
+	}
+
+	return &openAPIHandler{document: document, assets: assets}, nil
+}
+
+func (h *openAPIHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(h.document)
+	})
+
+	mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, h.assets, "index.html")
+	})
+
+	mux.Handle("GET /docs/", http.StripPrefix("/docs/", http.FileServerFS(h.assets)))
+}
+
+// OpenAPI builds the OpenAPI 3 document of every route in operations.
+func OpenAPI() map[string]any {
+	s := schemas{components: map[string]any{}}
+
+	paths := map[string]map[string]any{}
+
+	for _, op := range operations {
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]any{}
+		}
+
+		paths[op.Path][strings.ToLower(op.Method)] = s.operation(op)
+	}
+
+	// Problem is referenced by every response, the others are named by the
+	// parameters that change what a route answers.
+	for _, model := range []any{models.Problem{}, models.ReassignResponse{}, models.IncomeResponseTotal{}} {
+		s.schema(reflect.TypeOf(model))
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Finance API",
+			"version": "1.0.0",
+			"description": "Household finances: purchases and their installments, incomes, accounts, settlements between people and reports. " +
+				"Every /v1 route is served under /v2 as well, answering with a {data, meta, errors} envelope with snake_case keys.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": s.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "An access token from /v1/auth/login or an API key from /v1/apiKeys.",
+				},
+			},
+			"parameters": map[string]any{
+				"HouseholdID": map[string]any{
+					"name":        "X-Household-ID",
+					"in":          "header",
+					"description": "The household of the request, needed when the user is a member of more than one.",
+					"schema":      map[string]any{"type": "string", "format": "uuid"},
+				},
+				"IdempotencyKey": map[string]any{
+					"name":        "Idempotency-Key",
+					"in":          "header",
+					"description": "Makes the request safe to retry: a retry with the same key and body gets the first response back.",
+					"schema":      map[string]any{"type": "string", "maxLength": models.IdempotencyKeyMaxLength},
+				},
+				"IfMatch": map[string]any{
+					"name":        "If-Match",
+					"in":          "header",
+					"required":    true,
+					"description": "The ETag of the record, as returned when it was read.",
+					"schema":      map[string]any{"type": "string"},
+				},
+			},
+			"responses": map[string]any{
+				"Problem": map[string]any{
+					"description": "The request failed, as described by an RFC 7807 problem.",
+					"content": map[string]any{
+						"application/problem+json": map[string]any{"schema": ref("Problem")},
+					},
+				},
+			},
+		},
+		"security": []any{map[string]any{"bearerAuth": []string{}}},
+	}
+}
+
+// schemas turns Go types into JSON schemas, keeping every named struct as a
+// component referenced by name.
+type schemas struct {
+	components map[string]any
+}
+
+func (s *schemas) operation(op operation) map[string]any {
+	var parameters []any
+
+	for _, match := range pathParameter.FindAllStringSubmatch(op.Path, -1) {
+		schema := map[string]any{"type": "string", "format": "uuid"}
+		if match[1] == "entity" {
+			schema = map[string]any{"type": "string"}
+		}
+
+		parameters = append(parameters, map[string]any{"name": match[1], "in": "path", "required": true, "schema": schema})
+	}
+
+	for _, q := range op.Query {
+		parameters = append(parameters, map[string]any{
+			"name":        q.Name,
+			"in":          "query",
+			"required":    q.Required,
+			"description": q.Description,
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+
+	if op.IfMatch {
+		parameters = append(parameters, map[string]any{"$ref": "#/components/parameters/IfMatch"})
+	}
+
+	if op.Method == http.MethodPost && !publicRoutes[op.Path] {
+		parameters = append(parameters, map[string]any{"$ref": "#/components/parameters/IdempotencyKey"})
+	}
+
+	if scopedRoute(op.Path) {
+		parameters = append(parameters, map[string]any{"$ref": "#/components/parameters/HouseholdID"})
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := map[string]any{"description": http.StatusText(status)}
+
+	if op.Text {
+		success["content"] = map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}}
+	} else {
+		message := map[string]any{"type": "string"}
+		if op.Response != nil {
+			message = s.schema(reflect.TypeOf(op.Response))
+		}
+
+		success["content"] = map[string]any{"application/json": map[string]any{"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"StatusCode": map[string]any{"type": "integer"},
+				"Message":    message,
+			},
+		}}}
+	}
+
+	headers := map[string]any{}
+
+	if op.ETag {
+		headers["ETag"] = map[string]any{
+			"description": "The version of the record, to send back in If-Match.",
+			"schema":      map[string]any{"type": "string"},
+		}
+	}
+
+	if op.Location {
+		headers["Location"] = map[string]any{
+			"description": "The route of the created record.",
+			"schema":      map[string]any{"type": "string"},
+		}
+	}
+
+	if len(headers) > 0 {
+		success["headers"] = headers
+	}
+
+	result := map[string]any{
+		"tags":        []string{op.Tag},
+		"summary":     op.Summary,
+		"operationId": operationID(op),
+		"responses": map[string]any{
+			fmt.Sprint(status): success,
+			"default":          map[string]any{"$ref": "#/components/responses/Problem"},
+		},
+	}
+
+	if len(parameters) > 0 {
+		result["parameters"] = parameters
+	}
+
+	if op.Request != nil {
+		// A PATCH takes a merge patch of the record: any of its fields, with
+		// null clearing one.
+		contentType := "application/json"
+		if op.Method == http.MethodPatch {
+			contentType = models.MergePatchContentType
+		}
+
+		result["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{contentType: map[string]any{"schema": s.schema(reflect.TypeOf(op.Request))}},
+		}
+	}
+
+	if publicRoutes[op.Path] {
+		result["security"] = []any{map[string]any{}, map[string]any{"bearerAuth": []string{}}}
+	}
+
+	return result
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	uuidType     = reflect.TypeOf(uuid.UUID{})
+	nullUUIDType = reflect.TypeOf(uuid.NullUUID{})
+	rawType      = reflect.TypeOf(json.RawMessage{})
+)
+
+func (s *schemas) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case nullUUIDType:
+		return map[string]any{"type": "string", "format": "uuid", "nullable": true}
+	case rawType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.schema(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+
+		schema["nullable"] = true
+
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+
+		if _, done := s.components[t.Name()]; !done {
+			s.components[t.Name()] = map[string]any{}
+			s.components[t.Name()] = s.object(t)
+		}
+
+		return ref(t.Name())
+	default:
+		return map[string]any{}
+	}
+}
+
+// object describes the fields of a struct the way encoding/json writes them,
+// embedded structs included.
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.schema(field.Type)
+	}
+
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// operationID names an operation after its method and path, as in
+// getV1PurchasesId.
+func operationID(op operation) string {
+	id := strings.ToLower(op.Method)
+
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '{' || r == '}' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	return id
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+)
+
+var (
+	monthQuery      = parameter{Name: "month", Description: "The month, as YYYY-MM.", Required: true}
+	reassignToQuery = parameter{Name: "reassignTo", Description: "Move what references the record to this one before deleting it, answering with a ReassignResponse."}
+)
+
+// operations is every route of the API, as documented at /openapi.json.
+var operations = []operation{
+	{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Check the server is up", Text: true},
+
+	{Method: http.MethodPost, Path: "/v1/auth/register", Tag: "auth", Summary: "Register a user", Request: models.RegisterRequest{}, Response: models.User{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/v1/auth/login", Tag: "auth", Summary: "Log in and start a session", Request: models.LoginRequest{}, Response: models.TokenResponse{}},
+	{Method: http.MethodPost, Path: "/v1/auth/refresh", Tag: "auth", Summary: "Trade a refresh token for new tokens", Request: models.RefreshRequest{}, Response: models.TokenResponse{}},
+	{Method: http.MethodPost, Path: "/v1/auth/logout", Tag: "auth", Summary: "End the session"},
+	{Method: http.MethodGet, Path: "/v1/auth/me", Tag: "auth", Summary: "Find the user of the request", Response: models.User{}},
+
+	{Method: http.MethodPost, Path: "/v1/apiKeys", Tag: "apiKeys", Summary: "Create an API key, shown only in this response", Request: models.APIKeyRequest{}, Response: models.APIKeyCreated{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/v1/apiKeys", Tag: "apiKeys", Summary: "List the API keys of the user", Response: []models.APIKey{}},
+	{Method: http.MethodDelete, Path: "/v1/apiKeys/{id}", Tag: "apiKeys", Summary: "Revoke an API key"},
+
+	{Method: http.MethodPost, Path: "/v1/households", Tag: "households", Summary: "Create a household owned by the user", Request: models.Household{}, Response: models.Household{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/v1/households", Tag: "households", Summary: "List the households of the user", Response: []models.Household{}},
+	{Method: http.MethodGet, Path: "/v1/households/{id}/members", Tag: "households", Summary: "List the members of a household", Response: []models.HouseholdMember{}},
+	{Method: http.MethodGet, Path: "/v1/households/{id}/members/{userID}", Tag: "households", Summary: "Find a member of a household", Response: models.HouseholdMember{}},
+	{Method: http.MethodPost, Path: "/v1/households/{id}/members", Tag: "households", Summary: "Add a member to a household", Request: models.MemberRequest{}, Response: models.HouseholdMember{}, Status: http.StatusCreated, Location: true},
+	{Method: http.MethodPut, Path: "/v1/households/{id}/members/{userID}", Tag: "households", Summary: "Change the role of a member", Request: models.RoleRequest{}},
+	{Method: http.MethodDelete, Path: "/v1/households/{id}/members/{userID}", Tag: "households", Summary: "Remove a member from a household"},
+
+	{Method: http.MethodGet, Path: "/v1/audit", Tag: "audit", Summary: "List the latest changes", Response: []models.AuditEvent{}, Query: []parameter{
+		{Name: "entity", Description: "Only changes to this kind of record."},
+		{Name: "id", Description: "Only changes to this record."},
+		{Name: "actor", Description: "Only changes made by this user."},
+		{Name: "from", Description: "Only changes from this date, as YYYY-MM-DD."},
+		{Name: "to", Description: "Only changes up to this date, as YYYY-MM-DD."},
+		{Name: "limit", Description: "How many changes to return at most."},
+	}},
+
+	{Method: http.MethodPost, Path: "/v1/persons", Tag: "persons", Summary: "Create a person", Request: models.Person{}, Response: models.Person{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/persons", Tag: "persons", Summary: "Update a person", Request: models.Person{}, IfMatch: true},
+	{Method: http.MethodPatch, Path: "/v1/persons/{id}", Tag: "persons", Summary: "Change some fields of a person", Request: models.Person{}, Response: models.Person{}, IfMatch: true, ETag: true},
+	{Method: http.MethodDelete, Path: "/v1/persons/{id}", Tag: "persons", Summary: "Delete a person", Query: []parameter{reassignToQuery}, IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/persons/{id}", Tag: "persons", Summary: "Find a person", Response: models.Person{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/persons", Tag: "persons", Summary: "List the persons", Response: []models.Person{}},
+
+	{Method: http.MethodPost, Path: "/v1/creditCards", Tag: "creditCards", Summary: "Create a credit card", Request: models.CreditCard{}, Response: models.CreditCard{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/creditCards", Tag: "creditCards", Summary: "Update a credit card", Request: models.CreditCard{}, IfMatch: true},
+	{Method: http.MethodPatch, Path: "/v1/creditCards/{id}", Tag: "creditCards", Summary: "Change some fields of a credit card", Request: models.CreditCard{}, Response: models.CreditCard{}, IfMatch: true, ETag: true},
+	{Method: http.MethodDelete, Path: "/v1/creditCards/{id}", Tag: "creditCards", Summary: "Delete a credit card", Query: []parameter{reassignToQuery}, IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/creditCards/{id}", Tag: "creditCards", Summary: "Find a credit card", Response: models.CreditCard{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/creditCards", Tag: "creditCards", Summary: "List the credit cards", Response: []models.CreditCard{}},
+
+	{Method: http.MethodPost, Path: "/v1/paymentTypes", Tag: "paymentTypes", Summary: "Create a payment type", Request: models.PaymentType{}, Response: models.PaymentType{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/paymentTypes", Tag: "paymentTypes", Summary: "Update a payment type", Request: models.PaymentType{}, IfMatch: true},
+	{Method: http.MethodPatch, Path: "/v1/paymentTypes/{id}", Tag: "paymentTypes", Summary: "Change some fields of a payment type", Request: models.PaymentType{}, Response: models.PaymentType{}, IfMatch: true, ETag: true},
+	{Method: http.MethodDelete, Path: "/v1/paymentTypes/{id}", Tag: "paymentTypes", Summary: "Delete a payment type", Query: []parameter{reassignToQuery}, IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/paymentTypes/{id}", Tag: "paymentTypes", Summary: "Find a payment type", Response: models.PaymentType{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/paymentTypes", Tag: "paymentTypes", Summary: "List the payment types", Response: []models.PaymentType{}},
+
+	{Method: http.MethodPost, Path: "/v1/purchaseTypes", Tag: "purchaseTypes", Summary: "Create a purchase type", Request: models.PurchaseType{}, Response: models.PurchaseType{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/purchaseTypes", Tag: "purchaseTypes", Summary: "Update a purchase type", Request: models.PurchaseType{}, IfMatch: true},
+	{Method: http.MethodPatch, Path: "/v1/purchaseTypes/{id}", Tag: "purchaseTypes", Summary: "Change some fields of a purchase type", Request: models.PurchaseType{}, Response: models.PurchaseType{}, IfMatch: true, ETag: true},
+	{Method: http.MethodDelete, Path: "/v1/purchaseTypes/{id}", Tag: "purchaseTypes", Summary: "Delete a purchase type", Query: []parameter{reassignToQuery}, IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/purchaseTypes/{id}", Tag: "purchaseTypes", Summary: "Find a purchase type", Response: models.PurchaseType{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/purchaseTypes", Tag: "purchaseTypes", Summary: "List the purchase types as a tree", Response: []models.PurchaseType{}, Query: []parameter{
+		{Name: "flat", Description: "With true, list them flat instead of as a tree."},
+	}},
+
+	{Method: http.MethodPost, Path: "/v1/tags", Tag: "tags", Summary: "Create a tag", Request: models.Tag{}, Response: models.Tag{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/tags", Tag: "tags", Summary: "Update a tag", Request: models.Tag{}, IfMatch: true},
+	{Method: http.MethodDelete, Path: "/v1/tags/{id}", Tag: "tags", Summary: "Delete a tag", IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/tags/{id}", Tag: "tags", Summary: "Find a tag", Response: models.Tag{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/tags", Tag: "tags", Summary: "List the tags", Response: []models.Tag{}},
+
+	{Method: http.MethodPost, Path: "/v1/purchases", Tag: "purchases", Summary: "Create a purchase and its installments", Request: models.PurchaseRequest{}, Response: models.PurchaseCreated{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPost, Path: "/v1/purchases/batch", Tag: "purchases", Summary: "Create and update many purchases at once, atomically or best effort", Request: models.PurchaseBatchRequest{}, Response: models.PurchaseBatchResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/v1/purchases", Tag: "purchases", Summary: "Update a purchase", Request: models.PurchaseRequest{}, IfMatch: true},
+	{Method: http.MethodPatch, Path: "/v1/purchases/{id}", Tag: "purchases", Summary: "Change some fields of a purchase, rebuilding its installments", Request: models.PurchaseRequest{}, Response: models.PurchaseResponse{}, IfMatch: true, ETag: true},
+	{Method: http.MethodDelete, Path: "/v1/purchases/{id}", Tag: "purchases", Summary: "Delete a purchase", IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/purchases/{id}", Tag: "purchases", Summary: "Find a purchase", Response: models.PurchaseResponse{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/purchases", Tag: "purchases", Summary: "List the purchases, filtered by at most one of the query parameters", Response: models.PurchaseResponseTotal{}, Query: []parameter{
+		{Name: "date", Description: "Only purchases of this date, as YYYY-MM-DD."},
+		{Name: "month", Description: "Only purchases of this month, as YYYY-MM."},
+		{Name: "person", Description: "Only purchases of this person."},
+		{Name: "tags", Description: "Only purchases with these tags, as comma separated ids."},
+		{Name: "match", Description: "With tags, all to need every tag instead of any of them."},
+	}},
+	{Method: http.MethodPost, Path: "/v1/purchases/{id}/refunds", Tag: "purchases", Summary: "Refund a purchase", Request: models.Refund{}, Response: models.Refund{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/v1/purchases/{id}/refunds", Tag: "purchases", Summary: "List the refunds of a purchase", Response: []models.Refund{}},
+
+	{Method: http.MethodPut, Path: "/v1/installments/{id}", Tag: "installments", Summary: "Mark an installment as paid"},
+	{Method: http.MethodGet, Path: "/v1/installments/{id}", Tag: "installments", Summary: "List the installments of a purchase", Response: models.InstallmentResponse{}},
+	{Method: http.MethodGet, Path: "/v1/installments", Tag: "installments", Summary: "List the installments of a month", Response: models.InstallmentResponse{}, Query: []parameter{
+		monthQuery,
+		{Name: "person", Description: "Only installments of this person."},
+	}},
+	{Method: http.MethodGet, Path: "/v1/installments/notPaid", Tag: "installments", Summary: "List the installments not paid yet", Response: models.InstallmentResponse{}},
+
+	{Method: http.MethodPost, Path: "/v1/accounts", Tag: "accounts", Summary: "Create an account", Request: models.Account{}, Response: models.Account{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/accounts", Tag: "accounts", Summary: "Update an account", Request: models.Account{}, IfMatch: true},
+	{Method: http.MethodDelete, Path: "/v1/accounts/{id}", Tag: "accounts", Summary: "Delete an account", IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/accounts/{id}", Tag: "accounts", Summary: "Find an account", Response: models.Account{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/accounts", Tag: "accounts", Summary: "List the accounts", Response: []models.Account{}},
+	{Method: http.MethodGet, Path: "/v1/accounts/{id}/balance", Tag: "accounts", Summary: "Find the balance and statement of an account", Response: models.AccountStatement{}, Query: []parameter{
+		{Name: "date", Description: "The balance at this date, as YYYY-MM-DD, today by default."},
+	}},
+	{Method: http.MethodPost, Path: "/v1/transfers", Tag: "accounts", Summary: "Transfer between accounts", Request: models.AccountTransfer{}, Response: models.AccountTransfer{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/v1/invoicePayments", Tag: "accounts", Summary: "Pay a credit card invoice from an account", Request: models.InvoicePayment{}, Response: models.InvoicePayment{}, Status: http.StatusCreated},
+
+	{Method: http.MethodPost, Path: "/v1/incomes", Tag: "incomes", Summary: "Create an income", Request: models.Income{}, Response: models.Income{}, Status: http.StatusCreated, Location: true, ETag: true},
+	{Method: http.MethodPut, Path: "/v1/incomes", Tag: "incomes", Summary: "Update an income", Request: models.Income{}, IfMatch: true},
+	{Method: http.MethodDelete, Path: "/v1/incomes/{id}", Tag: "incomes", Summary: "Delete an income", IfMatch: true},
+	{Method: http.MethodGet, Path: "/v1/incomes/{id}", Tag: "incomes", Summary: "Find an income", Response: models.Income{}, ETag: true},
+	{Method: http.MethodGet, Path: "/v1/incomes", Tag: "incomes", Summary: "List the incomes, or those of a month with their total", Response: []models.Income{}, Query: []parameter{
+		{Name: "month", Description: "Only incomes of this month, as YYYY-MM, answered as an IncomeResponseTotal."},
+	}},
+
+	{Method: http.MethodPost, Path: "/v1/settlements", Tag: "settlements", Summary: "Record a settlement between persons", Request: models.Settlement{}, Response: models.Settlement{}, Status: http.StatusCreated, Location: true},
+	{Method: http.MethodDelete, Path: "/v1/settlements/{id}", Tag: "settlements", Summary: "Delete a settlement"},
+	{Method: http.MethodGet, Path: "/v1/settlements/{id}", Tag: "settlements", Summary: "Find a settlement", Response: models.Settlement{}},
+	{Method: http.MethodGet, Path: "/v1/settlements", Tag: "settlements", Summary: "List the settlements", Response: []models.Settlement{}},
+	{Method: http.MethodGet, Path: "/v1/settlements/balances", Tag: "settlements", Summary: "Find what each person owes", Response: models.BalanceResponse{}},
+	{Method: http.MethodGet, Path: "/v1/settlements/plan", Tag: "settlements", Summary: "Find the fewest transfers that settle every debt", Response: models.SettlementPlanResponse{}},
+
+	{Method: http.MethodGet, Path: "/v1/ledger/trialBalance", Tag: "ledger", Summary: "Find the trial balance of the ledger", Response: models.TrialBalance{}},
+	{Method: http.MethodGet, Path: "/v1/ledger/integrity", Tag: "ledger", Summary: "Check the ledger against the records", Response: models.IntegrityResponse{}},
+
+	{Method: http.MethodGet, Path: "/v1/reports/tags", Tag: "reports", Summary: "Find the totals by tag of a month", Response: models.ReportTagResponse{}, Query: []parameter{monthQuery}},
+	{Method: http.MethodGet, Path: "/v1/reports/purchaseTypes", Tag: "reports", Summary: "Find the totals by purchase type of a month", Response: models.ReportPurchaseTypeResponse{}, Query: []parameter{monthQuery}},
+	{Method: http.MethodGet, Path: "/v1/reports/balance", Tag: "reports", Summary: "Find the balance of a month", Response: models.MonthlyBalance{}, Query: []parameter{monthQuery}},
+
+	{Method: http.MethodGet, Path: "/v1/export", Tag: "export", Summary: "Export the journal as plain text accounting", Text: true, Query: []parameter{
+		{Name: "format", Description: "ledger, hledger or beancount.", Required: true},
+		{Name: "from", Description: "Only transactions from this date, as YYYY-MM-DD."},
+		{Name: "to", Description: "Only transactions up to this date, as YYYY-MM-DD."},
+	}},
+
+	{Method: http.MethodGet, Path: "/v1/trash", Tag: "trash", Summary: "List the deleted records", Response: []models.TrashItem{}},
+	{Method: http.MethodPost, Path: "/v1/trash/{entity}/{id}/restore", Tag: "trash", Summary: "Restore a deleted record"},
+	{Method: http.MethodDelete, Path: "/v1/trash", Tag: "trash", Summary: "Purge the records deleted before the retention", Response: models.PurgeResponse{}, Query: []parameter{
+		{Name: "olderThan", Description: "Purge what was deleted more than this many days ago, instead of the configured retention."},
+	}},
+}
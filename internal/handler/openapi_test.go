@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// routeMux registers the routes of every handler, as cmd/main.go does. The
+// handlers are not called, so they need no services.
+func routeMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, h := range []interface{ RegisterRoutes(*http.ServeMux) }{
+		NewAuthHandler(nil),
+		NewHouseholdHandler(nil),
+		NewAPIKeyHandler(nil),
+		NewAuditHandler(nil),
+		NewPersonHandler(nil),
+		NewCreditCardHandler(nil),
+		NewPaymentTypeHandler(nil),
+		NewPurchaseTypeHandler(nil),
+		NewLedgerHandler(nil),
+		NewAccountHandler(nil),
+		NewTagHandler(nil),
+		NewInstallmentHandler(nil),
+		NewPurchaseHandler(nil),
+		NewTrashHandler(nil, 0),
+		NewSettlementHandler(nil),
+		NewIncomeHandler(nil),
+		NewExportHandler(nil),
+		NewReportHandler(nil),
+	} {
+		h.RegisterRoutes(mux)
+	}
+
+	openAPI, err := NewOpenAPIHandler()
+	if err != nil {
+		t.Fatalf("building the openapi handler: %v", err)
+	}
+
+	openAPI.RegisterRoutes(mux)
+
+	return mux
+}
+
+// TestOperationsAreRegistered checks that every documented route is served,
+// so the document cannot promise routes that were renamed or removed.
+func TestOperationsAreRegistered(t *testing.T) {
+	mux := routeMux(t)
+
+	for _, op := range operations {
+		path := pathParameter.ReplaceAllString(op.Path, "x")
+
+		_, pattern := mux.Handler(httptest.NewRequest(op.Method, path, nil))
+		if pattern != op.Method+" "+op.Path && pattern != op.Path {
+			t.Errorf("%s %s is documented but not registered", op.Method, op.Path)
+		}
+	}
+}
+
+var handleFunc = regexp.MustCompile(`mux\.HandleFunc\("([A-Z]+) ([^"]+)"`)
+
+// TestRoutesAreDocumented checks that every route the handlers register is in
+// operations. The patterns are read from the sources, as a ServeMux cannot
+// list them.
+func TestRoutesAreDocumented(t *testing.T) {
+	documented := map[string]bool{}
+	for _, op := range operations {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || file == "openapi.go" {
+			continue
+		}
+
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, match := range handleFunc.FindAllStringSubmatch(string(source), -1) {
+			if route := match[1] + " " + match[2]; !documented[route] {
+				t.Errorf("%s is registered in %s but not documented", route, file)
+			}
+		}
+	}
+}
+
+// TestSchemasMatchJSON checks the properties of the request and response
+// schemas against the fields encoding/json reads and writes.
+func TestSchemasMatchJSON(t *testing.T) {
+	components := OpenAPI()["components"].(map[string]any)["schemas"].(map[string]any)
+
+	for _, op := range operations {
+		for _, model := range []any{op.Request, op.Response} {
+			if model == nil {
+				continue
+			}
+
+			typ := reflect.TypeOf(model)
+			for typ.Kind() == reflect.Slice || typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+
+			if typ.Kind() != reflect.Struct || typ.Name() == "" {
+				continue
+			}
+
+			schema, ok := components[typ.Name()].(map[string]any)
+			if !ok {
+				t.Errorf("%s %s: no schema for %s", op.Method, op.Path, typ.Name())
+				continue
+			}
+
+			var properties []string
+			for name := range schema["properties"].(map[string]any) {
+				properties = append(properties, name)
+			}
+
+			if got, want := sorted(properties), jsonFields(t, typ); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: schema properties %v, json fields %v", typ.Name(), got, want)
+			}
+		}
+	}
+}
+
+// jsonFields returns the names encoding/json gives the fields of the type:
+// those of a zero value it writes, and those it only writes when set.
+func jsonFields(t *testing.T, typ reflect.Type) []string {
+	t.Helper()
+
+	encoded, err := json.Marshal(reflect.New(typ).Interface())
+	if err != nil {
+		t.Fatalf("encoding %s: %v", typ.Name(), err)
+	}
+
+	fields := map[string]any{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatalf("decoding %s: %v", typ.Name(), err)
+	}
+
+	for _, field := range reflect.VisibleFields(typ) {
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.IsExported() && !field.Anonymous && name != "-" && name != "" && strings.Contains(options, "omitempty") {
+			fields[name] = nil
+		}
+	}
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	return sorted(names)
+}
+
+func sorted(names []string) []string {
+	sort.Strings(names)
+
+	return names
+}
+
+func TestDocsServeEmbeddedSwaggerUI(t *testing.T) {
+	mux := routeMux(t)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /docs answered %d", w.Code)
+	}
+
+	page := w.Body.String()
+	if !strings.Contains(page, `href="/docs/swagger-ui.css"`) || strings.Contains(page, "unpkg.com") {
+		t.Errorf("the docs page does not load the embedded assets:\n%s", page)
+	}
+
+	for _, asset := range []string{"/docs/swagger-ui.css", "/docs/swagger-ui-bundle.js"} {
+		if !publicRoutes[asset] {
+			t.Errorf("%s needs a token", asset)
+		}
+	}
+}
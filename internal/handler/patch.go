@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+)
+
+var errMergePatchType = errors.New("the patch must be sent as " + models.MergePatchContentType)
+
+// readMergePatch reads the RFC 7396 merge patch of a PATCH request. Plain
+// application/json is taken as well, for clients that cannot set the type.
+func readMergePatch(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != models.MergePatchContentType && mediaType != "application/json" {
+		return nil, errMergePatchType
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, models.Invalid("Error reading merge patch: %v", err)
+	}
+
+	return patch, nil
+}
@@ -7,13 +7,13 @@ import (
 
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/service"
-	"github.com/sagikazarmark/slog-shim"
 )
 
 type PaymentTypeHandler interface {
 	RegisterRoutes(mux *http.ServeMux)
 	CreatePaymentType(w http.ResponseWriter, r *http.Request)
 	UpdatePaymentType(w http.ResponseWriter, r *http.Request)
+	PatchPaymentType(w http.ResponseWriter, r *http.Request)
 	DeletePaymentType(w http.ResponseWriter, r *http.Request)
 	FindPaymentTypeByID(w http.ResponseWriter, r *http.Request)
 	FindAllPaymentTypes(w http.ResponseWriter, r *http.Request)
@@ -36,6 +36,10 @@ func (h *paymentTypeHandler) RegisterRoutes(mux *http.ServeMux) {
 		h.UpdatePaymentType(w, r)
 	})
 
+	mux.HandleFunc("PATCH /v1/paymentTypes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.PatchPaymentType(w, r)
+	})
+
 	mux.HandleFunc("DELETE /v1/paymentTypes/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.DeletePaymentType(w, r)
 	})
@@ -54,24 +58,23 @@ func (pt *paymentTypeHandler) CreatePaymentType(w http.ResponseWriter, r *http.R
 
 	err := json.NewDecoder(r.Body).Decode(&paymentType)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding payment: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding payment: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding payment: %v", err))
 		return
 	}
 
 	if err := paymentType.Validate(true); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := pt.service.CreatePaymentType(paymentType); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	created, err := pt.service.CreatePaymentType(r.Context(), paymentType)
+	if err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
-	HTTPResponse(w, fmt.Sprintf("Payment Type was created with success!"), http.StatusCreated)
+	setETag(w, created.Version)
+	HTTPCreated(w, location("paymentTypes", created.ID), created)
 }
 
 func (pt *paymentTypeHandler) UpdatePaymentType(w http.ResponseWriter, r *http.Request) {
@@ -79,40 +82,93 @@ func (pt *paymentTypeHandler) UpdatePaymentType(w http.ResponseWriter, r *http.R
 
 	err := json.NewDecoder(r.Body).Decode(&paymentType)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding Payment Type: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding Payment Type: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding Payment Type: %v", err))
 		return
 	}
 
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	paymentType.Version = version
+
 	if err := paymentType.Validate(false); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := pt.service.UpdatePaymentType(paymentType); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := pt.service.UpdatePaymentType(r.Context(), paymentType); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
 	HTTPResponse(w, fmt.Sprint("Payment Type was updated with success!"), http.StatusOK)
 }
 
+func (pt *paymentTypeHandler) PatchPaymentType(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	patch, err := readMergePatch(r)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	paymentType, err := pt.service.PatchPaymentType(r.Context(), id, patch, version)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, paymentType.Version)
+
+	HTTPResponse(w, paymentType, http.StatusOK)
+}
+
 func (pt *paymentTypeHandler) DeletePaymentType(w http.ResponseWriter, r *http.Request) {
 	idRequest := r.PathValue("id")
 	
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
-	err = pt.service.DeletePaymentType(id)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if reassignTo := r.URL.Query().Get("reassignTo"); reassignTo != "" {
+		to, err := models.ValidateReassign(id, reassignTo)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		response, err := pt.service.ReassignPaymentType(r.Context(), id, to, version)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		HTTPResponse(w, response, http.StatusOK)
+		return
+	}
+
+	err = pt.service.DeletePaymentType(r.Context(), id, version)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -124,26 +180,25 @@ func (pt *paymentTypeHandler) FindPaymentTypeByID(w http.ResponseWriter, r *http
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		fmt.Sprintf(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 	
-	paymentType, err := pt.service.FindPaymentTypeByID(id)
+	paymentType, err := pt.service.FindPaymentTypeByID(r.Context(), id)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
+	setETag(w, paymentType.Version)
+
 	HTTPResponse(w, paymentType, http.StatusOK)
 }
 
 func (pt *paymentTypeHandler) FindAllPaymentTypes(w http.ResponseWriter, r *http.Request) {
-	paymentTypes, err := pt.service.FindAllPaymentTypes()
+	paymentTypes, err := pt.service.FindAllPaymentTypes(r.Context())
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
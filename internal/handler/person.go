@@ -2,8 +2,6 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log/slog"
 	"net/http"
 
 	"github.com/me/finance/internal/models"
@@ -14,6 +12,7 @@ type PersonHandler interface {
 	RegisterRoutes(mux *http.ServeMux)
 	CreatePerson(w http.ResponseWriter, r *http.Request)
 	UpdatePerson(w http.ResponseWriter, r *http.Request)
+	PatchPerson(w http.ResponseWriter, r *http.Request)
 	DeletePerson(w http.ResponseWriter, r *http.Request)
 	FindPersonByID(w http.ResponseWriter, r *http.Request)
 	FindAllPersons(w http.ResponseWriter, r *http.Request)
@@ -36,6 +35,10 @@ func (h *personHandler) RegisterRoutes(mux *http.ServeMux) {
 		h.UpdatePerson(w, r)
 	})
 
+	mux.HandleFunc("PATCH /v1/persons/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.PatchPerson(w, r)
+	})
+
 	mux.HandleFunc("DELETE /v1/persons/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.DeletePerson(w, r)
 	})
@@ -54,24 +57,23 @@ func (h *personHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&person)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding person: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding person: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding person: %v", err))
 		return
 	}
 
 	if err := person.Validate(true); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := h.service.CreatePerson(person); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	created, err := h.service.CreatePerson(r.Context(), person)
+	if err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
-	HTTPResponse(w, "Person was created with success!", http.StatusCreated)
+	setETag(w, created.Version)
+	HTTPCreated(w, location("persons", created.ID), created)
 }
 
 func (h *personHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
@@ -79,39 +81,92 @@ func (h *personHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&person)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding person: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding person: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding person: %v", err))
 		return
 	}
 
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	person.Version = version
+
 	if err := person.Validate(false); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := h.service.UpdatePerson(person); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.UpdatePerson(r.Context(), person); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
 	HTTPResponse(w, "Person was updated with success!", http.StatusOK)
 }
 
+func (h *personHandler) PatchPerson(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	patch, err := readMergePatch(r)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	person, err := h.service.PatchPerson(r.Context(), id, patch, version)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, person.Version)
+
+	HTTPResponse(w, person, http.StatusOK)
+}
+
 func (h *personHandler) DeletePerson(w http.ResponseWriter, r *http.Request) {
 	idRequest := r.PathValue("id")
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := h.service.DeletePerson(id); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if reassignTo := r.URL.Query().Get("reassignTo"); reassignTo != "" {
+		to, err := models.ValidateReassign(id, reassignTo)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		response, err := h.service.ReassignPerson(r.Context(), id, to, version)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		HTTPResponse(w, response, http.StatusOK)
+		return
+	}
+
+	if err := h.service.DeletePerson(r.Context(), id, version); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -123,26 +178,25 @@ func (h *personHandler) FindPersonByID(w http.ResponseWriter, r *http.Request) {
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	person, err := h.service.FindPersonByID(id)
+	person, err := h.service.FindPersonByID(r.Context(), id)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusNotFound)
+		HTTPError(w, r, err)
 		return
 	}
 
+	setETag(w, person.Version)
+
 	HTTPResponse(w, person, http.StatusOK)
 }
 
 func (h *personHandler) FindAllPersons(w http.ResponseWriter, r *http.Request) {
-	persons, err := h.service.FindAllPersons()
+	persons, err := h.service.FindAllPersons(r.Context())
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
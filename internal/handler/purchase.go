@@ -2,9 +2,10 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/service"
 	"github.com/sagikazarmark/slog-shim"
@@ -14,12 +15,17 @@ type PurchaseHandler interface {
 	RegisterRoutes(mux *http.ServeMux)
 	Create(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
+	Patch(w http.ResponseWriter, r *http.Request)
+	Batch(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 	FindByID(w http.ResponseWriter, r *http.Request)
 	FindByDate(w http.ResponseWriter, r *http.Request)
 	FindByMonth(w http.ResponseWriter, r *http.Request)
 	FindByPerson(w http.ResponseWriter, r *http.Request)
+	FindByTags(w http.ResponseWriter, r *http.Request)
 	FindAll(w http.ResponseWriter, r *http.Request)
+	Refund(w http.ResponseWriter, r *http.Request)
+	FindRefunds(w http.ResponseWriter, r *http.Request)
 }
 
 type purchaseHandler struct {
@@ -35,10 +41,18 @@ func (h *purchaseHandler) RegisterRoutes(mux *http.ServeMux) {
 		h.Create(w, r)
 	})
 
+	mux.HandleFunc("POST /v1/purchases/batch", func(w http.ResponseWriter, r *http.Request) {
+		h.Batch(w, r)
+	})
+
 	mux.HandleFunc("PUT /v1/purchases", func(w http.ResponseWriter, r *http.Request) {
 		h.Update(w, r)
 	})
 
+	mux.HandleFunc("PATCH /v1/purchases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.Patch(w, r)
+	})
+
 	mux.HandleFunc("DELETE /v1/purchases/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.Delete(w, r)
 	})
@@ -47,10 +61,25 @@ func (h *purchaseHandler) RegisterRoutes(mux *http.ServeMux) {
 		h.FindByID(w, r)
 	})
 
+	mux.HandleFunc("POST /v1/purchases/{id}/refunds", func(w http.ResponseWriter, r *http.Request) {
+		h.Refund(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/purchases/{id}/refunds", func(w http.ResponseWriter, r *http.Request) {
+		h.FindRefunds(w, r)
+	})
+
 	mux.HandleFunc("GET /v1/purchases", func(w http.ResponseWriter, r *http.Request) {
 		data := r.URL.Query().Get("date")
 		month := r.URL.Query().Get("month")
 		person := r.URL.Query().Get("person")
+		tags := r.URL.Query().Get("tags")
+
+		// Each filter has its own query, tags cannot narrow down the others.
+		if tags != "" && (data != "" || month != "" || person != "") {
+			HTTPError(w, r, models.InvalidField("tags", "tags cannot be combined with date, month or person"))
+			return
+		}
 
 		if data != "" {
 			h.FindByDate(w, r)
@@ -61,6 +90,9 @@ func (h *purchaseHandler) RegisterRoutes(mux *http.ServeMux) {
 		} else if person != "" {
 			h.FindByPerson(w, r)
 			return
+		} else if tags != "" {
+			h.FindByTags(w, r)
+			return
 		}
 
 		h.FindAll(w, r)
@@ -75,31 +107,29 @@ func (p *purchaseHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&purchaseRequest)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding Purchase: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding Purchase: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding Purchase: %v", err))
 		return
 	}
 
 	purchase, err = purchaseRequest.ToEntity()
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
 	if err := purchase.Validate(); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := p.service.CreatePurchase(purchase); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	created, err := p.service.CreatePurchase(r.Context(), purchase)
+	if err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
-	HTTPResponse(w, "Purchase was created with success!", http.StatusCreated)
+	setETag(w, created.Version)
+	HTTPCreated(w, location("purchases", created.ID), created)
 }
 
 func (p *purchaseHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -110,47 +140,124 @@ func (p *purchaseHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&purchaseRequest)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding Purchase: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding Purchase: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding Purchase: %v", err))
 		return
 	}
 
 	purchase, err = purchaseRequest.ToEntity()
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	purchase.Version = version
+
 	if err := purchase.Validate(); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := p.service.UpdatePurchase(purchase); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := p.service.UpdatePurchase(r.Context(), purchase); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
 	HTTPResponse(w, "Purchase was updated with success!", http.StatusOK)
 }
 
+func (p *purchaseHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	patch, err := readMergePatch(r)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	purchase, err := p.service.PatchPurchase(r.Context(), id, patch, version)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, purchase.Version)
+
+	HTTPResponse(w, purchase, http.StatusOK)
+}
+
+// Batch creates and updates many purchases at once. It answers 201 when every
+// purchase succeeded, 207 when only some did in the best effort mode and 422
+// when an atomic batch was rolled back, with the result of each purchase.
+func (p *purchaseHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var request models.PurchaseBatchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding purchases: %v", err))
+		return
+	}
+
+	response, err := p.service.BatchPurchases(r.Context(), request)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	for i, result := range response.Results {
+		if result.Err != nil {
+			slog.Error(result.Err.Error(), "index", result.Index)
+
+			problem := toProblem(result.Err)
+			response.Results[i].Error = &problem
+		}
+	}
+
+	status := http.StatusCreated
+
+	switch {
+	case response.Failed > 0 && response.Mode == models.BatchModeAtomic:
+		status = http.StatusUnprocessableEntity
+	case response.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+
+	// As in HTTPCreated, the status is written once the headers are set.
+	setResponseHeaders(w)
+	w.WriteHeader(status)
+
+	HTTPResponse(w, response, status)
+}
+
 func (p *purchaseHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idRequest := r.PathValue("id")
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	err = p.service.DeletePurchase(id)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err = p.service.DeletePurchase(r.Context(), id, version)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -162,18 +269,18 @@ func (p *purchaseHandler) FindByID(w http.ResponseWriter, r *http.Request) {
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	purchase, err := p.service.FindPurchaseByID(id)
+	purchase, err := p.service.FindPurchaseByID(r.Context(), id)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
+	setETag(w, purchase.Version)
+
 	HTTPResponse(w, purchase, http.StatusOK)
 }
 
@@ -181,21 +288,18 @@ func (p *purchaseHandler) FindByDate(w http.ResponseWriter, r *http.Request) {
 	date := r.URL.Query().Get("date")
 
 	if date == "" {
-		slog.Error("date parameter is required")
-		http.Error(w, "date parameter is required", http.StatusBadRequest)
+		HTTPError(w, r, models.InvalidField("date", "date parameter is required"))
 		return
 	}
 
 	if err := models.ValidateDate(date); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	purchases, err := p.service.FindPurchaseByDate(date)
+	purchases, err := p.service.FindPurchaseByDate(r.Context(), date)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -206,21 +310,18 @@ func (p *purchaseHandler) FindByMonth(w http.ResponseWriter, r *http.Request) {
 	month := r.URL.Query().Get("month")
 
 	if month == "" {
-		slog.Error("month parameter is required")
-		http.Error(w, "month parameter is required", http.StatusBadRequest)
+		HTTPError(w, r, models.InvalidField("month", "month parameter is required"))
 		return
 	}
 
 	if err := models.ValidateYearMonth(month); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	purchases, err := p.service.FindPurchaseByMonth(month)
+	purchases, err := p.service.FindPurchaseByMonth(r.Context(), month)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -231,22 +332,49 @@ func (p *purchaseHandler) FindByPerson(w http.ResponseWriter, r *http.Request) {
 	personID := r.URL.Query().Get("person")
 
 	if personID == "" {
-		slog.Error("person parameter is required")
-		http.Error(w, "person parameter is required", http.StatusBadRequest)
+		HTTPError(w, r, models.InvalidField("person", "person parameter is required"))
 		return
 	}
 
 	id, err := models.ValidateID(personID)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
+		return
+	}
+
+	purchases, err := p.service.FindPurchaseByPerson(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, purchases, http.StatusOK)
+}
+
+// FindByTags expects a comma separated list of tag IDs in "tags" and an
+// optional "match" parameter ("any" by default, or "all").
+func (p *purchaseHandler) FindByTags(w http.ResponseWriter, r *http.Request) {
+	var tagIDs []uuid.UUID
+
+	for _, idRequest := range strings.Split(r.URL.Query().Get("tags"), ",") {
+		id, err := models.ValidateID(strings.TrimSpace(idRequest))
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		tagIDs = append(tagIDs, id)
+	}
+
+	match := r.URL.Query().Get("match")
+	if match != "" && match != "any" && match != "all" {
+		HTTPError(w, r, models.InvalidField("match", "match parameter must be any or all"))
 		return
 	}
 
-	purchases, err := p.service.FindPurchaseByPerson(id)
+	purchases, err := p.service.FindPurchaseByTags(r.Context(), tagIDs, match == "all")
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -254,12 +382,57 @@ func (p *purchaseHandler) FindByPerson(w http.ResponseWriter, r *http.Request) {
 }
 
 func (p *purchaseHandler) FindAll(w http.ResponseWriter, r *http.Request) {
-	purchases, err := p.service.FindAllPurchases()
+	purchases, err := p.service.FindAllPurchases(r.Context())
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
 	HTTPResponse(w, purchases, http.StatusOK)
 }
+
+func (p *purchaseHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	var refund models.Refund
+
+	if err := json.NewDecoder(r.Body).Decode(&refund); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding refund: %v", err))
+		return
+	}
+
+	refund.IDPurchase = id
+
+	if err := refund.Validate(); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	refund, err = p.service.RefundPurchase(r.Context(), refund)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, "", refund)
+}
+
+func (p *purchaseHandler) FindRefunds(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	refunds, err := p.service.FindRefundsByPurchaseID(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, refunds, http.StatusOK)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+// fakePurchaseService answers every batch with response.
+type fakePurchaseService struct {
+	service.PurchaseService
+	response models.PurchaseBatchResponse
+}
+
+func (s *fakePurchaseService) BatchPurchases(ctx context.Context, request models.PurchaseBatchRequest) (models.PurchaseBatchResponse, error) {
+	return s.response, nil
+}
+
+func TestBatchAnswersTheStatusOfTheBatch(t *testing.T) {
+	tests := map[string]struct {
+		response models.PurchaseBatchResponse
+		want     int
+	}{
+		"every purchase succeeded": {
+			response: models.PurchaseBatchResponse{Mode: models.BatchModeAtomic, Succeeded: 2},
+			want:     http.StatusCreated,
+		},
+		"best effort with failures": {
+			response: models.PurchaseBatchResponse{Mode: models.BatchModeBestEffort, Succeeded: 1, Failed: 1},
+			want:     http.StatusMultiStatus,
+		},
+		"atomic rolled back": {
+			response: models.PurchaseBatchResponse{Mode: models.BatchModeAtomic, Failed: 1},
+			want:     http.StatusUnprocessableEntity,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := &purchaseHandler{service: &fakePurchaseService{response: tt.response}}
+
+			w := httptest.NewRecorder()
+			h.Batch(w, httptest.NewRequest(http.MethodPost, "/v1/purchases/batch", strings.NewReader(`{"purchases":[{}]}`)))
+
+			if w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+
+			if got := w.Result().Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+
+			var body struct{ StatusCode int }
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding the body: %v", err)
+			}
+
+			if body.StatusCode != tt.want {
+				t.Errorf("the body says %d, the response %d", body.StatusCode, w.Code)
+			}
+		})
+	}
+}
@@ -2,18 +2,17 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/service"
-	"github.com/sagikazarmark/slog-shim"
 )
 
 type PurchaseTypeHandler interface {
 	RegisterRoutes(mux *http.ServeMux)
 	CreatePurchaseType(w http.ResponseWriter, r *http.Request)
 	UpdatePurchaseType(w http.ResponseWriter, r *http.Request)
+	PatchPurchaseType(w http.ResponseWriter, r *http.Request)
 	DeletePurchaseType(w http.ResponseWriter, r *http.Request)
 	FindPurchaseTypeByID(w http.ResponseWriter, r *http.Request)
 	FindAllPurchaseTypes(w http.ResponseWriter, r *http.Request)
@@ -36,6 +35,10 @@ func (h *purchaseTypeHandler) RegisterRoutes(mux *http.ServeMux) {
 		h.UpdatePurchaseType(w, r)
 	})
 
+	mux.HandleFunc("PATCH /v1/purchaseTypes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.PatchPurchaseType(w, r)
+	})
+
 	mux.HandleFunc("DELETE /v1/purchaseTypes/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.DeletePurchaseType(w, r)
 	})
@@ -54,24 +57,23 @@ func (pt *purchaseTypeHandler) CreatePurchaseType(w http.ResponseWriter, r *http
 
 	err := json.NewDecoder(r.Body).Decode(&purchaseType)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding Purchase Type: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding Purchase Type: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding Purchase Type: %v", err))
 		return
 	}
 
 	if err := purchaseType.Validate(true); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := pt.service.CreatePurchaseType(purchaseType); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	created, err := pt.service.CreatePurchaseType(r.Context(), purchaseType)
+	if err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
-	HTTPResponse(w, "Purchase Type was created with success!", http.StatusCreated)
+	setETag(w, created.Version)
+	HTTPCreated(w, location("purchaseTypes", created.ID), created)
 }
 
 func (pt *purchaseTypeHandler) UpdatePurchaseType(w http.ResponseWriter, r *http.Request) {
@@ -79,40 +81,93 @@ func (pt *purchaseTypeHandler) UpdatePurchaseType(w http.ResponseWriter, r *http
 
 	err := json.NewDecoder(r.Body).Decode(&purchaseType)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error decoding Purchase Type: %v", err))
-		http.Error(w, fmt.Sprintf("Error decoding Purchase Type: %v", err), http.StatusBadRequest)
+		HTTPError(w, r, models.Invalid("Error decoding Purchase Type: %v", err))
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 
+	purchaseType.Version = version
+
 	if err := purchaseType.Validate(false); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
-	if err := pt.service.UpdatePurchaseType(purchaseType); err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := pt.service.UpdatePurchaseType(r.Context(), purchaseType); err != nil {
+		HTTPError(w, r, err)
 		return
 	}
 
 	HTTPResponse(w, "Purchase Type was updated with success", http.StatusOK)
 }
 
+func (pt *purchaseTypeHandler) PatchPurchaseType(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	patch, err := readMergePatch(r)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	purchaseType, err := pt.service.PatchPurchaseType(r.Context(), id, patch, version)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, purchaseType.Version)
+
+	HTTPResponse(w, purchaseType, http.StatusOK)
+}
+
 func (pt *purchaseTypeHandler) DeletePurchaseType(w http.ResponseWriter, r *http.Request) {
 	idRequest := r.PathValue("id")
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 
-	err = pt.service.DeletePurchaseType(id)
+	if reassignTo := r.URL.Query().Get("reassignTo"); reassignTo != "" {
+		to, err := models.ValidateReassign(id, reassignTo)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		response, err := pt.service.ReassignPurchaseType(r.Context(), id, to, version)
+		if err != nil {
+			HTTPError(w, r, err)
+			return
+		}
+
+		HTTPResponse(w, response, http.StatusOK)
+		return
+	}
+
+	err = pt.service.DeletePurchaseType(r.Context(), id, version)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
@@ -124,26 +179,37 @@ func (pt *purchaseTypeHandler) FindPurchaseTypeByID(w http.ResponseWriter, r *ht
 
 	id, err := models.ValidateID(idRequest)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
-	purchaseType, err := pt.service.FindPurchaseTypeByID(id)
+	purchaseType, err := pt.service.FindPurchaseTypeByID(r.Context(), id)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		HTTPError(w, r, err)
 		return
 	}
 
+	setETag(w, purchaseType.Version)
+
 	HTTPResponse(w, purchaseType, http.StatusOK)
 }
 
+// FindAllPurchaseTypes returns the purchase types as a tree of categories and
+// subcategories, or as a flat list when "flat=true" is given.
 func (pt *purchaseTypeHandler) FindAllPurchaseTypes(w http.ResponseWriter, r *http.Request) {
-	purchaseTypes, err := pt.service.FindAllPurchaseTypes()
+	var (
+		purchaseTypes []models.PurchaseType
+		err           error
+	)
+
+	if r.URL.Query().Get("flat") == "true" {
+		purchaseTypes, err = pt.service.FindAllPurchaseTypes(r.Context())
+	} else {
+		purchaseTypes, err = pt.service.FindPurchaseTypeTree(r.Context())
+	}
+
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		HTTPError(w, r, err)
 		return
 	}
 
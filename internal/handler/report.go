@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type ReportHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	TotalsByTag(w http.ResponseWriter, r *http.Request)
+	TotalsByPurchaseType(w http.ResponseWriter, r *http.Request)
+	MonthlyBalance(w http.ResponseWriter, r *http.Request)
+}
+
+type reportHandler struct {
+	service service.ReportService
+}
+
+func NewReportHandler(svc service.ReportService) ReportHandler {
+	return &reportHandler{service: svc}
+}
+
+func (h *reportHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/reports/tags", func(w http.ResponseWriter, r *http.Request) {
+		h.TotalsByTag(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/reports/purchaseTypes", func(w http.ResponseWriter, r *http.Request) {
+		h.TotalsByPurchaseType(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/reports/balance", func(w http.ResponseWriter, r *http.Request) {
+		h.MonthlyBalance(w, r)
+	})
+}
+
+func (h *reportHandler) TotalsByTag(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+
+	if err := models.ValidateYearMonth(month); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	totals, err := h.service.TotalsByTag(r.Context(), month)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, totals, http.StatusOK)
+}
+
+func (h *reportHandler) TotalsByPurchaseType(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+
+	if err := models.ValidateYearMonth(month); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	totals, err := h.service.TotalsByPurchaseType(r.Context(), month)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, totals, http.StatusOK)
+}
+
+func (h *reportHandler) MonthlyBalance(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+
+	if err := models.ValidateYearMonth(month); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	balance, err := h.service.MonthlyBalance(r.Context(), month)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, balance, http.StatusOK)
+}
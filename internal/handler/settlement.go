@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type SettlementHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	CreateSettlement(w http.ResponseWriter, r *http.Request)
+	DeleteSettlement(w http.ResponseWriter, r *http.Request)
+	FindSettlementByID(w http.ResponseWriter, r *http.Request)
+	FindAllSettlements(w http.ResponseWriter, r *http.Request)
+	FindBalances(w http.ResponseWriter, r *http.Request)
+	FindSettlementPlan(w http.ResponseWriter, r *http.Request)
+}
+
+type settlementHandler struct {
+	service service.SettlementService
+}
+
+func NewSettlementHandler(svc service.SettlementService) SettlementHandler {
+	return &settlementHandler{service: svc}
+}
+
+func (h *settlementHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/settlements", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateSettlement(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/settlements/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.DeleteSettlement(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/settlements/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.FindSettlementByID(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/settlements", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAllSettlements(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/settlements/balances", func(w http.ResponseWriter, r *http.Request) {
+		h.FindBalances(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/settlements/plan", func(w http.ResponseWriter, r *http.Request) {
+		h.FindSettlementPlan(w, r)
+	})
+}
+
+func (h *settlementHandler) CreateSettlement(w http.ResponseWriter, r *http.Request) {
+	var settlement models.Settlement
+
+	if err := json.NewDecoder(r.Body).Decode(&settlement); err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding settlement: %v", err))
+		return
+	}
+
+	if err := settlement.Validate(); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	created, err := h.service.CreateSettlement(r.Context(), settlement)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPCreated(w, location("settlements", created.ID), created)
+}
+
+func (h *settlementHandler) DeleteSettlement(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.DeleteSettlement(r.Context(), id); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Settlement was deleted with success!", http.StatusOK)
+}
+
+func (h *settlementHandler) FindSettlementByID(w http.ResponseWriter, r *http.Request) {
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	settlement, err := h.service.FindSettlementByID(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, settlement, http.StatusOK)
+}
+
+func (h *settlementHandler) FindAllSettlements(w http.ResponseWriter, r *http.Request) {
+	settlements, err := h.service.FindAllSettlements(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, settlements, http.StatusOK)
+}
+
+func (h *settlementHandler) FindBalances(w http.ResponseWriter, r *http.Request) {
+	balances, err := h.service.FindBalances(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, balances, http.StatusOK)
+}
+
+func (h *settlementHandler) FindSettlementPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := h.service.FindSettlementPlan(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, plan, http.StatusOK)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type TagHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	CreateTag(w http.ResponseWriter, r *http.Request)
+	UpdateTag(w http.ResponseWriter, r *http.Request)
+	DeleteTag(w http.ResponseWriter, r *http.Request)
+	FindTagByID(w http.ResponseWriter, r *http.Request)
+	FindAllTags(w http.ResponseWriter, r *http.Request)
+}
+
+type tagHandler struct {
+	service service.TagService
+}
+
+func NewTagHandler(svc service.TagService) TagHandler {
+	return &tagHandler{service: svc}
+}
+
+func (h *tagHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/tags", func(w http.ResponseWriter, r *http.Request) {
+		h.CreateTag(w, r)
+	})
+
+	mux.HandleFunc("PUT /v1/tags", func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateTag(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/tags/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.DeleteTag(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/tags/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.FindTagByID(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/tags", func(w http.ResponseWriter, r *http.Request) {
+		h.FindAllTags(w, r)
+	})
+}
+
+func (h *tagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var tag models.Tag
+
+	err := json.NewDecoder(r.Body).Decode(&tag)
+	if err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding tag: %v", err))
+		return
+	}
+
+	if err := tag.Validate(true); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	created, err := h.service.CreateTag(r.Context(), tag)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, created.Version)
+	HTTPCreated(w, location("tags", created.ID), created)
+}
+
+func (h *tagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	var tag models.Tag
+
+	err := json.NewDecoder(r.Body).Decode(&tag)
+	if err != nil {
+		HTTPError(w, r, models.Invalid("Error decoding tag: %v", err))
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	tag.Version = version
+
+	if err := tag.Validate(false); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.UpdateTag(r.Context(), tag); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Tag was updated with success!", http.StatusOK)
+}
+
+func (h *tagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTag(r.Context(), id, version); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Tag was deleted with success!", http.StatusOK)
+}
+
+func (h *tagHandler) FindTagByID(w http.ResponseWriter, r *http.Request) {
+	idRequest := r.PathValue("id")
+
+	id, err := models.ValidateID(idRequest)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	tag, err := h.service.FindTagByID(r.Context(), id)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	setETag(w, tag.Version)
+
+	HTTPResponse(w, tag, http.StatusOK)
+}
+
+func (h *tagHandler) FindAllTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.service.FindAllTags(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, tags, http.StatusOK)
+}
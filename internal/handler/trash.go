@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/service"
+)
+
+type TrashHandler interface {
+	RegisterRoutes(mux *http.ServeMux)
+	FindTrash(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
+	Purge(w http.ResponseWriter, r *http.Request)
+}
+
+type trashHandler struct {
+	service   service.TrashService
+	retention time.Duration
+}
+
+func NewTrashHandler(svc service.TrashService, retention time.Duration) TrashHandler {
+	return &trashHandler{service: svc, retention: retention}
+}
+
+func (h *trashHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/trash", func(w http.ResponseWriter, r *http.Request) {
+		h.FindTrash(w, r)
+	})
+
+	mux.HandleFunc("POST /v1/trash/{entity}/{id}/restore", func(w http.ResponseWriter, r *http.Request) {
+		h.Restore(w, r)
+	})
+
+	mux.HandleFunc("DELETE /v1/trash", func(w http.ResponseWriter, r *http.Request) {
+		h.Purge(w, r)
+	})
+}
+
+func (h *trashHandler) FindTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.FindTrash(r.Context())
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, items, http.StatusOK)
+}
+
+func (h *trashHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	entity := r.PathValue("entity")
+
+	if _, err := models.TrashTable(entity); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	id, err := models.ValidateID(r.PathValue("id"))
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	if err := h.service.Restore(r.Context(), entity, id); err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, "Record was restored with success!", http.StatusOK)
+}
+
+// Purge removes what was trashed more than olderThan days ago, defaulting to
+// the configured retention.
+func (h *trashHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	retention := h.retention
+
+	if days := r.URL.Query().Get("olderThan"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			HTTPError(w, r, models.InvalidField("olderThan", "the olderThan must be a number of days"))
+			return
+		}
+
+		retention = time.Duration(n) * 24 * time.Hour
+	}
+
+	response, err := h.service.Purge(r.Context(), retention)
+	if err != nil {
+		HTTPError(w, r, err)
+		return
+	}
+
+	HTTPResponse(w, response, http.StatusOK)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/me/finance/internal/models"
+)
+
+// setETag tags the response with the version of the record, so the client can
+// send it back in If-Match when changing it.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+var errIfMatchRequired = errors.New("the If-Match header with the record ETag is required")
+
+// ifMatch reads the version the client expects the record to be at from the
+// If-Match header. When the header is missing or is not one of our ETags it
+// writes the error response and returns false.
+func ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		HTTPError(w, r, errIfMatchRequired)
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version < 1 {
+		HTTPError(w, r, models.InvalidField("If-Match", "invalid If-Match header: %s", header))
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	AccountChecking   = "checking"
+	AccountSavings    = "savings"
+	AccountWallet     = "wallet"
+	AccountInvestment = "investment"
+)
+
+type Account struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	IDPerson       uuid.UUID `json:"id_person"`
+	OpeningBalance float64   `json:"opening_balance"`
+	OpeningDate    string    `json:"opening_date"`
+	Version        int       `json:"version"`
+}
+
+type AccountTransfer struct {
+	ID          uuid.UUID `json:"id"`
+	IDFrom      uuid.UUID `json:"id_from"`
+	IDTo        uuid.UUID `json:"id_to"`
+	Amount      float64   `json:"amount"`
+	Date        string    `json:"date"`
+	Description string    `json:"description"`
+}
+
+// InvoicePayment is the payment of a credit card invoice debited from an
+// account. The whole invoice is paid: when Amount is zero the invoice total is
+// used, otherwise it must be that total.
+type InvoicePayment struct {
+	ID           uuid.UUID `json:"id"`
+	IDCreditCard uuid.UUID `json:"id_credit_card"`
+	IDAccount    uuid.UUID `json:"id_account"`
+	Month        string    `json:"month"`
+	Amount       float64   `json:"amount"`
+	Date         string    `json:"date"`
+}
+
+type AccountEntry struct {
+	Date        string  `json:"date"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Balance     float64 `json:"balance"`
+}
+
+type AccountStatement struct {
+	Account Account        `json:"account"`
+	Entries []AccountEntry `json:"entries"`
+	Balance float64        `json:"balance"`
+}
+
+func (a *Account) Validate(removeID bool) error {
+	var v Validator
+
+	if !removeID {
+		v.RequiredID("id", a.ID)
+	}
+
+	v.Required("name", a.Name != "")
+	v.RequiredID("id_person", a.IDPerson)
+	v.Date("opening_date", a.OpeningDate)
+	v.OneOf("type", a.Type, AccountChecking, AccountSavings, AccountWallet, AccountInvestment)
+
+	return v.Err()
+}
+
+func (t *AccountTransfer) Validate() error {
+	var v Validator
+
+	from := v.RequiredID("id_from", t.IDFrom)
+	to := v.RequiredID("id_to", t.IDTo)
+
+	if from && to {
+		v.Check(t.IDFrom != t.IDTo, "id_to", CodeInvalid, "the accounts of a transfer must be different", nil)
+	}
+
+	v.Positive("amount", t.Amount)
+	v.Date("date", t.Date)
+
+	return v.Err()
+}
+
+func (ip *InvoicePayment) Validate() error {
+	var v Validator
+
+	v.RequiredID("id_credit_card", ip.IDCreditCard)
+	v.RequiredID("id_account", ip.IDAccount)
+	v.YearMonth("month", ip.Month)
+	v.Check(ip.Amount >= 0, "amount", CodeMin, "the amount cannot be negative", map[string]any{"min": 0})
+	v.Date("date", ip.Date)
+
+	return v.Err()
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every API key, which is how the middleware tells them
+// from access tokens.
+const APIKeyPrefix = "fin_"
+
+// The scopes an API key can be limited to.
+const (
+	// ScopeRead allows reading everything the user can read.
+	ScopeRead = "read"
+	// ScopeWritePurchases allows creating, changing and deleting purchases.
+	ScopeWritePurchases = "write:purchases"
+	// ScopeAdmin allows everything the user can do.
+	ScopeAdmin = "admin"
+)
+
+func Scopes() []string {
+	return []string{ScopeRead, ScopeWritePurchases, ScopeAdmin}
+}
+
+// APIKey lets a script call the API as its user without logging in. Only the
+// hash of the key is kept, Prefix is the start of it to tell keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	IDUser     uuid.UUID  `json:"id_user"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyCreated is the response to the creation of an API key, the only time
+// the key itself is ever shown.
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRequest creates an API key. It never expires when ExpiresInDays is
+// zero.
+type APIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+func (r *APIKeyRequest) Validate() error {
+	var v Validator
+
+	v.Required("name", r.Name != "")
+
+	if v.Required("scopes", len(r.Scopes) > 0) {
+		for i, scope := range r.Scopes {
+			v.OneOf(fmt.Sprintf("scopes[%d]", i), scope, Scopes()...)
+		}
+	}
+
+	v.Min("expires_in_days", r.ExpiresInDays, 0)
+
+	return v.Err()
+}
+
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// ScopesAllow tells whether the scopes of an API key let it make a request:
+// reading needs the read scope, writing purchases the write:purchases scope
+// and anything else the admin scope.
+func ScopesAllow(scopes []string, method, path string) bool {
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeAdmin:
+			return true
+		case ScopeRead:
+			if MethodPermission(method) == PermissionRead {
+				return true
+			}
+		case ScopeWritePurchases:
+			if path == "/v1/purchases" || strings.HasPrefix(path, "/v1/purchases/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
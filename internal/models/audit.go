@@ -0,0 +1,87 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditCreate   = "create"
+	AuditUpdate   = "update"
+	AuditDelete   = "delete"
+	AuditPay      = "pay"
+	AuditRestore  = "restore"
+	AuditPurge    = "purge"
+	AuditReassign = "reassign"
+
+	AuditAnonymous = "anonymous"
+	AuditSystem    = "system"
+)
+
+// AuditEvent records a change to a row of an entity, named after its table,
+// with the row as it was before and after and the fields that changed.
+type AuditEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  uuid.UUID       `json:"entity_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Diff      json.RawMessage `json:"diff"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	Entity   string
+	EntityID uuid.NullUUID
+	Actor    string
+	From     string
+	To       string
+	Limit    int
+}
+
+func (f *AuditFilter) Validate() error {
+	var v Validator
+
+	if f.From != "" {
+		v.Date("from", f.From)
+	}
+
+	if f.To != "" {
+		v.Date("to", f.To)
+	}
+
+	if f.Limit <= 0 || f.Limit > 1000 {
+		f.Limit = 100
+	}
+
+	return v.Err()
+}
+
+// AuditMetadata identifies who made a change and in which request.
+type AuditMetadata struct {
+	Actor     string
+	RequestID string
+}
+
+type auditMetadataKey struct{}
+
+func WithAuditMetadata(ctx context.Context, m AuditMetadata) context.Context {
+	return context.WithValue(ctx, auditMetadataKey{}, m)
+}
+
+// AuditMetadataFrom returns the metadata of the request, or an anonymous actor
+// when the change does not come from a request.
+func AuditMetadataFrom(ctx context.Context) AuditMetadata {
+	m, ok := ctx.Value(auditMetadataKey{}).(AuditMetadata)
+	if !ok || m.Actor == "" {
+		m.Actor = AuditAnonymous
+	}
+
+	return m
+}
@@ -10,7 +10,7 @@ import (
 func ValidateID(idRequest string) (uuid.UUID, error) {
 	id, err := uuid.Parse(idRequest)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error converting ID to UUID: %v", err)
+		return uuid.Nil, InvalidField("id", "error converting ID to UUID: %v", err)
 	}
 
 	return id, nil
@@ -39,17 +39,15 @@ func ConverDate(date string) (string, error) {
 }
 
 func ValidateDate(date string) error {
-	if _, err := time.Parse("2006-01-02", date); err != nil {
-		return fmt.Errorf("the date is invalid")
-	}
+	var v Validator
+	v.Date("date", date)
 
-	return nil
+	return v.Err()
 }
 
 func ValidateYearMonth(date string) error {
-	if _, err := time.Parse("2006-01", date); err != nil {
-		return fmt.Errorf("the date is invalid")
-	}
+	var v Validator
+	v.YearMonth("month", date)
 
-	return nil
-}
\ No newline at end of file
+	return v.Err()
+}
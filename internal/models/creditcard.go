@@ -1,54 +1,44 @@
 package models
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/google/uuid"
 )
 
+// The types of credit card: the physical card, a virtual card and a temporary
+// virtual card, valid for a single purchase or a short time.
+const (
+	CreditCardPhysical         = "F"
+	CreditCardVirtual          = "V"
+	CreditCardVirtualTemporary = "VT"
+)
+
 type CreditCard struct {
 	ID                uuid.UUID `json:"id"`
 	Owner             string    `json:"owner"`
 	FinalCardNum      string    `json:"final_card_num"`
 	Type              string    `json:"type"`
 	InvoiceClosingDay int       `json:"invoice_closing_day"`
+	IDPerson          uuid.NullUUID `json:"id_person"`
+	Version           int       `json:"version"`
 }
 
 func (cc *CreditCard) Validate(removeID bool) error {
-	var invalidFields []string
+	var v Validator
 
 	if !removeID {
-		if cc.ID == uuid.Nil {
-			invalidFields = append(invalidFields, "ID")
-		}
-	}
-
-	if cc.Owner == "" {
-		invalidFields = append(invalidFields, "Owner")
+		v.RequiredID("id", cc.ID)
 	}
 
-	if cc.FinalCardNum == "" {
-		invalidFields = append(invalidFields, "FinalCardNum")
-	}
-
-	if cc.Type == ""{
-		invalidFields = append(invalidFields, "Type")
-	}
+	v.Required("owner", cc.Owner != "")
+	v.Required("final_card_num", cc.FinalCardNum != "")
 
-	if cc.InvoiceClosingDay == 0 {
-		invalidFields = append(invalidFields, "InvoiceClosingDay")
+	if v.Required("type", cc.Type != "") {
+		v.OneOf("type", cc.Type, CreditCardPhysical, CreditCardVirtual, CreditCardVirtualTemporary)
 	}
 
-	if len(invalidFields) > 0 {
-		fields := strings.Join(invalidFields, ", ")
-
-		if len(invalidFields) == 1 {
-			return fmt.Errorf("the field %s is required", fields)
-		}
-
-		return fmt.Errorf("the fields %s are required", fields)
+	if v.Required("invoice_closing_day", cc.InvoiceClosingDay != 0) {
+		v.Range("invoice_closing_day", cc.InvoiceClosingDay, 1, 31)
 	}
 
-	return nil
+	return v.Err()
 }
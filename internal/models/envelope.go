@@ -0,0 +1,27 @@
+package models
+
+// Envelope is the body of every /v2 response: Data holds what was asked for
+// and Errors what went wrong, while Meta is always there.
+type Envelope struct {
+	Data   any             `json:"data"`
+	Meta   EnvelopeMeta    `json:"meta"`
+	Errors []EnvelopeError `json:"errors,omitempty"`
+}
+
+// EnvelopeMeta describes the response. Count is only set when Data is a list
+// and Message when the route answers with nothing but a message.
+type EnvelopeMeta struct {
+	Status    int    `json:"status"`
+	RequestID string `json:"request_id,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Count     *int   `json:"count,omitempty"`
+}
+
+// EnvelopeError is one error of a /v2 response. Code is one of the Code
+// constants when Field is set, or else the kind of problem, as in not_found.
+type EnvelopeError struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Field   string         `json:"field,omitempty"`
+	Params  map[string]any `json:"params,omitempty"`
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of domain error. Every typed error below matches one of them with
+// errors.Is, which is what the handlers use to pick the HTTP status.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// ErrVersionConflict is returned when a record is changed or deleted with a
+// version other than the one stored, because someone else changed it first.
+var ErrVersionConflict = errors.New("the record was changed by someone else, reload it and try again")
+
+// ErrIdempotencyKeyReused is returned when an Idempotency-Key comes back with
+// a request other than the one it was first sent with.
+var ErrIdempotencyKeyReused = errors.New("the Idempotency-Key was already used for a different request")
+
+type NotFoundError struct {
+	Entity string
+}
+
+func NotFound(entity string) error {
+	return &NotFoundError{Entity: entity}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("does not exist %s with this id", e.Entity)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// FieldError tells what is wrong with one field of a request. Code is one of
+// the Code constants and Params carries the limits that were not met.
+type FieldError struct {
+	Code    string         `json:"code"`
+	Field   string         `json:"field"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
+// Invalid reports a request that cannot be accepted as it is, without
+// pointing at a field.
+func Invalid(format string, args ...any) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidField reports a single field with a wrong value.
+func InvalidField(field, format string, args ...any) error {
+	var v Validator
+	v.Add(field, CodeInvalid, fmt.Sprintf(format, args...), nil)
+
+	return v.Err()
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ConflictError is a request that is valid but clashes with the current
+// state of the data, like refunding a purchase twice.
+type ConflictError struct {
+	Message string
+}
+
+func Conflict(format string, args ...any) error {
+	return &ConflictError{Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// UnauthorizedError is a request without valid credentials. The message never
+// tells which part of the credentials was wrong.
+type UnauthorizedError struct {
+	Message string
+}
+
+func Unauthorized(format string, args ...any) error {
+	return &UnauthorizedError{Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Message
+}
+
+func (e *UnauthorizedError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// ForbiddenError is a request from a known user who is not allowed to do it.
+type ForbiddenError struct {
+	Message string
+}
+
+func Forbidden(format string, args ...any) error {
+	return &ForbiddenError{Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// UnavailableError wraps a failure to reach the database, which is worth
+// retrying later.
+type UnavailableError struct {
+	Err error
+}
+
+func Unavailable(err error) error {
+	return &UnavailableError{Err: err}
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("the service is unavailable: %v", e.Err)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Problem is an error response in the RFC 7807 problem details format.
+type Problem struct {
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Status     int          `json:"status"`
+	Detail     string       `json:"detail,omitempty"`
+	Instance   string       `json:"instance,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	Dependents *Dependents  `json:"dependents,omitempty"`
+}
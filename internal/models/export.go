@@ -0,0 +1,83 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	ExportFormatLedger    = "ledger"
+	ExportFormatHledger   = "hledger"
+	ExportFormatBeancount = "beancount"
+)
+
+// ExportPurchase is a purchase with the names needed to build account names
+// for plain-text accounting journals.
+type ExportPurchase struct {
+	ID             uuid.UUID
+	Date           string
+	Description    string
+	Place          string
+	Amount         float64
+	IDPurchaseType uuid.UUID
+	Person         string
+	CreditCard     string
+	Account        string
+	Shares         []PurchaseShare
+}
+
+// ExportRefund is a refund of a purchase, which gives back part of its
+// expense to the credit card or account it was paid with.
+type ExportRefund struct {
+	ID       uuid.UUID
+	Date     string
+	Amount   float64
+	Purchase ExportPurchase
+}
+
+// ExportPayment is a paid installment or an invoice payment that moves money
+// out of the credit card liability.
+type ExportPayment struct {
+	ID          uuid.UUID
+	Date        string
+	Description string
+	Amount      float64
+	CreditCard  string
+	Account     string
+}
+
+type ExportTransaction struct {
+	ID          uuid.UUID
+	Date        string
+	Description string
+	Postings    []Posting
+}
+
+func ValidateExportFormat(format string) bool {
+	switch format {
+	case ExportFormatLedger, ExportFormatHledger, ExportFormatBeancount:
+		return true
+	}
+
+	return false
+}
+
+// ExportPeriod validates the export dates, defaulting to every date.
+func ExportPeriod(from, to string) (string, string, error) {
+	if from == "" {
+		from = "1900-01-01"
+	}
+
+	if to == "" {
+		to = "9999-12-31"
+	}
+
+	var v Validator
+	v.Date("from", from)
+	v.Date("to", to)
+
+	if err := v.Err(); err != nil {
+		return "", "", err
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultHouseholdID is the household the data created before households
+// existed was moved to.
+var DefaultHouseholdID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Household is a family sharing one instance. Every record belongs to exactly
+// one household and is only ever visible to its members.
+type Household struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type HouseholdMember struct {
+	IDUser    uuid.UUID `json:"id_user"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MemberRequest adds a user to a household, as an editor when no role is
+// given.
+type MemberRequest struct {
+	Email string `json:"email"`
+	Role  Role   `json:"role"`
+}
+
+type RoleRequest struct {
+	Role Role `json:"role"`
+}
+
+// Membership is the household a request is scoped to and the role its user
+// has there.
+type Membership struct {
+	IDHousehold uuid.UUID
+	Role        Role
+}
+
+func (h *Household) Validate() error {
+	var v Validator
+
+	v.Required("name", h.Name != "")
+
+	return v.Err()
+}
+
+func (m *MemberRequest) Validate() error {
+	var v Validator
+
+	v.Required("email", m.Email != "")
+
+	if m.Role == "" {
+		m.Role = RoleEditor
+	}
+
+	v.OneOf("role", string(m.Role), Roles()...)
+
+	return v.Err()
+}
+
+func (r *RoleRequest) Validate() error {
+	var v Validator
+
+	if v.Required("role", r.Role != "") {
+		v.OneOf("role", string(r.Role), Roles()...)
+	}
+
+	return v.Err()
+}
+
+type householdKey struct{}
+
+// WithHousehold scopes the context to a household with full access, for the
+// jobs and commands that run outside of a request.
+func WithHousehold(ctx context.Context, id uuid.UUID) context.Context {
+	return WithMembership(ctx, Membership{IDHousehold: id, Role: RoleOwner})
+}
+
+func WithMembership(ctx context.Context, m Membership) context.Context {
+	return context.WithValue(ctx, householdKey{}, m)
+}
+
+// MembershipFrom returns the membership the request is scoped to, if any.
+func MembershipFrom(ctx context.Context) (Membership, bool) {
+	m, ok := ctx.Value(householdKey{}).(Membership)
+	return m, ok && m.IDHousehold != uuid.Nil
+}
+
+// HouseholdFrom returns the household the request is scoped to. Repositories
+// refuse to run without one, so forgetting to scope a request fails closed.
+func HouseholdFrom(ctx context.Context) (uuid.UUID, error) {
+	m, ok := MembershipFrom(ctx)
+	if !ok {
+		return uuid.Nil, Forbidden("no household selected, send its id in X-Household-ID")
+	}
+
+	return m.IDHousehold, nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyMaxLength bounds the Idempotency-Key header.
+const IdempotencyKeyMaxLength = 255
+
+// IdempotencyKey is a key sent by a client in the Idempotency-Key header of a
+// POST, with the hash of the request it first came with and, once that
+// request is done, its response. A nil Response means it is still running.
+// Keys belong to the user and the household of the request; IDHousehold is
+// the nil id on the routes not scoped to a household.
+type IdempotencyKey struct {
+	Key         string
+	IDUser      uuid.UUID
+	IDHousehold uuid.UUID
+	RequestHash string
+	Response    *IdempotentResponse
+	ExpiresAt   time.Time
+}
+
+// IdempotentResponse is what is replayed to the retries of a request.
+type IdempotentResponse struct {
+	Status  int
+	Headers map[string]string
+	Body    []byte
+}
+
+func ValidateIdempotencyKey(key string) error {
+	var v Validator
+
+	v.Check(len(key) <= IdempotencyKeyMaxLength, "Idempotency-Key", CodeInvalid,
+		"the Idempotency-Key cannot be longer than 255 characters", map[string]any{"max_length": IdempotencyKeyMaxLength})
+
+	return v.Err()
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	RecurrenceNone    = "none"
+	RecurrenceMonthly = "monthly"
+	RecurrenceYearly  = "yearly"
+)
+
+type Income struct {
+	ID          uuid.UUID `json:"id"`
+	Description string    `json:"description"`
+	Source      string    `json:"source"`
+	Category    string    `json:"category"`
+	Amount      float64   `json:"amount"`
+	Date        string    `json:"date"`
+	Recurrence  string    `json:"recurrence"`
+	EndDate     string    `json:"end_date"`
+	IDPerson    uuid.UUID `json:"id_person"`
+	Person      string    `json:"person,omitempty"`
+	Version     int       `json:"version"`
+}
+
+type IncomeResponseTotal struct {
+	Responses []Income `json:"responses"`
+	Quantity  int      `json:"quantity"`
+	Total     float64  `json:"total"`
+}
+
+func (i *Income) Validate(removeID bool) error {
+	var v Validator
+
+	if !removeID {
+		v.RequiredID("id", i.ID)
+	}
+
+	v.Required("description", i.Description != "")
+	v.Required("source", i.Source != "")
+	v.Positive("amount", i.Amount)
+	date := v.Date("date", i.Date)
+	v.RequiredID("id_person", i.IDPerson)
+
+	if i.Recurrence == "" {
+		i.Recurrence = RecurrenceNone
+	}
+
+	v.OneOf("recurrence", i.Recurrence, RecurrenceNone, RecurrenceMonthly, RecurrenceYearly)
+
+	if i.EndDate != "" && v.Date("end_date", i.EndDate) && date {
+		v.Check(i.EndDate >= i.Date, "end_date", CodeInvalid, "the end date must be after the date", nil)
+	}
+
+	return v.Err()
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LedgerSourcePurchase           = "purchase"
+	LedgerSourceInstallmentPayment = "installment_payment"
+	LedgerSourceInvoicePayment     = "invoice_payment"
+
+	LedgerCashAccount = "assets:cash"
+)
+
+// JournalEntry is a double-entry transaction. Postings are signed: debits are
+// positive and credits negative, so the postings of an entry add up to zero.
+type JournalEntry struct {
+	ID          uuid.UUID `json:"id"`
+	Date        string    `json:"date"`
+	Description string    `json:"description"`
+	SourceType  string    `json:"source_type"`
+	SourceID    uuid.UUID `json:"source_id"`
+	Postings    []Posting `json:"postings"`
+}
+
+type Posting struct {
+	Account string  `json:"account"`
+	Amount  float64 `json:"amount"`
+}
+
+type TrialBalanceLine struct {
+	Account string  `json:"account"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"`
+}
+
+type TrialBalance struct {
+	Lines       []TrialBalanceLine `json:"lines"`
+	TotalDebit  float64            `json:"total_debit"`
+	TotalCredit float64            `json:"total_credit"`
+	Balanced    bool               `json:"balanced"`
+}
+
+// LedgerDrift is a difference found between the ledger and the purchase and
+// installment tables.
+type LedgerDrift struct {
+	Kind     string    `json:"kind"`
+	SourceID uuid.UUID `json:"source_id"`
+	Expected float64   `json:"expected"`
+	Actual   float64   `json:"actual"`
+}
+
+type IntegrityResponse struct {
+	Drifts   []LedgerDrift `json:"drifts"`
+	Quantity int           `json:"quantity"`
+	Healthy  bool          `json:"healthy"`
+}
+
+func LedgerExpenseAccount(purchaseTypeID uuid.UUID) string {
+	return "expenses:" + purchaseTypeID.String()
+}
+
+func LedgerCreditCardAccount(creditCardID uuid.UUID) string {
+	return "liabilities:credit_card:" + creditCardID.String()
+}
+
+func LedgerAssetAccount(accountID uuid.UUID) string {
+	return "assets:account:" + accountID.String()
+}
+
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("a journal entry needs at least two postings")
+	}
+
+	var sum int64
+
+	for _, posting := range e.Postings {
+		if posting.Account == "" {
+			return fmt.Errorf("every posting needs an account")
+		}
+
+		sum += int64(math.Round(posting.Amount * 100))
+	}
+
+	if sum != 0 {
+		return fmt.Errorf("the postings of a journal entry must balance, difference of %.2f", float64(sum)/100)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatchContentType is the media type of an RFC 7396 merge patch.
+const MergePatchContentType = "application/merge-patch+json"
+
+// MergePatch applies an RFC 7396 merge patch to document: the members of the
+// patch replace the ones of the document, a null removes the member, objects
+// are merged member by member and anything else, arrays included, replaces
+// what the document had.
+func MergePatch(document, patch []byte) ([]byte, error) {
+	var target, changes any
+
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, fmt.Errorf("error decoding document: %w", err)
+	}
+
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, Invalid("Error decoding merge patch: %v", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(target, changes))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding document: %w", err)
+	}
+
+	return merged, nil
+}
+
+func mergePatch(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+
+		object[name] = mergePatch(object[name], value)
+	}
+
+	return object
+}
@@ -1,39 +1,23 @@
 package models
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/google/uuid"
 )
 
 type PaymentType struct {
 	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	Name    string    `json:"name"`
+	Version int       `json:"version"`
 }
 
 func (pt *PaymentType) Validate(removeID bool) error {
-	var invalidFields []string
+	var v Validator
 
 	if !removeID {
-		if pt.ID == uuid.Nil {
-			invalidFields = append(invalidFields, "ID")
-		}
-	}
-	
-	if pt.Name == "" {
-		invalidFields = append(invalidFields, "Name")
+		v.RequiredID("id", pt.ID)
 	}
-	
-	if len(invalidFields) > 0 {
-		fields := strings.Join(invalidFields, ", ")
 
-		if len(invalidFields) == 1 {
-			return fmt.Errorf("the field %s is required", fields)
-		}
-
-		return fmt.Errorf("the fields %s are required", fields)
-	}
+	v.Required("name", pt.Name != "")
 
-	return nil
+	return v.Err()
 }
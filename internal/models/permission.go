@@ -0,0 +1,68 @@
+package models
+
+import "net/http"
+
+// Role is what a member can do in a household.
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// Permission is a kind of access to the data of a household.
+type Permission string
+
+const (
+	// PermissionRead is reading the data of the household.
+	PermissionRead Permission = "read"
+	// PermissionWrite is creating, changing and deleting the data.
+	PermissionWrite Permission = "write"
+	// PermissionManage is managing the members of the household and their
+	// roles.
+	PermissionManage Permission = "manage"
+)
+
+// permissions is the permission matrix: what each role is allowed to do.
+var permissions = map[Role][]Permission{
+	RoleOwner:  {PermissionRead, PermissionWrite, PermissionManage},
+	RoleEditor: {PermissionRead, PermissionWrite},
+	RoleViewer: {PermissionRead},
+}
+
+func Roles() []string {
+	return []string{string(RoleOwner), string(RoleEditor), string(RoleViewer)}
+}
+
+// Can tells whether the role grants the permission. Unknown roles grant
+// nothing.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range permissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MethodPermission is the permission a request needs to reach a route with
+// the method: reading for the safe methods, writing for everything else.
+func MethodPermission(method string) Permission {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return PermissionRead
+	default:
+		return PermissionWrite
+	}
+}
+
+// Authorize refuses the permission to a role that does not grant it.
+func Authorize(role Role, p Permission) error {
+	if !role.Can(p) {
+		return Forbidden("the %s role does not allow to %s in this household", role, p)
+	}
+
+	return nil
+}
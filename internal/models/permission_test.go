@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRoleCan(t *testing.T) {
+	tests := []struct {
+		role       Role
+		permission Permission
+		want       bool
+	}{
+		{RoleOwner, PermissionRead, true},
+		{RoleOwner, PermissionWrite, true},
+		{RoleOwner, PermissionManage, true},
+		{RoleEditor, PermissionRead, true},
+		{RoleEditor, PermissionWrite, true},
+		{RoleEditor, PermissionManage, false},
+		{RoleViewer, PermissionRead, true},
+		{RoleViewer, PermissionWrite, false},
+		{RoleViewer, PermissionManage, false},
+		{Role("admin"), PermissionRead, false},
+		{Role(""), PermissionRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.permission); got != tt.want {
+			t.Errorf("%q.Can(%q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestMethodPermission(t *testing.T) {
+	tests := map[string]Permission{
+		http.MethodGet:     PermissionRead,
+		http.MethodHead:    PermissionRead,
+		http.MethodOptions: PermissionRead,
+		http.MethodPost:    PermissionWrite,
+		http.MethodPut:     PermissionWrite,
+		http.MethodPatch:   PermissionWrite,
+		http.MethodDelete:  PermissionWrite,
+	}
+
+	for method, want := range tests {
+		if got := MethodPermission(method); got != want {
+			t.Errorf("MethodPermission(%s) = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       Role
+		permission Permission
+		forbidden  bool
+	}{
+		{"viewer reads", RoleViewer, MethodPermission(http.MethodGet), false},
+		{"viewer cannot create", RoleViewer, MethodPermission(http.MethodPost), true},
+		{"viewer cannot update", RoleViewer, MethodPermission(http.MethodPut), true},
+		{"viewer cannot delete", RoleViewer, MethodPermission(http.MethodDelete), true},
+		{"editor writes", RoleEditor, MethodPermission(http.MethodPatch), false},
+		{"editor cannot manage members", RoleEditor, PermissionManage, true},
+		{"viewer cannot manage members", RoleViewer, PermissionManage, true},
+		{"owner manages members", RoleOwner, PermissionManage, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.role, tt.permission)
+
+			var forbidden *ForbiddenError
+			if got := errors.As(err, &forbidden); got != tt.forbidden {
+				t.Errorf("Authorize(%q, %q) = %v, want forbidden %v", tt.role, tt.permission, err, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestScopesAllow(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{"read key reads", []string{ScopeRead}, http.MethodGet, "/v1/persons", true},
+		{"read key cannot write", []string{ScopeRead}, http.MethodPost, "/v1/purchases", false},
+		{"purchases key writes purchases", []string{ScopeWritePurchases}, http.MethodPut, "/v1/purchases/1", true},
+		{"purchases key cannot write persons", []string{ScopeWritePurchases}, http.MethodPost, "/v1/persons", false},
+		{"purchases key cannot create keys", []string{ScopeRead, ScopeWritePurchases}, http.MethodPost, "/v1/apiKeys", false},
+		{"purchases key cannot manage members", []string{ScopeRead, ScopeWritePurchases}, http.MethodPost, "/v1/households/1/members", false},
+		{"admin key manages keys", []string{ScopeAdmin}, http.MethodDelete, "/v1/apiKeys/1", true},
+		{"no scopes", nil, http.MethodGet, "/v1/persons", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopesAllow(tt.scopes, tt.method, tt.path); got != tt.want {
+				t.Errorf("ScopesAllow(%v, %s, %s) = %v, want %v", tt.scopes, tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
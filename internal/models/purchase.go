@@ -1,9 +1,6 @@
 package models
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/google/uuid"
 )
 
@@ -19,6 +16,11 @@ type Purchase struct {
 	IDCreditCard   uuid.UUID `json:"id_credit_card"`
 	IDPurchaseType uuid.UUID `json:"id_purchase_type"`
 	IDPerson	   uuid.UUID `json:"id_person"`
+	IDAccount      uuid.NullUUID `json:"id_account"`
+	Tags           []uuid.UUID `json:"tags"`
+	SplitType      string      `json:"split_type"`
+	Shares         []PurchaseShare `json:"shares"`
+	Version        int         `json:"version"`
 }
 
 type PurchaseRequest struct {
@@ -34,6 +36,11 @@ type PurchaseRequest struct {
 	IDCreditCard	  uuid.UUID `json:"id_credit_card"`
 	IDPurchaseType    uuid.UUID `json:"id_purchase_type"`
 	IDPerson	      uuid.UUID `json:"id_person"`
+	IDAccount         uuid.NullUUID `json:"id_account"`
+	Tags              []uuid.UUID `json:"tags"`
+	SplitType         string      `json:"split_type"`
+	Shares            []PurchaseShare `json:"shares"`
+	Version           int         `json:"version"`
 }
 
 type PurchaseResponse struct {
@@ -49,6 +56,18 @@ type PurchaseResponse struct {
 	CreditCard	      string  `json:"credit_card"`
 	PurchaseType      string  `json:"purchase_type"`
 	Person	          string  `json:"person"`
+	Tags              []string `json:"tags"`
+	Shares            []PurchaseShare `json:"shares,omitempty"`
+	Refunded          float64 `json:"refunded"`
+	NetAmount         float64 `json:"net_amount"`
+	Version           int     `json:"version,omitempty"`
+}
+
+// PurchaseCreated is a new purchase as it was saved, with the installments
+// generated for it.
+type PurchaseCreated struct {
+	PurchaseResponse
+	Installments []Installment `json:"installments"`
 }
 
 type PurchaseResponseTotal struct {
@@ -75,51 +94,46 @@ func (p *PurchaseRequest) ToEntity() (Purchase, error) {
 		IDCreditCard:	p.IDCreditCard,
 		IDPurchaseType: p.IDPurchaseType,
 		IDPerson:	    p.IDPerson,
+		IDAccount:      p.IDAccount,
+		Tags:           p.Tags,
+		SplitType:      p.SplitType,
+		Shares:         p.Shares,
+		Version:        p.Version,
 	}
 
 	return purchase, nil
 }
 
-func (p *Purchase) Validate() error {
-	var invalidFields []string
-
-	if p.Amount <= 0{
-		invalidFields = append(invalidFields, "Amount")
-	}
-
-	if p.Date == "" {
-		invalidFields = append(invalidFields, "Data")
-	}
-
-	if err := ValidateDate(p.Date); err != nil {
-		invalidFields = append(invalidFields, "Date")
-	}
-
-	if p.IDPaymentType == uuid.Nil {
-		invalidFields = append(invalidFields, "ID of Payment Type")
-	}
+// MaxInstallments bounds the installments of a purchase, ten years of monthly
+// invoices.
+const MaxInstallments = 120
 
-	if p.IDCreditCard == uuid.Nil {
-		invalidFields = append(invalidFields, "ID of Credit Card")
-	}
+func (p *Purchase) Validate() error {
+	return p.validate(true)
+}
 
-	if p.IDPurchaseType == uuid.Nil {
-		invalidFields = append(invalidFields, "ID of Purchase Type")
-	}
+// ValidateWithoutPlan validates a purchase whose installments are kept as
+// they are, like one whose plan was cancelled by refunds, so the number of
+// installments is not checked.
+func (p *Purchase) ValidateWithoutPlan() error {
+	return p.validate(false)
+}
 
-	if p.IDPerson == uuid.Nil {
-		invalidFields = append(invalidFields, "ID of Person")
-	}
+func (p *Purchase) validate(plan bool) error {
+	var v Validator
 
-	if len(invalidFields) > 0 {
-		fields := strings.Join(invalidFields, ", ")
+	v.Positive("amount", p.Amount)
+	v.Date("date", p.Date)
+	v.RequiredID("id_payment_type", p.IDPaymentType)
+	v.Check(p.IDCreditCard != uuid.Nil || p.IDAccount.Valid, "id_credit_card", CodeRequired, "either id_credit_card or id_account is required", nil)
+	v.RequiredID("id_purchase_type", p.IDPurchaseType)
+	v.RequiredID("id_person", p.IDPerson)
 
-		if len(invalidFields) == 1 {
-			return fmt.Errorf("the field %s is required", fields)
-		} else {
-			return fmt.Errorf("the fields %s are required", fields)
-		}
+	// Only purchases on a credit card are split into installments, and with
+	// none the purchase would never be billed.
+	if plan && p.IDCreditCard != uuid.Nil {
+		v.Range("installment_number", p.Installment.Number, 1, MaxInstallments)
 	}
 
-	return nil
+	return v.Err()
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// PurchaseBatchMaxItems bounds a batch, so a single request cannot hold a
+// transaction open for too long.
+const PurchaseBatchMaxItems = 500
+
+// The modes of a batch: atomic keeps every purchase or none of them, best
+// effort keeps those that succeed.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// What was done with each purchase of a batch.
+const (
+	BatchStatusCreated    = "created"
+	BatchStatusUpdated    = "updated"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+)
+
+// PurchaseBatchRequest creates the purchases without a version, keeping the id
+// when the client chose one, and updates the ones with their version.
+type PurchaseBatchRequest struct {
+	Mode      string            `json:"mode"`
+	Purchases []PurchaseRequest `json:"purchases"`
+}
+
+func (r *PurchaseBatchRequest) Validate() error {
+	var v Validator
+
+	if r.Mode == "" {
+		r.Mode = BatchModeAtomic
+	}
+
+	v.OneOf("mode", r.Mode, BatchModeAtomic, BatchModeBestEffort)
+
+	if v.Required("purchases", len(r.Purchases) > 0) {
+		v.Check(len(r.Purchases) <= PurchaseBatchMaxItems, "purchases", CodeRange,
+			fmt.Sprintf("a batch can have at most %d purchases", PurchaseBatchMaxItems), map[string]any{"max": PurchaseBatchMaxItems})
+	}
+
+	return v.Err()
+}
+
+// PurchaseBatchResult is the outcome of the purchase at Index of the batch.
+// Err is turned into Error by the handler.
+type PurchaseBatchResult struct {
+	Index  int           `json:"index"`
+	ID     uuid.NullUUID `json:"id"`
+	Status string        `json:"status"`
+	Err    error         `json:"-"`
+	Error  *Problem      `json:"error,omitempty"`
+}
+
+type PurchaseBatchResponse struct {
+	Mode      string                `json:"mode"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Results   []PurchaseBatchResult `json:"results"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SplitEqual      = "equal"
+	SplitPercentage = "percentage"
+	SplitAmount     = "amount"
+)
+
+type PurchaseShare struct {
+	IDPerson   uuid.UUID `json:"id_person"`
+	Person     string    `json:"person,omitempty"`
+	Percentage float64   `json:"percentage"`
+	Amount     float64   `json:"amount"`
+}
+
+// ResolveShares fills the amount owed by each person according to the split
+// type. Rounding differences are assigned to the last share so the shares
+// always add up to the purchase amount.
+func (p *Purchase) ResolveShares() error {
+	if len(p.Shares) == 0 {
+		return nil
+	}
+
+	var (
+		v    Validator
+		seen = map[uuid.UUID]bool{}
+	)
+
+	for i, share := range p.Shares {
+		field := fmt.Sprintf("shares[%d].id_person", i)
+
+		if v.RequiredID(field, share.IDPerson) {
+			v.Check(!seen[share.IDPerson], field, CodeInvalid, "a person can only appear once in the shares", nil)
+		}
+		seen[share.IDPerson] = true
+	}
+
+	if p.SplitType == "" {
+		p.SplitType = SplitEqual
+	}
+
+	if !v.OneOf("split_type", p.SplitType, SplitEqual, SplitPercentage, SplitAmount) {
+		return v.Err()
+	}
+
+	var (
+		last      = len(p.Shares) - 1
+		allocated float64
+		total     float64
+	)
+
+	for i := range p.Shares {
+		switch p.SplitType {
+		case SplitEqual:
+			p.Shares[i].Amount = roundCents(p.Amount / float64(len(p.Shares)))
+		case SplitPercentage:
+			v.Check(p.Shares[i].Percentage > 0, fmt.Sprintf("shares[%d].percentage", i), CodeMin,
+				"the percentage of each share must be greater than zero", map[string]any{"min": 0, "exclusive": true})
+
+			total += p.Shares[i].Percentage
+			p.Shares[i].Amount = roundCents(p.Amount * p.Shares[i].Percentage / 100)
+		case SplitAmount:
+			v.Check(p.Shares[i].Amount > 0, fmt.Sprintf("shares[%d].amount", i), CodeMin,
+				"the amount of each share must be greater than zero", map[string]any{"min": 0, "exclusive": true})
+
+			total += p.Shares[i].Amount
+		}
+	}
+
+	switch p.SplitType {
+	case SplitPercentage:
+		v.Check(math.Abs(total-100) <= 0.001, "shares", CodeInvalid, "the percentages of the shares must add up to 100", map[string]any{"total": 100})
+	case SplitAmount:
+		v.Check(math.Abs(total-p.Amount) <= 0.001, "shares", CodeInvalid,
+			"the amounts of the shares must add up to the purchase amount", map[string]any{"total": p.Amount})
+	}
+
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	for i := 0; i < last; i++ {
+		allocated += p.Shares[i].Amount
+	}
+	p.Shares[last].Amount = roundCents(p.Amount - allocated)
+
+	for i := range p.Shares {
+		p.Shares[i].Percentage = roundCents(p.Shares[i].Amount * 100 / p.Amount)
+	}
+
+	return nil
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPurchaseInstallmentNumber(t *testing.T) {
+	tests := map[int]bool{0: false, 1: true, MaxInstallments: true, MaxInstallments + 1: false, 10000: false}
+
+	for number, valid := range tests {
+		p := Purchase{
+			Amount:         100,
+			Date:           "2024-03-10",
+			IDPaymentType:  uuid.New(),
+			IDCreditCard:   uuid.New(),
+			IDPurchaseType: uuid.New(),
+			IDPerson:       uuid.New(),
+			Installment:    Installment{Number: number},
+		}
+
+		err := p.Validate()
+
+		var invalid *ValidationError
+		if got := !errors.As(err, &invalid); got != valid {
+			t.Errorf("%d installments: got %v, want valid %v", number, err, valid)
+		}
+	}
+}
@@ -1,39 +1,36 @@
 package models
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/google/uuid"
 )
 
 type PurchaseType struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID       uuid.UUID      `json:"id"`
+	Name     string         `json:"name"`
+	IDParent uuid.NullUUID  `json:"id_parent"`
+	Children []PurchaseType `json:"children,omitempty"`
+	Version  int            `json:"version"`
+}
+
+type PurchaseTypeTotal struct {
+	ID       uuid.UUID           `json:"id"`
+	Name     string              `json:"name"`
+	IDParent uuid.NullUUID       `json:"id_parent"`
+	Quantity int                 `json:"quantity"`
+	Amount   float64             `json:"amount"`
+	Total    float64             `json:"total"`
+	Children []PurchaseTypeTotal `json:"children,omitempty"`
 }
 
 func (pt *PurchaseType) Validate(removeID bool) error {
-	var invalidFields []string
+	var v Validator
 
 	if !removeID {
-		if pt.ID == uuid.Nil {
-			invalidFields = append(invalidFields, "ID")
-		}
+		v.RequiredID("id", pt.ID)
 	}
-	
-	if pt.Name == "" {
-		invalidFields = append(invalidFields, "Name")
-	}
-	
-	if len(invalidFields) > 0 {
-		fields := strings.Join(invalidFields, ", ")
-
-		if len(invalidFields) == 1 {
-			return fmt.Errorf("the field %s is required", fields)
-		}		
 
-		return fmt.Errorf("the fields %s are required", fields)
-	}
+	v.Required("name", pt.Name != "")
+	v.Check(!pt.IDParent.Valid || pt.IDParent.UUID != pt.ID, "id_parent", CodeInvalid, "a purchase type cannot be its own parent", nil)
 
-	return nil
+	return v.Err()
 }
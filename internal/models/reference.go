@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const LedgerSourceReassign = "reassign"
+
+// Dependents counts the records that reference a person, credit card, payment
+// type or purchase type. Trashed purchases are not counted, they are moved
+// along on a reassignment but do not block a deletion.
+type Dependents struct {
+	Purchases       int `json:"purchases"`
+	PurchaseShares  int `json:"purchase_shares,omitempty"`
+	PurchaseTypes   int `json:"purchase_types,omitempty"`
+	CreditCards     int `json:"credit_cards,omitempty"`
+	Accounts        int `json:"accounts,omitempty"`
+	Incomes         int `json:"incomes,omitempty"`
+	Settlements     int `json:"settlements,omitempty"`
+	InvoicePayments int `json:"invoice_payments,omitempty"`
+}
+
+func (d Dependents) Total() int {
+	return d.Purchases + d.PurchaseShares + d.PurchaseTypes + d.CreditCards +
+		d.Accounts + d.Incomes + d.Settlements + d.InvoicePayments
+}
+
+// InUseError is returned when deleting a record that other records still
+// reference. It carries the counts so the client can offer a reassignment.
+type InUseError struct {
+	Entity     string     `json:"entity"`
+	ID         uuid.UUID  `json:"id"`
+	Dependents Dependents `json:"dependents"`
+}
+
+func (e *InUseError) Error() string {
+	return fmt.Sprintf("the %s is used by %d records, reassign them to another %s before deleting it",
+		e.Entity, e.Dependents.Total(), e.Entity)
+}
+
+func (e *InUseError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// ReassignResponse tells what was moved from the deleted record to the one
+// that replaced it.
+type ReassignResponse struct {
+	From  uuid.UUID  `json:"from"`
+	To    uuid.UUID  `json:"to"`
+	Moved Dependents `json:"moved"`
+}
+
+// ValidateReassign checks the target of a "delete and reassign" request.
+func ValidateReassign(id uuid.UUID, reassignTo string) (uuid.UUID, error) {
+	to, err := ValidateID(reassignTo)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if to == id {
+		return uuid.Nil, Invalid("cannot reassign a record to itself")
+	}
+
+	return to, nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	RefundModeCredit = "credit"
+	RefundModeCancel = "cancel"
+
+	LedgerSourceRefund = "refund"
+)
+
+// Refund returns all or part of a purchase. In credit mode the amount is
+// credited on the next invoice; in cancel mode the unpaid installments are
+// reduced, latest first, and only what they cannot absorb becomes a credit.
+type Refund struct {
+	ID         uuid.UUID `json:"id"`
+	IDPurchase uuid.UUID `json:"id_purchase"`
+	Amount     float64   `json:"amount"`
+	Date       string    `json:"date"`
+	Reason     string    `json:"reason"`
+	Mode       string    `json:"mode"`
+}
+
+func (r *Refund) Validate() error {
+	var v Validator
+
+	v.RequiredID("id_purchase", r.IDPurchase)
+	v.Check(r.Amount >= 0, "amount", CodeMin, "the amount cannot be negative", map[string]any{"min": 0})
+	v.Date("date", r.Date)
+
+	if r.Mode == "" {
+		r.Mode = RefundModeCredit
+	}
+
+	v.OneOf("mode", r.Mode, RefundModeCredit, RefundModeCancel)
+
+	return v.Err()
+}
@@ -0,0 +1,17 @@
+package models
+
+type ReportTagResponse struct {
+	Responses []TagTotal `json:"responses"`
+}
+
+type ReportPurchaseTypeResponse struct {
+	Responses []PurchaseTypeTotal `json:"responses"`
+	Total     float64             `json:"total"`
+}
+
+type MonthlyBalance struct {
+	Month    string  `json:"month"`
+	Income   float64 `json:"income"`
+	Expenses float64 `json:"expenses"`
+	Net      float64 `json:"net"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// Settlement is a payment made by one person to another to pay back
+// purchases charged on the receiver's credit card.
+type Settlement struct {
+	ID          uuid.UUID `json:"id"`
+	IDPayer     uuid.UUID `json:"id_payer"`
+	Payer       string    `json:"payer,omitempty"`
+	IDReceiver  uuid.UUID `json:"id_receiver"`
+	Receiver    string    `json:"receiver,omitempty"`
+	Amount      float64   `json:"amount"`
+	Date        string    `json:"date"`
+	Description string    `json:"description"`
+}
+
+// Debt is the amount a debtor owes a creditor, either because a purchase was
+// charged on the creditor's card or, inverted, because of a settlement.
+type Debt struct {
+	IDDebtor   uuid.UUID `json:"id_debtor"`
+	Debtor     string    `json:"debtor"`
+	IDCreditor uuid.UUID `json:"id_creditor"`
+	Creditor   string    `json:"creditor"`
+	Amount     float64   `json:"amount"`
+}
+
+type PersonBalance struct {
+	IDPerson uuid.UUID `json:"id_person"`
+	Person   string    `json:"person"`
+	Balance  float64   `json:"balance"`
+}
+
+type Transfer struct {
+	IDFrom uuid.UUID `json:"id_from"`
+	From   string    `json:"from"`
+	IDTo   uuid.UUID `json:"id_to"`
+	To     string    `json:"to"`
+	Amount float64   `json:"amount"`
+}
+
+type BalanceResponse struct {
+	Balances []PersonBalance `json:"balances"`
+	Debts    []Debt          `json:"debts"`
+}
+
+type SettlementPlanResponse struct {
+	Transfers []Transfer `json:"transfers"`
+	Quantity  int        `json:"quantity"`
+	Total     float64    `json:"total"`
+}
+
+func (s *Settlement) Validate() error {
+	var v Validator
+
+	payer := v.RequiredID("id_payer", s.IDPayer)
+	receiver := v.RequiredID("id_receiver", s.IDReceiver)
+
+	if payer && receiver {
+		v.Check(s.IDPayer != s.IDReceiver, "id_receiver", CodeInvalid, "the payer and the receiver must be different persons", nil)
+	}
+
+	v.Positive("amount", s.Amount)
+	v.Date("date", s.Date)
+
+	return v.Err()
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+type Tag struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Version int       `json:"version"`
+}
+
+type TagTotal struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Quantity int       `json:"quantity"`
+	Total    float64   `json:"total"`
+}
+
+func (t *Tag) Validate(removeID bool) error {
+	var v Validator
+
+	if !removeID {
+		v.RequiredID("id", t.ID)
+	}
+
+	v.Required("name", t.Name != "")
+
+	return v.Err()
+}
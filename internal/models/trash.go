@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TrashPersons       = "persons"
+	TrashCreditCards   = "creditCards"
+	TrashPaymentTypes  = "paymentTypes"
+	TrashPurchaseTypes = "purchaseTypes"
+	TrashPurchases     = "purchases"
+)
+
+// TrashTables maps the entities that can be trashed to their tables, in the
+// order they must be purged so that a row goes before the ones it references.
+var TrashTables = []struct {
+	Entity string
+	Table  string
+}{
+	{TrashPurchases, "purchase"},
+	{TrashCreditCards, "credit_card"},
+	{TrashPaymentTypes, "payment_type"},
+	{TrashPurchaseTypes, "purchase_type"},
+	{TrashPersons, "person"},
+}
+
+// TrashItem is a soft deleted record, kept until it is restored or purged.
+type TrashItem struct {
+	Entity      string    `json:"entity"`
+	ID          uuid.UUID `json:"id"`
+	Description string    `json:"description"`
+	DeletedAt   time.Time `json:"deleted_at"`
+}
+
+type PurgeResponse struct {
+	Before  time.Time        `json:"before"`
+	Purged  map[string]int64 `json:"purged"`
+	Skipped map[string]int64 `json:"skipped"`
+}
+
+// TrashTable returns the table of a trashable entity.
+func TrashTable(entity string) (string, error) {
+	for _, t := range TrashTables {
+		if t.Entity == entity {
+			return t.Table, nil
+		}
+	}
+
+	return "", Invalid("the entity %s cannot be restored from the trash", entity)
+}
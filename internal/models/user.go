@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PasswordMinLength = 8
+
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+type User struct {
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	Admin        bool      `json:"admin"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse is returned by a login or a refresh. ExpiresIn is the lifetime
+// of the access token in seconds.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type Session struct {
+	ID        uuid.UUID
+	IDUser    uuid.UUID
+	RefreshID uuid.UUID
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return !s.RevokedAt.Valid && now.Before(s.ExpiresAt)
+}
+
+func (r *RegisterRequest) Validate() error {
+	var v Validator
+
+	r.Email = strings.TrimSpace(r.Email)
+
+	if v.Required("email", r.Email != "") {
+		v.Check(strings.Contains(r.Email, "@"), "email", CodeInvalid, "the email is invalid", nil)
+	}
+
+	v.Required("name", r.Name != "")
+
+	if v.Required("password", r.Password != "") {
+		v.Check(len(r.Password) >= PasswordMinLength, "password", CodeMinLength,
+			"the password must have at least 8 characters", map[string]any{"min_length": PasswordMinLength})
+	}
+
+	return v.Err()
+}
+
+func (l *LoginRequest) Validate() error {
+	var v Validator
+
+	v.Required("email", l.Email != "")
+	v.Required("password", l.Password != "")
+
+	return v.Err()
+}
+
+// Principal is who is making a request, as proved by the token it sent: the
+// access token of a session or an API key, which is limited to its scopes.
+type Principal struct {
+	User      User
+	SessionID uuid.UUID
+	APIKey    *APIKey
+}
+
+// Allows tells whether the principal may make the request. Sessions can make
+// any request, API keys only those their scopes allow.
+func (p *Principal) Allows(method, path string) bool {
+	return p.APIKey == nil || ScopesAllow(p.APIKey.Scopes, method, path)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+
+	return p, ok
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The codes of FieldError, stable for clients to translate or react to.
+const (
+	CodeRequired  = "required"
+	CodeInvalid   = "invalid"
+	CodeMin       = "min"
+	CodeMinLength = "min_length"
+	CodeRange     = "range"
+	CodeOneOf     = "one_of"
+	CodeDate      = "date"
+	CodeYearMonth = "year_month"
+)
+
+// Validator collects every problem found in a model, so a single response
+// tells the client all the fields to fix. Fields are named as in the JSON of
+// the request.
+type Validator struct {
+	fields []FieldError
+}
+
+func (v *Validator) Add(field, code, message string, params map[string]any) {
+	v.fields = append(v.fields, FieldError{Field: field, Code: code, Message: message, Params: params})
+}
+
+// Check adds the error when ok is false, and returns ok so dependent checks
+// can be skipped.
+func (v *Validator) Check(ok bool, field, code, message string, params map[string]any) bool {
+	if !ok {
+		v.Add(field, code, message, params)
+	}
+
+	return ok
+}
+
+func (v *Validator) Required(field string, present bool) bool {
+	return v.Check(present, field, CodeRequired, fmt.Sprintf("the field %s is required", field), nil)
+}
+
+func (v *Validator) RequiredID(field string, id uuid.UUID) bool {
+	return v.Required(field, id != uuid.Nil)
+}
+
+func (v *Validator) Positive(field string, value float64) bool {
+	return v.Check(value > 0, field, CodeMin, fmt.Sprintf("the %s must be greater than zero", field), map[string]any{"min": 0, "exclusive": true})
+}
+
+func (v *Validator) Min(field string, value, min int) bool {
+	return v.Check(value >= min, field, CodeMin, fmt.Sprintf("the %s must be at least %d", field, min), map[string]any{"min": min})
+}
+
+func (v *Validator) Range(field string, value, min, max int) bool {
+	return v.Check(value >= min && value <= max, field, CodeRange,
+		fmt.Sprintf("the %s must be between %d and %d", field, min, max), map[string]any{"min": min, "max": max})
+}
+
+func (v *Validator) OneOf(field, value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+
+	v.Add(field, CodeOneOf, fmt.Sprintf("the %s must be one of %s", field, strings.Join(allowed, ", ")), map[string]any{"allowed": allowed})
+
+	return false
+}
+
+// Date checks a date in the YYYY-MM-DD format. An empty date is left to
+// Required.
+func (v *Validator) Date(field, value string) bool {
+	if value == "" {
+		return v.Required(field, false)
+	}
+
+	_, err := time.Parse("2006-01-02", value)
+
+	return v.Check(err == nil, field, CodeDate, fmt.Sprintf("the %s must be a date in the format YYYY-MM-DD", field), map[string]any{"format": "YYYY-MM-DD"})
+}
+
+func (v *Validator) YearMonth(field, value string) bool {
+	_, err := time.Parse("2006-01", value)
+
+	return v.Check(err == nil, field, CodeYearMonth, fmt.Sprintf("the %s must be a month in the format YYYY-MM", field), map[string]any{"format": "YYYY-MM"})
+}
+
+// Merge adds the field errors of a nested validation, naming the fields under
+// prefix, like shares[0].amount. Other errors are added against prefix itself.
+func (v *Validator) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) || len(validation.Fields) == 0 {
+		v.Add(prefix, CodeInvalid, err.Error(), nil)
+		return
+	}
+
+	for _, f := range validation.Fields {
+		f.Field = prefix + "." + f.Field
+		v.fields = append(v.fields, f)
+	}
+}
+
+func (v *Validator) Valid() bool {
+	return len(v.fields) == 0
+}
+
+// Err returns the collected errors as a *ValidationError, or nil when the
+// model is valid.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	if len(v.fields) == 1 {
+		return &ValidationError{Message: v.fields[0].Message, Fields: v.fields}
+	}
+
+	var (
+		names    []string
+		required = true
+	)
+
+	for _, f := range v.fields {
+		names = append(names, f.Field)
+		required = required && f.Code == CodeRequired
+	}
+
+	message := fmt.Sprintf("the fields %s are invalid", strings.Join(names, ", "))
+	if required {
+		message = fmt.Sprintf("the fields %s are required", strings.Join(names, ", "))
+	}
+
+	return &ValidationError{Message: message, Fields: v.fields}
+}
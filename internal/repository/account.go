@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+type AccountRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, a models.Account) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, a models.Account) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Account, error)
+	FindAll(ctx context.Context) ([]models.Account, error)
+	CreateTransfer(ctx context.Context, tx *sql.Tx, t models.AccountTransfer) (uuid.UUID, error)
+	FindInvoiceInstallments(ctx context.Context, tx *sql.Tx, creditCardID uuid.UUID, month string) ([]models.Installment, error)
+	InvoicePaid(ctx context.Context, tx *sql.Tx, creditCardID uuid.UUID, month string) (bool, error)
+	CreateInvoicePayment(ctx context.Context, tx *sql.Tx, ip models.InvoicePayment, installments []uuid.UUID) (models.InvoicePayment, error)
+	FindEntries(ctx context.Context, id uuid.UUID, date string) ([]models.AccountEntry, error)
+}
+
+type accountRepository struct {
+	db *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) *accountRepository {
+	return &accountRepository{db}
+}
+
+func (r *accountRepository) Create(ctx context.Context, tx *sql.Tx, a models.Account) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO account (id, id_household, name, type, id_person, opening_balance, opening_date) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	id, err := newID(a.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err = stmt.Exec(id, householdID, a.Name, a.Type, a.IDPerson, a.OpeningBalance, a.OpeningDate); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert account: %w", err), "account", "account", id)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *accountRepository) Update(ctx context.Context, tx *sql.Tx, a models.Account) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE account 
+			SET name = $1, 
+				type = $2, 
+				id_person = $3, 
+				opening_balance = $4, 
+				opening_date = $5, 
+				version = version + 1 
+			WHERE id = $6 
+				AND version = $7
+				AND id_household = $8`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(a.Name, a.Type, a.IDPerson, a.OpeningBalance, a.OpeningDate, a.ID, a.Version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying update account: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "account", "account", a.ID); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *accountRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM account WHERE id = $1 AND version = $2 AND id_household = $3`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete account: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "account", "account", id); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *accountRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Account, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	query := `SELECT id, name, type, id_person, opening_balance, opening_date, version 
+			FROM account 
+			WHERE id = $1
+				AND id_household = $2`
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	var a models.Account
+	if err = stmt.QueryRow(id, householdID).Scan(
+		&a.ID,
+		&a.Name,
+		&a.Type,
+		&a.IDPerson,
+		&a.OpeningBalance,
+		&a.OpeningDate,
+		&a.Version,
+	); err != nil && err != sql.ErrNoRows {
+		return models.Account{}, fmt.Errorf("error trying find account: %w", err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return models.Account{}, models.NotFound("account")
+	}
+
+	if err := stmt.Close(); err != nil {
+		return models.Account{}, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *accountRepository) FindAll(ctx context.Context) ([]models.Account, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, name, type, id_person, opening_balance, opening_date, version 
+			FROM account 
+			WHERE id_household = $1
+			ORDER BY name`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find all accounts: %w", err)
+	}
+
+	var accounts []models.Account
+
+	for rows.Next() {
+		var a models.Account
+		if err = rows.Scan(
+			&a.ID,
+			&a.Name,
+			&a.Type,
+			&a.IDPerson,
+			&a.OpeningBalance,
+			&a.OpeningDate,
+			&a.Version,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan account: %w", err)
+		}
+
+		accounts = append(accounts, a)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return accounts, nil
+}
+
+func (r *accountRepository) CreateTransfer(ctx context.Context, tx *sql.Tx, t models.AccountTransfer) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO account_transfer (id, id_household, id_from, id_to, amount, "date", description) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	id, err := newID(t.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err = stmt.Exec(id, householdID, t.IDFrom, t.IDTo, t.Amount, t.Date, t.Description); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert account transfer: %w", err), "account_transfer", "transfer", id)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return id, nil
+}
+
+// FindInvoiceInstallments returns the unpaid installments billed on the card
+// in the month, locking them for the rest of the transaction.
+func (r *accountRepository) FindInvoiceInstallments(ctx context.Context, tx *sql.Tx, creditCardID uuid.UUID, month string) ([]models.Installment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT i.id, i.description, i.number, i.value, i.month, i.paid, i.purchase_id
+			FROM installment i
+			INNER JOIN purchase p
+				ON p.id = i.purchase_id
+			WHERE p.id_credit_card = $1
+				AND to_char(i.month, 'YYYY-MM') = $2
+				AND i.paid = false
+				AND i.deleted_at IS NULL
+				AND i.id_household = $3
+			ORDER BY i.month, i.id
+			FOR UPDATE OF i`
+
+	rows, err := tx.Query(query, creditCardID, month, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find invoice installments: %w", err)
+	}
+
+	var installments []models.Installment
+	for rows.Next() {
+		var installment models.Installment
+		if err := rows.Scan(
+			&installment.ID,
+			&installment.Description,
+			&installment.Number,
+			&installment.Value,
+			&installment.Month,
+			&installment.Paid,
+			&installment.PurchaseID,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan invoice installment: %w", err)
+		}
+
+		installments = append(installments, installment)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return installments, nil
+}
+
+// InvoicePaid reports whether the invoice of the card for the month was
+// already paid.
+func (r *accountRepository) InvoicePaid(ctx context.Context, tx *sql.Tx, creditCardID uuid.UUID, month string) (bool, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	query := `SELECT EXISTS (
+				SELECT 1 
+				FROM invoice_payment 
+				WHERE id_credit_card = $1 
+					AND month = $2 
+					AND id_household = $3
+			)`
+
+	var paid bool
+	if err := tx.QueryRow(query, creditCardID, month, householdID).Scan(&paid); err != nil {
+		return false, fmt.Errorf("error trying find invoice payment: %w", err)
+	}
+
+	return paid, nil
+}
+
+// CreateInvoicePayment records the payment of a credit card invoice and marks
+// the installments it pays as paid. It returns the payment with its ID.
+func (r *accountRepository) CreateInvoicePayment(ctx context.Context, tx *sql.Tx, ip models.InvoicePayment, installments []uuid.UUID) (models.InvoicePayment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.InvoicePayment{}, err
+	}
+
+	if ip.ID, err = newID(ip.ID); err != nil {
+		return models.InvoicePayment{}, err
+	}
+
+	query := `INSERT INTO invoice_payment (id, id_household, id_credit_card, id_account, month, amount, "date") 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := tx.Exec(query, ip.ID, householdID, ip.IDCreditCard, ip.IDAccount, ip.Month, ip.Amount, ip.Date); err != nil {
+		return models.InvoicePayment{}, duplicateID(fmt.Errorf("error trying insert invoice payment: %w", err), "invoice_payment", "invoice payment", ip.ID)
+	}
+
+	query = `UPDATE installment 
+			SET paid = true 
+			WHERE id = ANY($1::uuid[])
+				AND id_household = $2`
+
+	if _, err := tx.Exec(query, pq.Array(uuidsToStrings(installments)), householdID); err != nil {
+		return models.InvoicePayment{}, fmt.Errorf("error trying update installments: %w", err)
+	}
+
+	return ip, nil
+}
+
+// FindEntries returns every movement of the account up to the date, ordered by
+// date, starting with the opening balance. Balance is left for the caller.
+func (r *accountRepository) FindEntries(ctx context.Context, id uuid.UUID, date string) ([]models.AccountEntry, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT e."date", e.description, e.amount FROM (
+				SELECT opening_date AS "date", 'Saldo inicial' AS description, opening_balance AS amount, 0 AS kind
+				FROM account
+				WHERE id = $1
+					AND id_household = $3
+				UNION ALL
+				SELECT "date", description, -amount, 1
+				FROM purchase
+				WHERE id_account = $1
+					AND id_household = $3
+					AND deleted_at IS NULL
+				UNION ALL
+				SELECT r."date", 'Estorno ' || p.description, r.amount, 1
+				FROM purchase_refund r
+				INNER JOIN purchase p
+					ON r.id_purchase = p.id
+				WHERE p.id_account = $1
+					AND r.id_household = $3
+					AND p.deleted_at IS NULL
+				UNION ALL
+				SELECT "date", description, -amount, 2
+				FROM account_transfer
+				WHERE id_from = $1
+					AND id_household = $3
+				UNION ALL
+				SELECT "date", description, amount, 2
+				FROM account_transfer
+				WHERE id_to = $1
+					AND id_household = $3
+				UNION ALL
+				SELECT ip."date", 'Fatura ' || cc."owner" || ' ' || ip.month, -ip.amount, 3
+				FROM invoice_payment ip
+				INNER JOIN credit_card cc
+					ON ip.id_credit_card = cc.id
+				WHERE ip.id_account = $1
+					AND ip.id_household = $3
+			) e
+			WHERE e."date" <= $2
+			ORDER BY e."date", e.kind;`
+
+	rows, err := r.db.Query(query, id, date, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find account entries: %w", err)
+	}
+
+	var entries []models.AccountEntry
+
+	for rows.Next() {
+		var e models.AccountEntry
+		if err = rows.Scan(&e.Date, &e.Description, &e.Amount); err != nil {
+			return nil, fmt.Errorf("error trying scan account entry: %w", err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return entries, nil
+}
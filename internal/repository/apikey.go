@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+type APIKeyRepository interface {
+	Create(k models.APIKey) (uuid.UUID, error)
+	FindByID(id uuid.UUID) (models.APIKey, error)
+	FindByUser(userID uuid.UUID) ([]models.APIKey, error)
+	FindByHash(hash string) (models.APIKey, error)
+	Revoke(id, userID uuid.UUID) error
+	Touch(id uuid.UUID) error
+}
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *apiKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+const apiKeyColumns = `id, id_user, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func (r *apiKeyRepository) Create(k models.APIKey) (uuid.UUID, error) {
+	query := `INSERT INTO api_key (id, id_user, name, prefix, key_hash, scopes, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying create uuid: %w", err)
+	}
+
+	if _, err := r.db.Exec(query, id, k.IDUser, k.Name, k.Prefix, k.KeyHash, pq.Array(k.Scopes), k.ExpiresAt); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying insert api key: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *apiKeyRepository) FindByID(id uuid.UUID) (models.APIKey, error) {
+	return r.findOne(`SELECT `+apiKeyColumns+` FROM api_key WHERE id = $1`, id)
+}
+
+func (r *apiKeyRepository) FindByHash(hash string) (models.APIKey, error) {
+	return r.findOne(`SELECT `+apiKeyColumns+` FROM api_key WHERE key_hash = $1`, hash)
+}
+
+func (r *apiKeyRepository) findOne(query string, arg any) (models.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return models.APIKey{}, models.NotFound("api key")
+	}
+
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("error trying find api key: %w", err)
+	}
+
+	return k, nil
+}
+
+func (r *apiKeyRepository) FindByUser(userID uuid.UUID) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_key WHERE id_user = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find api keys: %w", err)
+	}
+
+	var keys []models.APIKey
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error trying scan api key: %w", err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke ends an API key of the user. Revoking it again keeps the time it was
+// first revoked.
+func (r *apiKeyRepository) Revoke(id, userID uuid.UUID) error {
+	query := `UPDATE api_key SET revoked_at = coalesce(revoked_at, now()) WHERE id = $1 AND id_user = $2`
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("error trying revoke api key: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("api key")
+	}
+
+	return nil
+}
+
+// Touch records that the API key was just used.
+func (r *apiKeyRepository) Touch(id uuid.UUID) error {
+	if _, err := r.db.Exec(`UPDATE api_key SET last_used_at = now() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error trying update api key: %w", err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }) (models.APIKey, error) {
+	var k models.APIKey
+
+	err := row.Scan(&k.ID, &k.IDUser, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes),
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+
+	return k, err
+}
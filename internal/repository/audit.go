@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type AuditRepository interface {
+	BeginTransaction() (*sql.Tx, error)
+	Commit(tx *sql.Tx) error
+	Rollback(tx *sql.Tx) error
+	Snapshot(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) (json.RawMessage, error)
+	Create(ctx context.Context, tx *sql.Tx, event models.AuditEvent) error
+	Find(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *auditRepository {
+	return &auditRepository{db}
+}
+
+func (r *auditRepository) BeginTransaction() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *auditRepository) Commit(tx *sql.Tx) error {
+	return tx.Commit()
+}
+
+func (r *auditRepository) Rollback(tx *sql.Tx) error {
+	return tx.Rollback()
+}
+
+// Snapshot returns the row of the table as JSON, or nil when it does not
+// exist. The table always comes from the code, never from the request.
+func (r *auditRepository) Snapshot(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) (json.RawMessage, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT to_jsonb(t) FROM %s t WHERE t.id = $1 AND t.id_household = $2`, table)
+
+	var snapshot []byte
+
+	err = tx.QueryRow(query, id, householdID).Scan(&snapshot)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error trying snapshot %s: %w", table, err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return snapshot, nil
+}
+
+func (r *auditRepository) Create(ctx context.Context, tx *sql.Tx, event models.AuditEvent) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (id, id_household, entity, entity_id, action, actor, request_id, before, after, diff) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return fmt.Errorf("error trying create uuid: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		query,
+		id,
+		householdID,
+		event.Entity,
+		event.EntityID,
+		event.Action,
+		event.Actor,
+		event.RequestID,
+		nullJSON(event.Before),
+		nullJSON(event.After),
+		nullJSON(event.Diff),
+	); err != nil {
+		return fmt.Errorf("error trying insert audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *auditRepository) Find(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	where("id_household = $%d", householdID)
+
+	if filter.Entity != "" {
+		where("entity = $%d", filter.Entity)
+	}
+
+	if filter.EntityID.Valid {
+		where("entity_id = $%d", filter.EntityID.UUID)
+	}
+
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+
+	if filter.From != "" {
+		where(`created_at >= $%d::date`, filter.From)
+	}
+
+	if filter.To != "" {
+		where(`created_at < $%d::date + 1`, filter.To)
+	}
+
+	query := `SELECT id, entity, entity_id, action, actor, request_id, before, after, diff, created_at 
+			FROM audit_log 
+			WHERE ` + strings.Join(conditions, " AND ")
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find audit events: %w", err)
+	}
+
+	var events []models.AuditEvent
+
+	for rows.Next() {
+		var (
+			e                    models.AuditEvent
+			before, after, diffs []byte
+		)
+
+		if err := rows.Scan(
+			&e.ID,
+			&e.Entity,
+			&e.EntityID,
+			&e.Action,
+			&e.Actor,
+			&e.RequestID,
+			&before,
+			&after,
+			&diffs,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan audit event: %w", err)
+		}
+
+		e.Before, e.After, e.Diff = before, after, diffs
+
+		events = append(events, e)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	return string(raw)
+}
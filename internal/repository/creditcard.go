@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -9,11 +10,11 @@ import (
 )
 
 type CreditCardRepository interface {
-	Create(cc models.CreditCard) error
-	Update(cc models.CreditCard) error
-	Delete(id uuid.UUID) error
-	FindByID(id uuid.UUID) (models.CreditCard, error)
-	FindAll() ([]models.CreditCard, error)
+	Create(ctx context.Context, tx *sql.Tx, cc models.CreditCard) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, cc models.CreditCard) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.CreditCard, error)
+	FindAll(ctx context.Context) ([]models.CreditCard, error)
 }
 
 type creditCardRepository struct {
@@ -24,110 +25,144 @@ func NewRepositoryCreditCard(db *sql.DB) *creditCardRepository {
 	return &creditCardRepository{db}
 }
 
-func (r creditCardRepository) Create(cc models.CreditCard) error {
-	query := `INSERT INTO credit_card (id, owner, final_card_num, type, invoice_closing_day) VALUES ($1, $2, $3, $4, $5)`
-	stmt, err := r.db.Prepare(query)
+func (r creditCardRepository) Create(ctx context.Context, tx *sql.Tx, cc models.CreditCard) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO credit_card (id, id_household, owner, final_card_num, type, invoice_closing_day, id_person) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	id, err := uuid.NewUUID()
+	id, err := newID(cc.ID)
 	if err != nil {
-		return fmt.Errorf("error trying create uuid: %v", err)
+		return uuid.Nil, err
 	}
 
-	if _, err = stmt.Exec(id, cc.Owner, cc.FinalCardNum, cc.Type, cc.InvoiceClosingDay); err != nil {
-		return fmt.Errorf("error trying insert credit card: %v", err)
+	if _, err = stmt.Exec(id, householdID, cc.Owner, cc.FinalCardNum, cc.Type, cc.InvoiceClosingDay, cc.IDPerson); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert credit card: %w", err), "credit_card", "credit card", id)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
-func (r creditCardRepository) Update(cc models.CreditCard) error {
+func (r creditCardRepository) Update(ctx context.Context, tx *sql.Tx, cc models.CreditCard) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE credit_card 
 				SET owner = $1,
 					final_card_num = $2,
 					type = $3,
-					invoice_closing_day = $4
-				WHERE id = $5`
-	stmt, err := r.db.Prepare(query)
+					invoice_closing_day = $4,
+					id_person = $5,
+					version = version + 1
+				WHERE id = $6
+					AND version = $7
+					AND id_household = $8
+					AND deleted_at IS NULL`
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	if _, err = stmt.Exec(
+	result, err := stmt.Exec(
 		cc.Owner,
 		cc.FinalCardNum,
 		cc.Type,
 		cc.InvoiceClosingDay,
-		cc.ID); err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying update credit card: %v", err)
+		cc.IDPerson,
+		cc.ID,
+		cc.Version,
+		householdID)
+	if err != nil {
+		return fmt.Errorf("error trying update credit card: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist credit card with this id")
+	if err := checkVersion(ctx, tx, result, "credit_card", "credit card", cc.ID); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return nil
 }
 
-func (r creditCardRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM credit_card WHERE id = $1`
+func (r creditCardRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := r.db.Prepare(query)
+	query := `UPDATE credit_card SET deleted_at = now(), version = version + 1 WHERE id = $1 AND version = $2 AND id_household = $3 AND deleted_at IS NULL`
+
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	_, err = stmt.Exec(id)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying delete credit card: %v", err)
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete credit card: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist credit card with this id")
+	if err := checkVersion(ctx, tx, result, "credit_card", "credit card", id); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return nil
 }
 
-func (r creditCardRepository) FindByID(id uuid.UUID) (models.CreditCard, error) {
-	query := "SELECT id, owner, final_card_num, type, invoice_closing_day FROM credit_card WHERE id = $1"
+func (r creditCardRepository) FindByID(ctx context.Context, id uuid.UUID) (models.CreditCard, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.CreditCard{}, err
+	}
+
+	query := "SELECT id, owner, final_card_num, type, invoice_closing_day, id_person, version FROM credit_card WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL"
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return models.CreditCard{}, fmt.Errorf("error trying prepare statment: %v", err)
+		return models.CreditCard{}, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
 	var cc models.CreditCard
-	if err = stmt.QueryRow(id).Scan(&cc.ID, &cc.Owner, &cc.FinalCardNum, &cc.Type, &cc.InvoiceClosingDay); err != nil && err != sql.ErrNoRows {
-		return models.CreditCard{}, fmt.Errorf("error trying find credit card: %v", err)
+	if err = stmt.QueryRow(id, householdID).Scan(&cc.ID, &cc.Owner, &cc.FinalCardNum, &cc.Type, &cc.InvoiceClosingDay, &cc.IDPerson, &cc.Version); err != nil && err != sql.ErrNoRows {
+		return models.CreditCard{}, fmt.Errorf("error trying find credit card: %w", err)
 	}
 
 	if err != nil && err == sql.ErrNoRows {
-		return models.CreditCard{}, fmt.Errorf("does not exist this id")
+		return models.CreditCard{}, models.NotFound("credit card")
 	}
 
 	if err := stmt.Close(); err != nil {
-		return models.CreditCard{}, fmt.Errorf("error trying close statment: %v", err)
+		return models.CreditCard{}, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return cc, nil
 }
 
-func (r creditCardRepository) FindAll() ([]models.CreditCard, error) {
+func (r creditCardRepository) FindAll(ctx context.Context) ([]models.CreditCard, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `SELECT 
 				id, 
 				owner, 
@@ -137,21 +172,25 @@ func (r creditCardRepository) FindAll() ([]models.CreditCard, error) {
 					WHEN type = 'V' THEN 'Virtual'
 					WHEN type = 'VT' THEN 'Virtual Temporário'
 				END AS type,
-				invoice_closing_day 
+				invoice_closing_day,
+				id_person,
+				version 
 			FROM credit_card 
+			WHERE id_household = $1
+				AND deleted_at IS NULL
 			ORDER BY owner`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, householdID)
 	if err != nil {
-		return []models.CreditCard{}, fmt.Errorf("error trying find all credit cards: %v", err)
+		return []models.CreditCard{}, fmt.Errorf("error trying find all credit cards: %w", err)
 	}
 
 	var creditCards []models.CreditCard
 
 	for rows.Next() {
 		var cc models.CreditCard
-		if err = rows.Scan(&cc.ID, &cc.Owner, &cc.FinalCardNum, &cc.Type, &cc.InvoiceClosingDay); err != nil && err != sql.ErrNoRows {
-			return []models.CreditCard{}, fmt.Errorf("error trying scan credit card: %v", err)
+		if err = rows.Scan(&cc.ID, &cc.Owner, &cc.FinalCardNum, &cc.Type, &cc.InvoiceClosingDay, &cc.IDPerson, &cc.Version); err != nil && err != sql.ErrNoRows {
+			return []models.CreditCard{}, fmt.Errorf("error trying scan credit card: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -162,7 +201,7 @@ func (r creditCardRepository) FindAll() ([]models.CreditCard, error) {
 	}
 
 	if rows.Close(); err != nil {
-		return []models.CreditCard{}, fmt.Errorf("error trying close rows: %v", err)
+		return []models.CreditCard{}, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	return creditCards, nil
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type ExportRepository interface {
+	FindPurchases(ctx context.Context, from, to string) ([]models.ExportPurchase, error)
+	FindRefunds(ctx context.Context, from, to string) ([]models.ExportRefund, error)
+	FindPayments(ctx context.Context, from, to string) ([]models.ExportPayment, error)
+}
+
+type exportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) *exportRepository {
+	return &exportRepository{db}
+}
+
+func (r *exportRepository) FindPurchases(ctx context.Context, from, to string) ([]models.ExportPurchase, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				p.id, 
+				to_char(p."date", 'YYYY-MM-DD'), 
+				p.description, 
+				p.place, 
+				p.amount, 
+				p.id_purchase_type, 
+				per."name", 
+				COALESCE(cc."owner" || ' ' || cc.final_card_num, ''), 
+				COALESCE(a."name", '')
+			FROM purchase p
+			INNER JOIN person per
+				ON p.id_person = per.id
+			LEFT JOIN credit_card cc
+				ON p.id_credit_card = cc.id
+			LEFT JOIN account a
+				ON p.id_account = a.id
+			WHERE p."date" BETWEEN $1 AND $2
+				AND p.id_household = $3
+				AND p.deleted_at IS NULL
+			ORDER BY p."date", p.id`
+
+	rows, err := r.db.Query(query, from, to, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find purchases to export: %w", err)
+	}
+
+	var (
+		purchases []models.ExportPurchase
+		index     = map[uuid.UUID]int{}
+	)
+
+	for rows.Next() {
+		var p models.ExportPurchase
+		if err := rows.Scan(
+			&p.ID,
+			&p.Date,
+			&p.Description,
+			&p.Place,
+			&p.Amount,
+			&p.IDPurchaseType,
+			&p.Person,
+			&p.CreditCard,
+			&p.Account,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan purchase to export: %w", err)
+		}
+
+		index[p.ID] = len(purchases)
+		purchases = append(purchases, p)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	query = `SELECT ps.id_purchase, ps.id_person, per."name", ps.percentage, ps.amount
+			FROM purchase_share ps
+			INNER JOIN purchase p
+				ON ps.id_purchase = p.id
+			INNER JOIN person per
+				ON ps.id_person = per.id
+			WHERE p."date" BETWEEN $1 AND $2
+				AND p.id_household = $3
+				AND p.deleted_at IS NULL
+			ORDER BY ps.id_purchase, per."name"`
+
+	rows, err = r.db.Query(query, from, to, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find purchase shares to export: %w", err)
+	}
+
+	for rows.Next() {
+		var (
+			purchaseID uuid.UUID
+			share      models.PurchaseShare
+		)
+
+		if err := rows.Scan(&purchaseID, &share.IDPerson, &share.Person, &share.Percentage, &share.Amount); err != nil {
+			return nil, fmt.Errorf("error trying scan purchase share to export: %w", err)
+		}
+
+		if i, ok := index[purchaseID]; ok {
+			purchases[i].Shares = append(purchases[i].Shares, share)
+		}
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return purchases, nil
+}
+
+// FindRefunds returns the refunds made between the dates with the purchase
+// they refund, so its expense can be reversed in the same accounts.
+func (r *exportRepository) FindRefunds(ctx context.Context, from, to string) ([]models.ExportRefund, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				r.id, 
+				to_char(r."date", 'YYYY-MM-DD'), 
+				r.amount, 
+				p.id, 
+				to_char(p."date", 'YYYY-MM-DD'), 
+				p.description, 
+				p.place, 
+				p.amount, 
+				p.id_purchase_type, 
+				per."name", 
+				COALESCE(cc."owner" || ' ' || cc.final_card_num, ''), 
+				COALESCE(a."name", '')
+			FROM purchase_refund r
+			INNER JOIN purchase p
+				ON r.id_purchase = p.id
+			INNER JOIN person per
+				ON p.id_person = per.id
+			LEFT JOIN credit_card cc
+				ON p.id_credit_card = cc.id
+			LEFT JOIN account a
+				ON p.id_account = a.id
+			WHERE r."date" BETWEEN $1 AND $2
+				AND r.id_household = $3
+				AND p.deleted_at IS NULL
+			ORDER BY r."date", r.id`
+
+	rows, err := r.db.Query(query, from, to, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find refunds to export: %w", err)
+	}
+
+	var (
+		refunds []models.ExportRefund
+		index   = map[uuid.UUID][]int{}
+	)
+
+	for rows.Next() {
+		var refund models.ExportRefund
+		if err := rows.Scan(
+			&refund.ID,
+			&refund.Date,
+			&refund.Amount,
+			&refund.Purchase.ID,
+			&refund.Purchase.Date,
+			&refund.Purchase.Description,
+			&refund.Purchase.Place,
+			&refund.Purchase.Amount,
+			&refund.Purchase.IDPurchaseType,
+			&refund.Purchase.Person,
+			&refund.Purchase.CreditCard,
+			&refund.Purchase.Account,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan refund to export: %w", err)
+		}
+
+		index[refund.Purchase.ID] = append(index[refund.Purchase.ID], len(refunds))
+		refunds = append(refunds, refund)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	query = `SELECT ps.id_purchase, ps.id_person, per."name", ps.percentage, ps.amount
+			FROM purchase_share ps
+			INNER JOIN person per
+				ON ps.id_person = per.id
+			WHERE ps.id_purchase IN (
+				SELECT r.id_purchase 
+				FROM purchase_refund r 
+				WHERE r."date" BETWEEN $1 AND $2
+					AND r.id_household = $3
+			)
+			ORDER BY ps.id_purchase, per."name"`
+
+	rows, err = r.db.Query(query, from, to, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find refund shares to export: %w", err)
+	}
+
+	for rows.Next() {
+		var (
+			purchaseID uuid.UUID
+			share      models.PurchaseShare
+		)
+
+		if err := rows.Scan(&purchaseID, &share.IDPerson, &share.Person, &share.Percentage, &share.Amount); err != nil {
+			return nil, fmt.Errorf("error trying scan refund share to export: %w", err)
+		}
+
+		for _, i := range index[purchaseID] {
+			refunds[i].Purchase.Shares = append(refunds[i].Purchase.Shares, share)
+		}
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return refunds, nil
+}
+
+// FindPayments returns the invoice payments and the installments paid one by
+// one, leaving out installments already covered by an invoice payment. The
+// credits of refunds, installments numbered 0, are left out too: the refund
+// is exported on its own.
+func (r *exportRepository) FindPayments(ctx context.Context, from, to string) ([]models.ExportPayment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				ip.id, 
+				to_char(ip."date", 'YYYY-MM-DD'), 
+				'Fatura ' || ip.month, 
+				ip.amount, 
+				cc."owner" || ' ' || cc.final_card_num, 
+				a."name"
+			FROM invoice_payment ip
+			INNER JOIN credit_card cc
+				ON ip.id_credit_card = cc.id
+			INNER JOIN account a
+				ON ip.id_account = a.id
+			WHERE ip."date" BETWEEN $1 AND $2
+				AND ip.id_household = $3
+			UNION ALL
+			SELECT 
+				i.id, 
+				to_char(i.month, 'YYYY-MM-DD'), 
+				p.description || ' - ' || i.description, 
+				i.value, 
+				cc."owner" || ' ' || cc.final_card_num, 
+				''
+			FROM installment i
+			INNER JOIN purchase p
+				ON i.purchase_id = p.id
+			INNER JOIN credit_card cc
+				ON p.id_credit_card = cc.id
+			WHERE i.paid = true
+				AND i.number > 0
+				AND i.id_household = $3
+				AND i.deleted_at IS NULL
+				AND i.month BETWEEN $1 AND $2
+				AND NOT EXISTS (
+					SELECT 1 
+					FROM invoice_payment ip 
+					WHERE ip.id_credit_card = p.id_credit_card 
+						AND ip.month = to_char(i.month, 'YYYY-MM')
+				)
+			ORDER BY 2, 1`
+
+	rows, err := r.db.Query(query, from, to, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find payments to export: %w", err)
+	}
+
+	var payments []models.ExportPayment
+
+	for rows.Next() {
+		var p models.ExportPayment
+		if err := rows.Scan(&p.ID, &p.Date, &p.Description, &p.Amount, &p.CreditCard, &p.Account); err != nil {
+			return nil, fmt.Errorf("error trying scan payment to export: %w", err)
+		}
+
+		payments = append(payments, p)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return payments, nil
+}
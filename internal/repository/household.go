@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+type HouseholdRepository interface {
+	BeginTransaction() (*sql.Tx, error)
+	Commit(tx *sql.Tx) error
+	Rollback(tx *sql.Tx) error
+	Create(tx *sql.Tx, h models.Household) (uuid.UUID, error)
+	FindByID(id uuid.UUID) (models.Household, error)
+	FindByUser(userID uuid.UUID) ([]models.Household, error)
+	FindAllIDs() ([]uuid.UUID, error)
+	FindRole(householdID, userID uuid.UUID) (models.Role, error)
+	AddMember(tx *sql.Tx, householdID, userID uuid.UUID, role models.Role) error
+	UpdateRole(householdID, userID uuid.UUID, role models.Role) error
+	RemoveMember(householdID, userID uuid.UUID) error
+	FindMembers(householdID uuid.UUID) ([]models.HouseholdMember, error)
+	FindMember(householdID, userID uuid.UUID) (models.HouseholdMember, error)
+	AdoptOrphans(userID uuid.UUID) error
+}
+
+type householdRepository struct {
+	db *sql.DB
+}
+
+func NewHouseholdRepository(db *sql.DB) *householdRepository {
+	return &householdRepository{db}
+}
+
+func (r *householdRepository) BeginTransaction() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *householdRepository) Commit(tx *sql.Tx) error {
+	return tx.Commit()
+}
+
+func (r *householdRepository) Rollback(tx *sql.Tx) error {
+	return tx.Rollback()
+}
+
+func (r *householdRepository) Create(tx *sql.Tx, h models.Household) (uuid.UUID, error) {
+	query := `INSERT INTO household (id, name) VALUES ($1, $2)`
+
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying create uuid: %w", err)
+	}
+
+	if _, err := tx.Exec(query, id, h.Name); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying insert household: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *householdRepository) FindByID(id uuid.UUID) (models.Household, error) {
+	query := `SELECT id, name, created_at FROM household WHERE id = $1`
+
+	var h models.Household
+
+	err := r.db.QueryRow(query, id).Scan(&h.ID, &h.Name, &h.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.Household{}, models.NotFound("household")
+	}
+
+	if err != nil {
+		return models.Household{}, fmt.Errorf("error trying find household: %w", err)
+	}
+
+	return h, nil
+}
+
+func (r *householdRepository) FindByUser(userID uuid.UUID) ([]models.Household, error) {
+	query := `SELECT h.id, h.name, m.role, h.created_at
+			FROM household h
+			INNER JOIN household_member m
+				ON m.id_household = h.id
+			WHERE m.id_user = $1
+			ORDER BY h.name`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find households: %w", err)
+	}
+
+	var households []models.Household
+
+	for rows.Next() {
+		var h models.Household
+		if err := rows.Scan(&h.ID, &h.Name, &h.Role, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error trying scan household: %w", err)
+		}
+
+		households = append(households, h)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return households, nil
+}
+
+// FindAllIDs returns every household, for the jobs that run outside of a
+// request and have to go through each of them.
+func (r *householdRepository) FindAllIDs() ([]uuid.UUID, error) {
+	rows, err := r.db.Query(`SELECT id FROM household ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find households: %w", err)
+	}
+
+	var ids []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error trying scan household: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// FindRole returns the role of the user in the household, or a not found
+// error when the user is not a member of it.
+func (r *householdRepository) FindRole(householdID, userID uuid.UUID) (models.Role, error) {
+	query := `SELECT role FROM household_member WHERE id_household = $1 AND id_user = $2`
+
+	var role models.Role
+
+	err := r.db.QueryRow(query, householdID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", models.NotFound("household")
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error trying find household member: %w", err)
+	}
+
+	return role, nil
+}
+
+func (r *householdRepository) AddMember(tx *sql.Tx, householdID, userID uuid.UUID, role models.Role) error {
+	query := `INSERT INTO household_member (id_household, id_user, role) VALUES ($1, $2, $3)`
+
+	_, err := tx.Exec(query, householdID, userID, role)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return models.Conflict("the user is already a member of the household")
+	}
+
+	if err != nil {
+		return fmt.Errorf("error trying insert household member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *householdRepository) UpdateRole(householdID, userID uuid.UUID, role models.Role) error {
+	query := `UPDATE household_member SET role = $3 WHERE id_household = $1 AND id_user = $2`
+
+	result, err := r.db.Exec(query, householdID, userID, role)
+	if err != nil {
+		return fmt.Errorf("error trying update household member: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("household member")
+	}
+
+	return nil
+}
+
+func (r *householdRepository) RemoveMember(householdID, userID uuid.UUID) error {
+	query := `DELETE FROM household_member WHERE id_household = $1 AND id_user = $2`
+
+	result, err := r.db.Exec(query, householdID, userID)
+	if err != nil {
+		return fmt.Errorf("error trying delete household member: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("household member")
+	}
+
+	return nil
+}
+
+func (r *householdRepository) FindMembers(householdID uuid.UUID) ([]models.HouseholdMember, error) {
+	query := `SELECT u.id, u.email, u.name, m.role, m.created_at
+			FROM household_member m
+			INNER JOIN app_user u
+				ON m.id_user = u.id
+			WHERE m.id_household = $1
+			ORDER BY u.name`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find household members: %w", err)
+	}
+
+	var members []models.HouseholdMember
+
+	for rows.Next() {
+		var m models.HouseholdMember
+		if err := rows.Scan(&m.IDUser, &m.Email, &m.Name, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error trying scan household member: %w", err)
+		}
+
+		members = append(members, m)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return members, nil
+}
+
+// AdoptOrphans makes the user the owner of every household without members,
+// which is how the first admin gets the data migrated to the default one.
+func (r *householdRepository) FindMember(householdID, userID uuid.UUID) (models.HouseholdMember, error) {
+	query := `SELECT u.id, u.email, u.name, m.role, m.created_at
+			FROM household_member m
+			INNER JOIN app_user u
+				ON m.id_user = u.id
+			WHERE m.id_household = $1
+				AND m.id_user = $2`
+
+	var m models.HouseholdMember
+
+	err := r.db.QueryRow(query, householdID, userID).Scan(&m.IDUser, &m.Email, &m.Name, &m.Role, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.HouseholdMember{}, models.NotFound("member")
+	}
+
+	if err != nil {
+		return models.HouseholdMember{}, fmt.Errorf("error trying find household member: %w", err)
+	}
+
+	return m, nil
+}
+
+func (r *householdRepository) AdoptOrphans(userID uuid.UUID) error {
+	query := `INSERT INTO household_member (id_household, id_user, role)
+			SELECT h.id, $1, 'owner'
+			FROM household h
+			WHERE NOT EXISTS (SELECT 1 FROM household_member m WHERE m.id_household = h.id)`
+
+	if _, err := r.db.Exec(query, userID); err != nil {
+		return fmt.Errorf("error trying adopt households: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+func assertNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+
+	var notFound *models.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("%s from another household: got %v, want not found", what, err)
+	}
+}
+
+func TestHouseholdIsolationPersons(t *testing.T) {
+	db := openTestDB(t)
+	home := newTestHousehold(t, db, "Home")
+	other := newTestHousehold(t, db, "Other")
+
+	persons := NewRepositoryPerson(db)
+
+	var id uuid.UUID
+	err := inTx(t, db, func(tx *sql.Tx) (err error) {
+		id, err = persons.Create(home, tx, models.Person{Name: "Ana"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("creating the person: %v", err)
+	}
+
+	_, err = persons.FindByID(other, id)
+	assertNotFound(t, "get", err)
+
+	list, err := persons.FindAll(other)
+	if err != nil {
+		t.Fatalf("listing the persons: %v", err)
+	}
+
+	for _, p := range list {
+		if p.ID == id {
+			t.Errorf("list: the person of another household was listed")
+		}
+	}
+
+	err = inTx(t, db, func(tx *sql.Tx) error {
+		return persons.Update(other, tx, models.Person{ID: id, Name: "Bia", Version: 1})
+	})
+	assertNotFound(t, "update", err)
+
+	err = inTx(t, db, func(tx *sql.Tx) error {
+		return persons.Delete(other, tx, id, 1)
+	})
+	assertNotFound(t, "delete", err)
+
+	person, err := persons.FindByID(home, id)
+	if err != nil {
+		t.Fatalf("finding the person in its household: %v", err)
+	}
+
+	if person.Name != "Ana" || person.Version != 1 {
+		t.Errorf("got %+v, want the person untouched", person)
+	}
+}
+
+func TestHouseholdIsolationPurchases(t *testing.T) {
+	db := openTestDB(t)
+	home := newTestHousehold(t, db, "Home")
+	other := newTestHousehold(t, db, "Other")
+
+	purchases := NewRepositoryPurchase(db)
+
+	var purchase models.Purchase
+	err := inTx(t, db, func(tx *sql.Tx) (err error) {
+		if purchase.IDPerson, err = NewRepositoryPerson(db).Create(home, tx, models.Person{Name: "Ana"}); err != nil {
+			return err
+		}
+
+		if purchase.IDPaymentType, err = NewRepositoryPaymentType(db).Create(home, tx, models.PaymentType{Name: "Pix"}); err != nil {
+			return err
+		}
+
+		if purchase.IDPurchaseType, err = NewRepositoryPurchaseType(db).Create(home, tx, models.PurchaseType{Name: "Market"}); err != nil {
+			return err
+		}
+
+		purchase.Description = "Groceries"
+		purchase.Amount = 80
+		purchase.Date = "2024-03-10"
+		purchase.Version = 1
+
+		purchase.ID, err = purchases.Create(home, tx, purchase)
+
+		return err
+	})
+	if err != nil {
+		t.Fatalf("creating the purchase: %v", err)
+	}
+
+	_, err = purchases.FindByID(other, purchase.ID)
+	assertNotFound(t, "get", err)
+
+	list, err := purchases.FindAll(other)
+	if err != nil {
+		t.Fatalf("listing the purchases: %v", err)
+	}
+
+	for _, p := range list {
+		if p.ID == purchase.ID {
+			t.Errorf("list: the purchase of another household was listed")
+		}
+	}
+
+	err = inTx(t, db, func(tx *sql.Tx) error {
+		changed := purchase
+		changed.Amount = 1
+
+		return purchases.Update(other, tx, changed)
+	})
+	assertNotFound(t, "update", err)
+
+	err = inTx(t, db, func(tx *sql.Tx) error {
+		return purchases.Delete(other, tx, purchase.ID, purchase.Version)
+	})
+	assertNotFound(t, "delete", err)
+
+	// The person, payment type and purchase type belong to the first
+	// household, so a purchase of the other one cannot reference them.
+	err = inTx(t, db, func(tx *sql.Tx) error {
+		foreign := purchase
+		foreign.ID = uuid.Nil
+
+		_, err := purchases.Create(other, tx, foreign)
+
+		return err
+	})
+	if err == nil {
+		t.Errorf("reference ids: a purchase referenced the records of another household")
+	}
+
+	stored, err := purchases.FindEntity(home, purchase.ID)
+	if err != nil {
+		t.Fatalf("finding the purchase in its household: %v", err)
+	}
+
+	if stored.Amount != 80 || stored.Version != 1 {
+		t.Errorf("got %+v, want the purchase untouched", stored)
+	}
+}
+
+func TestTakenIDAnswersAlikeInEveryHousehold(t *testing.T) {
+	db := openTestDB(t)
+	home := newTestHousehold(t, db, "Home")
+	other := newTestHousehold(t, db, "Other")
+
+	persons := NewRepositoryPerson(db)
+
+	id := uuid.New()
+
+	create := func(ctx context.Context) error {
+		return inTx(t, db, func(tx *sql.Tx) error {
+			_, err := persons.Create(ctx, tx, models.Person{ID: id, Name: "Ana"})
+			return err
+		})
+	}
+
+	if err := create(home); err != nil {
+		t.Fatalf("creating the person: %v", err)
+	}
+
+	same, another := create(home), create(other)
+
+	var conflict *models.ConflictError
+	if !errors.As(same, &conflict) || !errors.As(another, &conflict) {
+		t.Fatalf("got %v and %v, want conflicts", same, another)
+	}
+
+	if same.Error() != another.Error() {
+		t.Errorf("the id taken in another household answers %q, in the same one %q", another, same)
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+// newID returns the id for a new record: the one the client chose, so
+// offline clients can refer to the record before it is synced, or a new one
+// when the client left it empty.
+func newID(id uuid.UUID) (uuid.UUID, error) {
+	if id != uuid.Nil {
+		return id, nil
+	}
+
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying create uuid: %w", err)
+	}
+
+	return id, nil
+}
+
+// duplicateID turns the error of inserting a record whose id is already taken
+// into a conflict, leaving any other error as it is. Ids are unique across
+// households, so the answer is the same whether the id is taken in the
+// household of the request or in another one, and does not tell that a record
+// exists: it cannot be used to find out what other households hold.
+func duplicateID(err error, table, entity string, id uuid.UUID) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation &&
+		(pqErr.Constraint == table+"_pkey" || pqErr.Constraint == table+"_household_id_key") {
+		return models.Conflict("the id %s cannot be used for a new %s, choose another one", id, entity)
+	}
+
+	return err
+}
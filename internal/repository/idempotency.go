@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type IdempotencyRepository interface {
+	Reserve(k models.IdempotencyKey) (bool, error)
+	Find(userID, householdID uuid.UUID, key string) (models.IdempotencyKey, error)
+	Complete(userID, householdID uuid.UUID, key string, response models.IdempotentResponse) error
+	Release(userID, householdID uuid.UUID, key string) error
+	DeleteExpired() (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *idempotencyRepository {
+	return &idempotencyRepository{db}
+}
+
+// Reserve stores a key for the request about to run, unless the user already
+// has it in the household. An expired key is dropped first, so it can be used again. It
+// reports whether the key was stored.
+func (r *idempotencyRepository) Reserve(k models.IdempotencyKey) (bool, error) {
+	if _, err := r.db.Exec(`DELETE FROM idempotency_key WHERE id_user = $1 AND id_household = $2 AND key = $3 AND expires_at <= now()`, k.IDUser, k.IDHousehold, k.Key); err != nil {
+		return false, fmt.Errorf("error trying delete expired idempotency key: %w", err)
+	}
+
+	query := `INSERT INTO idempotency_key (key, id_user, id_household, request_hash, expires_at) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id_user, id_household, key) DO NOTHING`
+
+	result, err := r.db.Exec(query, k.Key, k.IDUser, k.IDHousehold, k.RequestHash, k.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("error trying insert idempotency key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error trying insert idempotency key: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+func (r *idempotencyRepository) Find(userID, householdID uuid.UUID, key string) (models.IdempotencyKey, error) {
+	query := `SELECT key, id_user, id_household, request_hash, status, headers, body, expires_at
+			FROM idempotency_key
+			WHERE id_user = $1 AND id_household = $2 AND key = $3`
+
+	var (
+		k       models.IdempotencyKey
+		status  sql.NullInt32
+		headers []byte
+		body    []byte
+	)
+
+	err := r.db.QueryRow(query, userID, householdID, key).Scan(&k.Key, &k.IDUser, &k.IDHousehold, &k.RequestHash, &status, &headers, &body, &k.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return models.IdempotencyKey{}, models.NotFound("idempotency key")
+	}
+
+	if err != nil {
+		return models.IdempotencyKey{}, fmt.Errorf("error trying find idempotency key: %w", err)
+	}
+
+	if status.Valid {
+		k.Response = &models.IdempotentResponse{Status: int(status.Int32), Body: body}
+
+		if err := json.Unmarshal(headers, &k.Response.Headers); err != nil {
+			return models.IdempotencyKey{}, fmt.Errorf("error trying decode idempotency key headers: %w", err)
+		}
+	}
+
+	return k, nil
+}
+
+func (r *idempotencyRepository) Complete(userID, householdID uuid.UUID, key string, response models.IdempotentResponse) error {
+	query := `UPDATE idempotency_key SET status = $4, headers = $5, body = $6 WHERE id_user = $1 AND id_household = $2 AND key = $3`
+
+	headers, err := json.Marshal(response.Headers)
+	if err != nil {
+		return fmt.Errorf("error trying encode idempotency key headers: %w", err)
+	}
+
+	if _, err := r.db.Exec(query, userID, householdID, key, response.Status, headers, response.Body); err != nil {
+		return fmt.Errorf("error trying update idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release drops a key whose request did not finish, so it can be retried.
+func (r *idempotencyRepository) Release(userID, householdID uuid.UUID, key string) error {
+	if _, err := r.db.Exec(`DELETE FROM idempotency_key WHERE id_user = $1 AND id_household = $2 AND key = $3`, userID, householdID, key); err != nil {
+		return fmt.Errorf("error trying delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_key WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("error trying delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
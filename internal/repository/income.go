@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type IncomeRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, i models.Income) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, i models.Income) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Income, error)
+	FindByMonth(ctx context.Context, month string) ([]models.Income, error)
+	FindAll(ctx context.Context) ([]models.Income, error)
+}
+
+type incomeRepository struct {
+	db *sql.DB
+}
+
+func NewIncomeRepository(db *sql.DB) *incomeRepository {
+	return &incomeRepository{db}
+}
+
+const incomeColumns = `i.id, 
+				i.description, 
+				i.source, 
+				i.category, 
+				i.amount, 
+				i."date", 
+				i.recurrence, 
+				i.end_date, 
+				i.id_person, 
+				per."name",
+				i.version`
+
+func (r *incomeRepository) Create(ctx context.Context, tx *sql.Tx, i models.Income) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO income (id, id_household, description, source, category, amount, "date", recurrence, end_date, id_person) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	id, err := newID(i.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err = stmt.Exec(
+		id,
+		householdID,
+		i.Description,
+		i.Source,
+		i.Category,
+		i.Amount,
+		i.Date,
+		i.Recurrence,
+		sql.NullString{String: i.EndDate, Valid: i.EndDate != ""},
+		i.IDPerson,
+	); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert income: %w", err), "income", "income", id)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *incomeRepository) Update(ctx context.Context, tx *sql.Tx, i models.Income) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE income
+		SET description = $1, 
+			source = $2, 
+			category = $3, 
+			amount = $4, 
+			"date" = $5, 
+			recurrence = $6, 
+			end_date = $7, 
+			id_person = $8,
+			version = version + 1
+		WHERE id = $9
+			AND version = $10
+			AND id_household = $11`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(
+		i.Description,
+		i.Source,
+		i.Category,
+		i.Amount,
+		i.Date,
+		i.Recurrence,
+		sql.NullString{String: i.EndDate, Valid: i.EndDate != ""},
+		i.IDPerson,
+		i.ID,
+		i.Version,
+		householdID,
+	)
+	if err != nil {
+		return fmt.Errorf("error trying update income: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "income", "income", i.ID); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *incomeRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM income WHERE id = $1 AND version = $2 AND id_household = $3`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete income: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "income", "income", id); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *incomeRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Income, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Income{}, err
+	}
+
+	query := `SELECT ` + incomeColumns + `
+			FROM income i
+			INNER JOIN person per
+				ON i.id_person = per.id
+			WHERE i.id = $1
+				AND i.id_household = $2`
+
+	rows, err := r.db.Query(query, id, householdID)
+	if err != nil {
+		return models.Income{}, fmt.Errorf("error trying find income: %w", err)
+	}
+
+	incomes, err := scanIncomes(rows)
+	if err != nil {
+		return models.Income{}, err
+	}
+
+	if len(incomes) == 0 {
+		return models.Income{}, models.NotFound("income")
+	}
+
+	return incomes[0], nil
+}
+
+// FindByMonth returns the incomes received in the month, including recurring
+// incomes that started before the month and have not ended yet.
+func (r *incomeRepository) FindByMonth(ctx context.Context, month string) ([]models.Income, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + incomeColumns + `
+			FROM income i
+			INNER JOIN person per
+				ON i.id_person = per.id
+			WHERE i.id_household = $2
+				AND (
+					(i.recurrence = 'none' AND to_char(i."date", 'YYYY-MM') = $1)
+					OR (
+						i.recurrence IN ('monthly', 'yearly')
+						AND to_char(i."date", 'YYYY-MM') <= $1
+						AND (i.end_date IS NULL OR to_char(i.end_date, 'YYYY-MM') >= $1)
+						AND (i.recurrence = 'monthly' OR to_char(i."date", 'MM') = substr($1, 6, 2))
+					)
+				)
+			ORDER BY i."date"`
+
+	rows, err := r.db.Query(query, month, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find income by month: %w", err)
+	}
+
+	return scanIncomes(rows)
+}
+
+func (r *incomeRepository) FindAll(ctx context.Context) ([]models.Income, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + incomeColumns + `
+			FROM income i
+			INNER JOIN person per
+				ON i.id_person = per.id
+			WHERE i.id_household = $1
+			ORDER BY i."date"`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find all incomes: %w", err)
+	}
+
+	return scanIncomes(rows)
+}
+
+func scanIncomes(rows *sql.Rows) ([]models.Income, error) {
+	var incomes []models.Income
+
+	for rows.Next() {
+		var (
+			i       models.Income
+			endDate sql.NullString
+		)
+
+		if err := rows.Scan(
+			&i.ID,
+			&i.Description,
+			&i.Source,
+			&i.Category,
+			&i.Amount,
+			&i.Date,
+			&i.Recurrence,
+			&endDate,
+			&i.IDPerson,
+			&i.Person,
+			&i.Version,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan income: %w", err)
+		}
+
+		i.EndDate = endDate.String
+		incomes = append(incomes, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return incomes, nil
+}
@@ -1,20 +1,31 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 )
 
 type InstallmentRepository interface {
-	Create(tx *sql.Tx, installment models.Installment) error
-	Update(id uuid.UUID) error
-	Delete(id uuid.UUID) error
-	FindByPurchaseID(id uuid.UUID) ([]models.Installment, error)
-	FindByMonth(month string) ([]models.Installment, error)
-	FindByNotPaid() ([]models.Installment, error)
+	Create(ctx context.Context, tx *sql.Tx, installment models.Installment) error
+	CreateMany(ctx context.Context, tx *sql.Tx, installments []models.Installment) error
+	Update(ctx context.Context, tx *sql.Tx, id uuid.UUID) (models.Installment, uuid.UUID, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByPurchaseID(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error
+	HasPaidByPurchaseID(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) (bool, error)
+	Trash(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error
+	Restore(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error
+	FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Installment, error)
+	FindByMonth(ctx context.Context, month string) ([]models.Installment, error)
+	FindByMonthAndPerson(ctx context.Context, month string, personID uuid.UUID) ([]models.Installment, error)
+	FindByNotPaid(ctx context.Context) ([]models.Installment, error)
+	FindUnpaidByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) ([]models.Installment, error)
+	UpdateValue(ctx context.Context, tx *sql.Tx, id uuid.UUID, value float64) error
+	DeleteByID(ctx context.Context, tx *sql.Tx, id uuid.UUID) error
 }
 
 type installmentRepository struct {
@@ -25,15 +36,21 @@ func NewInstallmentRepository(db *sql.DB) *installmentRepository {
 	return &installmentRepository{db}
 }
 
-func (r *installmentRepository) Create(tx *sql.Tx, installment models.Installment) error {
-	sql := `INSERT INTO installment (id, description, number, value, month, paid, purchase_id) 
+func (r *installmentRepository) Create(ctx context.Context, tx *sql.Tx, installment models.Installment) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	sql := `INSERT INTO installment (id, id_household, description, number, value, month, paid, purchase_id) 
 			VALUES 
-			($1, $2, $3, $4, $5, $6, $7)`
+			($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	installment.ID = uuid.New()
 
-	_, err := tx.Exec(sql,
+	_, err = tx.Exec(sql,
 		installment.ID,
+		householdID,
 		installment.Description,
 		installment.Number,
 		installment.Value,
@@ -42,57 +59,220 @@ func (r *installmentRepository) Create(tx *sql.Tx, installment models.Installmen
 		installment.PurchaseID,
 	)
 	if err != nil {
-		return fmt.Errorf("error executing statement: %v", err)
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
 	return nil
 }
 
-func (r *installmentRepository) Update(id uuid.UUID) error {
-	sql := `UPDATE installment SET paid = true WHERE id = $1`
+// CreateMany inserts every installment of a purchase with a single statement.
+func (r *installmentRepository) CreateMany(ctx context.Context, tx *sql.Tx, installments []models.Installment) error {
+	if len(installments) == 0 {
+		return nil
+	}
+
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	const columns = 8
+
+	var (
+		rows = make([]string, len(installments))
+		args = make([]any, 0, len(installments)*columns)
+	)
+
+	for i, installment := range installments {
+		n := i * columns
+		rows[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+
+		args = append(args,
+			uuid.New(),
+			householdID,
+			installment.Description,
+			installment.Number,
+			installment.Value,
+			installment.Month,
+			installment.Paid,
+			installment.PurchaseID,
+		)
+	}
+
+	query := `INSERT INTO installment (id, id_household, description, number, value, month, paid, purchase_id) VALUES ` +
+		strings.Join(rows, ", ")
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+// Update marks the installment as paid and returns it together with the
+// credit card it was billed on.
+func (r *installmentRepository) Update(ctx context.Context, tx *sql.Tx, id uuid.UUID) (models.Installment, uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Installment{}, uuid.Nil, err
+	}
+
+	query := `UPDATE installment i 
+			SET paid = true 
+			FROM purchase p
+			WHERE i.id = $1
+				AND i.id_household = $2
+				AND i.paid = false
+				AND i.deleted_at IS NULL
+				AND p.id = i.purchase_id
+			RETURNING i.id, i.description, i.number, i.value, i.month, i.paid, i.purchase_id, p.id_credit_card`
+
+	var (
+		installment  models.Installment
+		creditCardID uuid.UUID
+	)
+
+	err = tx.QueryRow(query, id, householdID).Scan(
+		&installment.ID,
+		&installment.Description,
+		&installment.Number,
+		&installment.Value,
+		&installment.Month,
+		&installment.Paid,
+		&installment.PurchaseID,
+		&creditCardID,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return models.Installment{}, uuid.Nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return models.Installment{}, uuid.Nil, models.NotFound("unpaid installment")
+	}
+
+	return installment, creditCardID, nil
+}
+
+func (r *installmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	sql := `DELETE FROM installment WHERE purchase_id = $1 AND id_household = $2`
 
 	stmt, err := r.db.Prepare(sql)
 	if err != nil {
-		return fmt.Errorf("error preparing statement: %v", err)
+		return fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	_, err = stmt.Exec(id)
+	_, err = stmt.Exec(id, householdID)
 	if err != nil {
-		return fmt.Errorf("error executing statement: %v", err)
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
 	return nil
 }
 
-func (r *installmentRepository) Delete(id uuid.UUID) error {
-	sql := `DELETE FROM installment WHERE purchase_id = $1`
+// DeleteByPurchaseID deletes the installments of a purchase in the transaction
+// that replaces them.
+func (r *installmentRepository) DeleteByPurchaseID(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := r.db.Prepare(sql)
+	if _, err := tx.Exec(`DELETE FROM installment WHERE purchase_id = $1 AND id_household = $2`, purchaseID, householdID); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+// HasPaidByPurchaseID tells whether any installment of the purchase was
+// already paid on an invoice.
+func (r *installmentRepository) HasPaidByPurchaseID(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) (bool, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	query := `SELECT EXISTS (
+				SELECT 1 FROM installment
+				WHERE purchase_id = $1
+					AND id_household = $2
+					AND paid = true
+					AND deleted_at IS NULL
+			)`
+
+	var paid bool
+	if err := tx.QueryRow(query, purchaseID, householdID).Scan(&paid); err != nil {
+		return false, fmt.Errorf("error trying find paid installments: %w", err)
+	}
+
+	return paid, nil
+}
+
+// Trash soft deletes the installments of a purchase in the same transaction
+// that trashes the purchase, so both share the deletion time.
+func (r *installmentRepository) Trash(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
 	if err != nil {
-		return fmt.Errorf("error preparing statement: %v", err)
+		return err
 	}
 
-	_, err = stmt.Exec(id)
+	query := `UPDATE installment SET deleted_at = now() WHERE purchase_id = $1 AND id_household = $2 AND deleted_at IS NULL`
+
+	if _, err := tx.Exec(query, purchaseID, householdID); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+// Restore brings back the installments trashed together with the purchase. It
+// must run before the purchase itself is restored.
+func (r *installmentRepository) Restore(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
 	if err != nil {
-		return fmt.Errorf("error executing statement: %v", err)
+		return err
+	}
+
+	query := `UPDATE installment i 
+			SET deleted_at = NULL 
+			FROM purchase p
+			WHERE i.purchase_id = $1
+				AND i.id_household = $2
+				AND p.id = i.purchase_id
+				AND i.deleted_at = p.deleted_at`
+
+	if _, err := tx.Exec(query, purchaseID, householdID); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
 	return nil
 }
 
-func (r *installmentRepository) FindByPurchaseID(id uuid.UUID) ([]models.Installment, error) {
+func (r *installmentRepository) FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Installment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	sql := `SELECT id, description, number, value, month, paid, purchase_id
 			 FROM installment 
-			 WHERE purchase_id = $1`
+			 WHERE purchase_id = $1
+			 	AND id_household = $2
+			 	AND deleted_at IS NULL`
 
 	stmt, err := r.db.Prepare(sql)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %v", err)
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	rows, err := stmt.Query(id)
+	rows, err := stmt.Query(id, householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error executing statement: %v", err)
+		return nil, fmt.Errorf("error executing statement: %w", err)
 	}
 
 	var installments []models.Installment
@@ -108,7 +288,7 @@ func (r *installmentRepository) FindByPurchaseID(id uuid.UUID) ([]models.Install
 			&installment.PurchaseID,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning rows: %v", err)
+			return nil, fmt.Errorf("error scanning rows: %w", err)
 		}
 
 		installments = append(installments, installment)
@@ -117,19 +297,91 @@ func (r *installmentRepository) FindByPurchaseID(id uuid.UUID) ([]models.Install
 	return installments, nil
 }
 
-func (r *installmentRepository) FindByMonth(month string) ([]models.Installment, error) {
+func (r *installmentRepository) FindByMonth(ctx context.Context, month string) ([]models.Installment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	sql := `SELECT id, description, number, value, month, paid, purchase_id
 			 FROM installment 
-			 WHERE to_char(month, 'YYYY-MM') = $1`
+			 WHERE to_char(month, 'YYYY-MM') = $1
+			 	AND id_household = $2
+			 	AND deleted_at IS NULL`
+
+	stmt, err := r.db.Prepare(sql)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.Query(month, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	var installments []models.Installment
+	for rows.Next() {
+		var installment models.Installment
+		err = rows.Scan(
+			&installment.ID,
+			&installment.Description,
+			&installment.Number,
+			&installment.Value,
+			&installment.Month,
+			&installment.Paid,
+			&installment.PurchaseID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+
+		installments = append(installments, installment)
+	}
+
+	return installments, nil
+}
+
+// FindByMonthAndPerson returns the installments due in the month for a person,
+// with each value reduced to the person's share of the purchase.
+func (r *installmentRepository) FindByMonthAndPerson(ctx context.Context, month string, personID uuid.UUID) ([]models.Installment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := `SELECT 
+				i.id, 
+				i.description, 
+				i.number, 
+				ROUND((i.value * COALESCE(ps.amount, p.amount) / p.amount)::numeric, 2), 
+				i.month, 
+				i.paid, 
+				i.purchase_id
+			FROM installment i
+			INNER JOIN purchase p
+				ON p.id = i.purchase_id
+			LEFT JOIN purchase_share ps
+				ON ps.id_purchase = p.id
+				AND ps.id_person = $2
+			WHERE to_char(i.month, 'YYYY-MM') = $1
+				AND i.id_household = $3
+				AND i.deleted_at IS NULL
+				AND (
+					ps.id_person IS NOT NULL
+					OR (
+						p.id_person = $2 
+						AND NOT EXISTS (SELECT 1 FROM purchase_share s WHERE s.id_purchase = p.id)
+					)
+				)`
 
 	stmt, err := r.db.Prepare(sql)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %v", err)
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	rows, err := stmt.Query(month)
+	rows, err := stmt.Query(month, personID, householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error executing statement: %v", err)
+		return nil, fmt.Errorf("error executing statement: %w", err)
 	}
 
 	var installments []models.Installment
@@ -145,7 +397,7 @@ func (r *installmentRepository) FindByMonth(month string) ([]models.Installment,
 			&installment.PurchaseID,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning rows: %v", err)
+			return nil, fmt.Errorf("error scanning rows: %w", err)
 		}
 
 		installments = append(installments, installment)
@@ -154,19 +406,26 @@ func (r *installmentRepository) FindByMonth(month string) ([]models.Installment,
 	return installments, nil
 }
 
-func (r *installmentRepository) FindByNotPaid() ([]models.Installment, error) {
+func (r *installmentRepository) FindByNotPaid(ctx context.Context) ([]models.Installment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	sql := `SELECT id, description, number, value, month, paid, purchase_id 
 			FROM installment 
-			WHERE paid = false`
+			WHERE paid = false
+				AND id_household = $1
+				AND deleted_at IS NULL`
 
 	stmt, err := r.db.Prepare(sql)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %v", err)
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	rows, err := stmt.Query()
+	rows, err := stmt.Query(householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error executing statement: %v", err)
+		return nil, fmt.Errorf("error executing statement: %w", err)
 	}
 
 	var installments []models.Installment
@@ -182,7 +441,7 @@ func (r *installmentRepository) FindByNotPaid() ([]models.Installment, error) {
 			&installment.PurchaseID,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning rows: %v", err)
+			return nil, fmt.Errorf("error scanning rows: %w", err)
 		}
 
 		installments = append(installments, installment)
@@ -190,3 +449,78 @@ func (r *installmentRepository) FindByNotPaid() ([]models.Installment, error) {
 
 	return installments, nil
 }
+
+// FindUnpaidByPurchaseID returns the unpaid installments of a purchase, latest
+// first, locking them for the rest of the transaction.
+func (r *installmentRepository) FindUnpaidByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) ([]models.Installment, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, description, number, value, month, paid, purchase_id
+			FROM installment 
+			WHERE purchase_id = $1 
+				AND id_household = $2
+				AND paid = false
+				AND value > 0
+				AND deleted_at IS NULL
+			ORDER BY month DESC
+			FOR UPDATE`
+
+	rows, err := tx.Query(query, id, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	var installments []models.Installment
+	for rows.Next() {
+		var installment models.Installment
+		err = rows.Scan(
+			&installment.ID,
+			&installment.Description,
+			&installment.Number,
+			&installment.Value,
+			&installment.Month,
+			&installment.Paid,
+			&installment.PurchaseID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+
+		installments = append(installments, installment)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return installments, nil
+}
+
+func (r *installmentRepository) UpdateValue(ctx context.Context, tx *sql.Tx, id uuid.UUID, value float64) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE installment SET value = $1 WHERE id = $2 AND id_household = $3`, value, id, householdID); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (r *installmentRepository) DeleteByID(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM installment WHERE id = $1 AND id_household = $2`, id, householdID); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type LedgerRepository interface {
+	BeginTransaction() (*sql.Tx, error)
+	Commit(tx *sql.Tx) error
+	Rollback(tx *sql.Tx) error
+	CreateEntry(ctx context.Context, tx *sql.Tx, e models.JournalEntry) error
+	FindNetBySource(ctx context.Context, tx *sql.Tx, sourceType string, sourceID uuid.UUID) ([]models.Posting, error)
+	FindAccountBalance(ctx context.Context, tx *sql.Tx, account string) (float64, error)
+	TrialBalance(ctx context.Context) ([]models.TrialBalanceLine, error)
+	FindPurchaseDrifts(ctx context.Context) ([]models.LedgerDrift, error)
+	FindCreditCardDrifts(ctx context.Context) ([]models.LedgerDrift, error)
+	FindUnbalancedEntries(ctx context.Context) ([]models.LedgerDrift, error)
+}
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *ledgerRepository {
+	return &ledgerRepository{db}
+}
+
+func (r *ledgerRepository) BeginTransaction() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *ledgerRepository) Commit(tx *sql.Tx) error {
+	return tx.Commit()
+}
+
+func (r *ledgerRepository) Rollback(tx *sql.Tx) error {
+	return tx.Rollback()
+}
+
+func (r *ledgerRepository) CreateEntry(ctx context.Context, tx *sql.Tx, e models.JournalEntry) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO journal_entry (id, id_household, "date", description, source_type, source_id) 
+			VALUES ($1, $2, $3, $4, $5, $6)`
+
+	entryID := uuid.New()
+
+	if _, err := tx.Exec(query, entryID, householdID, e.Date, e.Description, e.SourceType, e.SourceID); err != nil {
+		return fmt.Errorf("error trying insert journal entry: %w", err)
+	}
+
+	query = `INSERT INTO posting (id, id_entry, account, amount) VALUES ($1, $2, $3, $4)`
+
+	for _, posting := range e.Postings {
+		if _, err := tx.Exec(query, uuid.New(), entryID, posting.Account, posting.Amount); err != nil {
+			return fmt.Errorf("error trying insert posting: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FindNetBySource returns the net amount posted to each account by all the
+// entries of a source, which is what a reversal has to cancel.
+func (r *ledgerRepository) FindNetBySource(ctx context.Context, tx *sql.Tx, sourceType string, sourceID uuid.UUID) ([]models.Posting, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT po.account, SUM(po.amount)
+			FROM posting po
+			INNER JOIN journal_entry je
+				ON po.id_entry = je.id
+			WHERE je.source_type = $1
+				AND je.source_id = $2
+				AND je.id_household = $3
+			GROUP BY po.account
+			HAVING SUM(po.amount) <> 0
+			ORDER BY po.account`
+
+	rows, err := tx.Query(query, sourceType, sourceID, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find postings by source: %w", err)
+	}
+
+	var postings []models.Posting
+
+	for rows.Next() {
+		var p models.Posting
+		if err := rows.Scan(&p.Account, &p.Amount); err != nil {
+			return nil, fmt.Errorf("error trying scan posting: %w", err)
+		}
+
+		postings = append(postings, p)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return postings, nil
+}
+
+func (r *ledgerRepository) FindAccountBalance(ctx context.Context, tx *sql.Tx, account string) (float64, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT COALESCE(SUM(po.amount), 0) 
+			FROM posting po
+			INNER JOIN journal_entry je
+				ON po.id_entry = je.id
+			WHERE po.account = $1
+				AND je.id_household = $2`
+
+	var balance float64
+	if err := tx.QueryRow(query, account, householdID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("error trying find account balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (r *ledgerRepository) TrialBalance(ctx context.Context) ([]models.TrialBalanceLine, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				po.account,
+				SUM(CASE WHEN po.amount > 0 THEN po.amount ELSE 0 END),
+				SUM(CASE WHEN po.amount < 0 THEN -po.amount ELSE 0 END),
+				SUM(po.amount)
+			FROM posting po
+			INNER JOIN journal_entry je
+				ON po.id_entry = je.id
+			WHERE je.id_household = $1
+			GROUP BY po.account
+			ORDER BY po.account`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find trial balance: %w", err)
+	}
+
+	var lines []models.TrialBalanceLine
+
+	for rows.Next() {
+		var l models.TrialBalanceLine
+		if err := rows.Scan(&l.Account, &l.Debit, &l.Credit, &l.Balance); err != nil {
+			return nil, fmt.Errorf("error trying scan trial balance: %w", err)
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return lines, nil
+}
+
+// FindPurchaseDrifts compares the amount of every purchase with what the
+// ledger has expensed for it, and flags expenses left for deleted purchases.
+func (r *ledgerRepository) FindPurchaseDrifts(ctx context.Context) ([]models.LedgerDrift, error) {
+	query := `SELECT 'purchase_amount', p.id, p.amount, COALESCE(SUM(po.amount), 0)
+			FROM purchase p
+			LEFT JOIN journal_entry je
+				ON je.source_type = 'purchase'
+				AND je.source_id = p.id
+			LEFT JOIN posting po
+				ON po.id_entry = je.id
+				AND po.account LIKE 'expenses:%'
+			WHERE p.deleted_at IS NULL
+				AND p.id_household = $1
+			GROUP BY p.id, p.amount
+			HAVING ABS(p.amount - COALESCE(SUM(po.amount), 0)) >= 0.01
+			UNION ALL
+			SELECT 'purchase_missing', je.source_id, 0, SUM(po.amount)
+			FROM journal_entry je
+			INNER JOIN posting po
+				ON po.id_entry = je.id
+				AND po.account LIKE 'expenses:%'
+			WHERE je.source_type = 'purchase'
+				AND je.id_household = $1
+				AND NOT EXISTS (SELECT 1 FROM purchase p WHERE p.id = je.source_id AND p.deleted_at IS NULL)
+			GROUP BY je.source_id
+			HAVING ABS(SUM(po.amount)) >= 0.01`
+
+	return r.findDrifts(ctx, query)
+}
+
+// FindCreditCardDrifts compares the liability of every credit card in the
+// ledger with its unpaid installments, allowing a cent of rounding for each
+// installment.
+func (r *ledgerRepository) FindCreditCardDrifts(ctx context.Context) ([]models.LedgerDrift, error) {
+	query := `SELECT 'credit_card_liability', cc.id, -COALESCE(i.unpaid, 0), COALESCE(l.balance, 0)
+			FROM credit_card cc
+			LEFT JOIN (
+				SELECT p.id_credit_card, SUM(i.value) AS unpaid, COUNT(i.id) AS quantity
+				FROM installment i
+				INNER JOIN purchase p
+					ON p.id = i.purchase_id
+				WHERE i.paid = false
+					AND i.deleted_at IS NULL
+				GROUP BY p.id_credit_card
+			) i
+				ON i.id_credit_card = cc.id
+			LEFT JOIN (
+				SELECT po.account, SUM(po.amount) AS balance
+				FROM posting po
+				INNER JOIN journal_entry je
+					ON po.id_entry = je.id
+				WHERE po.account LIKE 'liabilities:credit_card:%'
+					AND je.id_household = $1
+				GROUP BY po.account
+			) l
+				ON l.account = 'liabilities:credit_card:' || cc.id
+			WHERE cc.id_household = $1
+				AND ABS(COALESCE(l.balance, 0) + COALESCE(i.unpaid, 0)) > 0.01 * (COALESCE(i.quantity, 0) + 1)`
+
+	return r.findDrifts(ctx, query)
+}
+
+func (r *ledgerRepository) FindUnbalancedEntries(ctx context.Context) ([]models.LedgerDrift, error) {
+	query := `SELECT 'entry_unbalanced', je.id, 0, COALESCE(SUM(po.amount), 0)
+			FROM journal_entry je
+			LEFT JOIN posting po
+				ON po.id_entry = je.id
+			WHERE je.id_household = $1
+			GROUP BY je.id
+			HAVING COALESCE(SUM(po.amount), 0) <> 0 OR COUNT(po.id) < 2`
+
+	return r.findDrifts(ctx, query)
+}
+
+func (r *ledgerRepository) findDrifts(ctx context.Context, query string) ([]models.LedgerDrift, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find ledger drifts: %w", err)
+	}
+
+	var drifts []models.LedgerDrift
+
+	for rows.Next() {
+		var d models.LedgerDrift
+		if err := rows.Scan(&d.Kind, &d.SourceID, &d.Expected, &d.Actual); err != nil {
+			return nil, fmt.Errorf("error trying scan ledger drift: %w", err)
+		}
+
+		drifts = append(drifts, d)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return drifts, nil
+}
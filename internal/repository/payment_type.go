@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -9,11 +10,11 @@ import (
 )
 
 type PaymentTypeRepository interface {
-	Create(p models.PaymentType) error
-	Update(pt models.PaymentType) error
-	Delete(id uuid.UUID) error
-	FindByID(id uuid.UUID) (models.PaymentType, error)
-	FindAll() ([]models.PaymentType, error)
+	Create(ctx context.Context, tx *sql.Tx, p models.PaymentType) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, pt models.PaymentType) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.PaymentType, error)
+	FindAll(ctx context.Context) ([]models.PaymentType, error)
 }
 
 type paymentTypeRepository struct {
@@ -24,113 +25,139 @@ func NewRepositoryPaymentType(db *sql.DB) *paymentTypeRepository {
 	return &paymentTypeRepository{db}
 }
 
-func (r paymentTypeRepository) Create(p models.PaymentType) error {
-	query := `INSERT INTO payment_type (id, name) VALUES ($1, $2)`
-	stmt, err := r.db.Prepare(query)
+func (r paymentTypeRepository) Create(ctx context.Context, tx *sql.Tx, p models.PaymentType) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO payment_type (id, id_household, name) VALUES ($1, $2, $3)`
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	id, err := uuid.NewUUID()
+	id, err := newID(p.ID)
 	if err != nil {
-		return fmt.Errorf("error trying create uuid: %v", err)
+		return uuid.Nil, err
 	}
 
-	if _, err = stmt.Exec(id, p.Name); err != nil {
-		return fmt.Errorf("error trying insert payment type: %v", err)
+	if _, err = stmt.Exec(id, householdID, p.Name); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert payment type: %w", err), "payment_type", "payment type", id)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close stmt: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying close stmt: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
-func (r paymentTypeRepository) Update(pt models.PaymentType) error {
-	query := `UPDATE payment_type SET name = $1 WHERE id = $2`
-	stmt, err := r.db.Prepare(query)
+func (r paymentTypeRepository) Update(ctx context.Context, tx *sql.Tx, pt models.PaymentType) error {
+	householdID, err := models.HouseholdFrom(ctx)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return err
 	}
 
-	if _, err = stmt.Exec(pt.Name, pt.ID); err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying update payment type: %v", err)
+	query := `UPDATE payment_type SET name = $1, version = version + 1 WHERE id = $2 AND version = $3 AND id_household = $4 AND deleted_at IS NULL`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist payment type with this id")
+	result, err := stmt.Exec(pt.Name, pt.ID, pt.Version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying update payment type: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "payment_type", "payment type", pt.ID); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close stmt: %v", err)
+		return fmt.Errorf("error trying close stmt: %w", err)
 	}
 
 	return nil
 }
 
-func (r paymentTypeRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM payment_type WHERE id = $1`
+func (r paymentTypeRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := r.db.Prepare(query)
+	query := `UPDATE payment_type SET deleted_at = now(), version = version + 1 WHERE id = $1 AND version = $2 AND id_household = $3 AND deleted_at IS NULL`
+
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	_, err = stmt.Exec(id)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying delete payment type: %v", err)
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete payment type: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist payment type with this id")
+	if err := checkVersion(ctx, tx, result, "payment_type", "payment type", id); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close stmt: %v", err)
+		return fmt.Errorf("error trying close stmt: %w", err)
 	}
 
 	return nil
 }
 
-func (r paymentTypeRepository) FindByID(id uuid.UUID) (models.PaymentType, error) {
-	query := "SELECT id, name FROM payment_type WHERE id = $1"
+func (r paymentTypeRepository) FindByID(ctx context.Context, id uuid.UUID) (models.PaymentType, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.PaymentType{}, err
+	}
+
+	query := "SELECT id, name, version FROM payment_type WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL"
 	
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return models.PaymentType{}, fmt.Errorf("error trying prepare statment: %v", err)
+		return models.PaymentType{}, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
 	var pt models.PaymentType
-	if err = stmt.QueryRow(id).Scan(&pt.ID, &pt.Name); err != nil && err != sql.ErrNoRows {
-		return models.PaymentType{}, fmt.Errorf("error trying find payment type: %v", err)
+	if err = stmt.QueryRow(id, householdID).Scan(&pt.ID, &pt.Name, &pt.Version); err != nil && err != sql.ErrNoRows {
+		return models.PaymentType{}, fmt.Errorf("error trying find payment type: %w", err)
 	}
 
 	if err != nil && err == sql.ErrNoRows {
-		return models.PaymentType{}, fmt.Errorf("does not exist payment type with this id")
+		return models.PaymentType{}, models.NotFound("payment type")
 	}
 
 	if err := stmt.Close(); err != nil {
-		return models.PaymentType{}, fmt.Errorf("error trying close stmt: %v", err)
+		return models.PaymentType{}, fmt.Errorf("error trying close stmt: %w", err)
 	}
 
 	return pt, nil
 }
 
-func (r paymentTypeRepository) FindAll() ([]models.PaymentType, error) {
-	query := "SELECT id, name FROM payment_type ORDER BY name"
+func (r paymentTypeRepository) FindAll(ctx context.Context) ([]models.PaymentType, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, name, version FROM payment_type WHERE id_household = $1 AND deleted_at IS NULL ORDER BY name"
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, householdID)
 	if err != nil {
-		return []models.PaymentType{}, fmt.Errorf("error trying find all payment type: %v", err)
+		return []models.PaymentType{}, fmt.Errorf("error trying find all payment type: %w", err)
 	}
 
 	var payments []models.PaymentType
 
 	for rows.Next() {
 		var pt models.PaymentType
-		if err = rows.Scan(&pt.ID, &pt.Name); err != nil && err != sql.ErrNoRows {
-			return []models.PaymentType{}, fmt.Errorf("error trying scan payment type: %v", err)
+		if err = rows.Scan(&pt.ID, &pt.Name, &pt.Version); err != nil && err != sql.ErrNoRows {
+			return []models.PaymentType{}, fmt.Errorf("error trying scan payment type: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -141,7 +168,7 @@ func (r paymentTypeRepository) FindAll() ([]models.PaymentType, error) {
 	}
 
 	if err := rows.Close(); err != nil {
-		return []models.PaymentType{}, fmt.Errorf("error trying close rows: %v", err)
+		return []models.PaymentType{}, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	return payments, nil
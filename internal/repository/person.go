@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -9,11 +10,11 @@ import (
 )
 
 type PersonRepository interface {
-	Create(p models.Person) error
-	Update(p models.Person) error
-	Delete(id uuid.UUID) error
-	FindByID(id uuid.UUID) (models.Person, error)
-	FindAll() ([]models.Person, error)
+	Create(ctx context.Context, tx *sql.Tx, p models.Person) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, p models.Person) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Person, error)
+	FindAll(ctx context.Context) ([]models.Person, error)
 }
 
 type personRepository struct {
@@ -24,113 +25,139 @@ func NewRepositoryPerson(db *sql.DB) *personRepository {
 	return &personRepository{db}
 }
 
-func (r personRepository) Create(p models.Person) error {
-	query := `INSERT INTO person (id, name) VALUES ($1, $2)`
-	stmt, err := r.db.Prepare(query)
+func (r personRepository) Create(ctx context.Context, tx *sql.Tx, p models.Person) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO person (id, id_household, name) VALUES ($1, $2, $3)`
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	id, err := uuid.NewUUID()
+	id, err := newID(p.ID)
 	if err != nil {
-		return fmt.Errorf("error trying create uuid: %v", err)
+		return uuid.Nil, err
 	}
 
-	if _, err = stmt.Exec(id, p.Name); err != nil {
-		return fmt.Errorf("error trying insert person: %v", err)
+	if _, err = stmt.Exec(id, householdID, p.Name); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert person: %w", err), "person", "person", id)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close stmt: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying close stmt: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
-func (r personRepository) Update(p models.Person) error {
-	query := `UPDATE person SET name = $1 WHERE id = $2`
-	stmt, err := r.db.Prepare(query)
+func (r personRepository) Update(ctx context.Context, tx *sql.Tx, p models.Person) error {
+	householdID, err := models.HouseholdFrom(ctx)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return err
 	}
 
-	if _, err = stmt.Exec(p.Name, p.ID); err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying update person: %v", err)
+	query := `UPDATE person SET name = $1, version = version + 1 WHERE id = $2 AND version = $3 AND id_household = $4 AND deleted_at IS NULL`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist person with this id")
+	result, err := stmt.Exec(p.Name, p.ID, p.Version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying update person: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "person", "person", p.ID); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close stmt: %v", err)
+		return fmt.Errorf("error trying close stmt: %w", err)
 	}
 
 	return nil
 }
 
-func (r personRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM person WHERE id = $1`
+func (r personRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := r.db.Prepare(query)
+	query := `UPDATE person SET deleted_at = now(), version = version + 1 WHERE id = $1 AND version = $2 AND id_household = $3 AND deleted_at IS NULL`
+
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	_, err = stmt.Exec(id)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying delete person: %v", err)
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete person: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist person with this id")
+	if err := checkVersion(ctx, tx, result, "person", "person", id); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close stmt: %v", err)
+		return fmt.Errorf("error trying close stmt: %w", err)
 	}
 
 	return nil
 }
 
-func (r personRepository) FindByID(id uuid.UUID) (models.Person, error) {
-	query := "SELECT id, name FROM person WHERE id = $1"
+func (r personRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Person, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Person{}, err
+	}
+
+	query := "SELECT id, name, version FROM person WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL"
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return models.Person{}, fmt.Errorf("error trying prepare statment: %v", err)
+		return models.Person{}, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
 	var p models.Person
-	if err = stmt.QueryRow(id).Scan(&p.ID, &p.Name); err != nil && err != sql.ErrNoRows {
-		return models.Person{}, fmt.Errorf("error trying find person: %v", err)
+	if err = stmt.QueryRow(id, householdID).Scan(&p.ID, &p.Name, &p.Version); err != nil && err != sql.ErrNoRows {
+		return models.Person{}, fmt.Errorf("error trying find person: %w", err)
 	}
 
 	if err != nil && err == sql.ErrNoRows {
-		return models.Person{}, fmt.Errorf("does not exist person with this id")
+		return models.Person{}, models.NotFound("person")
 	}
 
 	if err := stmt.Close(); err != nil {
-		return models.Person{}, fmt.Errorf("error trying close stmt: %v", err)
+		return models.Person{}, fmt.Errorf("error trying close stmt: %w", err)
 	}
 
 	return p, nil
 }
 
-func (r personRepository) FindAll() ([]models.Person, error) {
-	query := "SELECT id, name FROM person ORDER BY name"
+func (r personRepository) FindAll(ctx context.Context) ([]models.Person, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, name, version FROM person WHERE id_household = $1 AND deleted_at IS NULL ORDER BY name"
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, householdID)
 	if err != nil {
-		return []models.Person{}, fmt.Errorf("error trying find all persons: %v", err)
+		return []models.Person{}, fmt.Errorf("error trying find all persons: %w", err)
 	}
 
 	var persons []models.Person
 
 	for rows.Next() {
 		var p models.Person
-		if err = rows.Scan(&p.ID, &p.Name); err != nil && err != sql.ErrNoRows {
-			return []models.Person{}, fmt.Errorf("error trying scan person: %v", err)
+		if err = rows.Scan(&p.ID, &p.Name, &p.Version); err != nil && err != sql.ErrNoRows {
+			return []models.Person{}, fmt.Errorf("error trying scan person: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -141,7 +168,7 @@ func (r personRepository) FindAll() ([]models.Person, error) {
 	}
 
 	if err := rows.Close(); err != nil {
-		return []models.Person{}, fmt.Errorf("error trying close rows: %v", err)
+		return []models.Person{}, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	return persons, nil
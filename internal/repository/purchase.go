@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/me/finance/internal/models"
 )
 
@@ -12,14 +14,20 @@ type PurchaseRepository interface {
 	BeginTransaction() (*sql.Tx, error)
 	Commit(tx *sql.Tx) error
 	Rollback(tx *sql.Tx) error
-	Create(tx *sql.Tx, p models.Purchase) (uuid.UUID, error)
-	Update(tx *sql.Tx, p models.Purchase) error
-	Delete(tx *sql.Tx, id uuid.UUID) error
-	FindByID(id uuid.UUID) (models.PurchaseResponse, error)
-	FindByDate(date string) ([]models.PurchaseResponse, error)
-	FindByMonth(date string) ([]models.PurchaseResponse, error)
-	FindByPerson(id uuid.UUID) ([]models.PurchaseResponse, error)
-	FindAll() ([]models.PurchaseResponse, error)
+	Savepoint(tx *sql.Tx) error
+	ReleaseSavepoint(tx *sql.Tx) error
+	RollbackToSavepoint(tx *sql.Tx) error
+	Create(ctx context.Context, tx *sql.Tx, p models.Purchase) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, p models.Purchase) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	Restore(ctx context.Context, tx *sql.Tx, id uuid.UUID) (models.Purchase, error)
+	FindByID(ctx context.Context, id uuid.UUID) (models.PurchaseResponse, error)
+	FindEntity(ctx context.Context, id uuid.UUID) (models.Purchase, error)
+	FindByDate(ctx context.Context, date string) ([]models.PurchaseResponse, error)
+	FindByMonth(ctx context.Context, date string) ([]models.PurchaseResponse, error)
+	FindByPerson(ctx context.Context, id uuid.UUID) ([]models.PurchaseResponse, error)
+	FindByTags(ctx context.Context, tagIDs []uuid.UUID, matchAll bool) ([]models.PurchaseResponse, error)
+	FindAll(ctx context.Context) ([]models.PurchaseResponse, error)
 }
 
 type repositoryPurchase struct {
@@ -42,9 +50,41 @@ func (r repositoryPurchase) Rollback(tx *sql.Tx) error {
 	return tx.Rollback()
 }
 
-func (r repositoryPurchase) Create(tx *sql.Tx, p models.Purchase) (uuid.UUID, error) {
+// Savepoint marks where RollbackToSavepoint goes back to, undoing a part of
+// the transaction without losing the rest of it.
+func (r repositoryPurchase) Savepoint(tx *sql.Tx) error {
+	if _, err := tx.Exec(`SAVEPOINT batch_item`); err != nil {
+		return fmt.Errorf("error trying create savepoint: %w", err)
+	}
+
+	return nil
+}
+
+func (r repositoryPurchase) ReleaseSavepoint(tx *sql.Tx) error {
+	if _, err := tx.Exec(`RELEASE SAVEPOINT batch_item`); err != nil {
+		return fmt.Errorf("error trying release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+func (r repositoryPurchase) RollbackToSavepoint(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT batch_item`); err != nil {
+		return fmt.Errorf("error trying rollback to savepoint: %w", err)
+	}
+
+	return nil
+}
+
+func (r repositoryPurchase) Create(ctx context.Context, tx *sql.Tx, p models.Purchase) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
 	query := `INSERT INTO purchase(
 		id,
+		id_household,
 		description, 
 		amount, 
 		"date", 
@@ -53,21 +93,23 @@ func (r repositoryPurchase) Create(tx *sql.Tx, p models.Purchase) (uuid.UUID, er
 		id_payment_type, 
 		id_purchase_type, 
 		id_credit_card, 
-		id_person
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
+		id_person,
+		id_account
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);`
 
 	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error trying prepare statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	id, err := uuid.NewUUID()
+	id, err := newID(p.ID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error trying create uuid: %v", err)
+		return uuid.Nil, err
 	}
 
 	if _, err = stmt.Exec(
 		id,
+		householdID,
 		p.Description,
 		p.Amount,
 		p.Date,
@@ -75,20 +117,26 @@ func (r repositoryPurchase) Create(tx *sql.Tx, p models.Purchase) (uuid.UUID, er
 		p.Paid,
 		p.IDPaymentType,
 		p.IDPurchaseType,
-		p.IDCreditCard,
+		uuid.NullUUID{UUID: p.IDCreditCard, Valid: p.IDCreditCard != uuid.Nil},
 		p.IDPerson,
+		p.IDAccount,
 	); err != nil {
-		return uuid.Nil, fmt.Errorf("error trying insert purchase type: %v", err)
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert purchase type: %w", err), "purchase", "purchase", id)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return uuid.Nil, fmt.Errorf("error trying close statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return id, nil
 }
 
-func (r repositoryPurchase) Update(tx *sql.Tx, p models.Purchase) error {
+func (r repositoryPurchase) Update(ctx context.Context, tx *sql.Tx, p models.Purchase) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE purchase
 		SET description = $1, 
 			amount = $2, 
@@ -98,15 +146,20 @@ func (r repositoryPurchase) Update(tx *sql.Tx, p models.Purchase) error {
 			id_payment_type = $6, 
 			id_purchase_type = $7, 
 			id_credit_card = $8, 
-			id_person = $9
-		WHERE id = $10;`
+			id_person = $9,
+			id_account = $10,
+			version = version + 1
+		WHERE id = $11
+			AND version = $12
+			AND id_household = $13
+			AND deleted_at IS NULL;`
 
 	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	if _, err = stmt.Exec(
+	result, err := stmt.Exec(
 		p.Description,
 		p.Amount,
 		p.Date,
@@ -114,49 +167,133 @@ func (r repositoryPurchase) Update(tx *sql.Tx, p models.Purchase) error {
 		p.Paid,
 		p.IDPaymentType,
 		p.IDPurchaseType,
-		p.IDCreditCard,
+		uuid.NullUUID{UUID: p.IDCreditCard, Valid: p.IDCreditCard != uuid.Nil},
 		p.IDPerson,
+		p.IDAccount,
 		p.ID,
-	); err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying update purchase: %v", err)
+		p.Version,
+		householdID,
+	)
+	if err != nil {
+		return fmt.Errorf("error trying update purchase: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist purchase with this id")
+	if err := checkVersion(ctx, tx, result, "purchase", "purchase", p.ID); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return nil
 }
 
-func (r repositoryPurchase) Delete(tx *sql.Tx, id uuid.UUID) error {
-	query := `DELETE FROM purchase WHERE id = $1`
+func (r repositoryPurchase) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := r.db.Prepare(query)
+	query := `UPDATE purchase 
+			SET deleted_at = now(), 
+				version = version + 1 
+			WHERE id = $1 
+				AND version = $2 
+				AND id_household = $3
+				AND deleted_at IS NULL`
+
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	_, err = stmt.Exec(id)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying delete purchase: %v", err)
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete purchase: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist purchase with this id")
+	if err := checkVersion(ctx, tx, result, "purchase", "purchase", id); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return nil
 }
 
-func (r repositoryPurchase) FindByID(id uuid.UUID) (models.PurchaseResponse, error) {
+// Restore takes the purchase out of the trash and returns it as stored.
+func (r repositoryPurchase) Restore(ctx context.Context, tx *sql.Tx, id uuid.UUID) (models.Purchase, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Purchase{}, err
+	}
+
+	query := `UPDATE purchase 
+			SET deleted_at = NULL, 
+				version = version + 1 
+			WHERE id = $1 
+				AND id_household = $2
+				AND deleted_at IS NOT NULL
+			RETURNING 
+				id, 
+				description, 
+				amount, 
+				to_char("date", 'YYYY-MM-DD'), 
+				place, 
+				paid, 
+				id_payment_type, 
+				id_purchase_type, 
+				id_credit_card, 
+				id_person, 
+				id_account, 
+				version`
+
+	var (
+		p            models.Purchase
+		creditCardID uuid.NullUUID
+	)
+
+	err = tx.QueryRow(query, id, householdID).Scan(
+		&p.ID,
+		&p.Description,
+		&p.Amount,
+		&p.Date,
+		&p.Place,
+		&p.Paid,
+		&p.IDPaymentType,
+		&p.IDPurchaseType,
+		&creditCardID,
+		&p.IDPerson,
+		&p.IDAccount,
+		&p.Version,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return models.Purchase{}, fmt.Errorf("error trying restore purchase: %w", err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return models.Purchase{}, models.NotFound("deleted purchase")
+	}
+
+	p.IDCreditCard = creditCardID.UUID
+
+	return p, nil
+}
+
+// FindByID and the listings read the plan from its installments only: the
+// credits of refunds are installments numbered 0. A cancelling refund lowers
+// the value of the last unpaid installments, so the plan is taken from the
+// first month instead of grouping by the installment, which would list the
+// purchase once per value.
+func (r repositoryPurchase) FindByID(ctx context.Context, id uuid.UUID) (models.PurchaseResponse, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
 	query := `SELECT 
 				p.id, 
 				p.description, 
@@ -168,25 +305,37 @@ func (r repositoryPurchase) FindByID(id uuid.UUID) (models.PurchaseResponse, err
 				p.paid,
 				pt."name",
 				purt."name", 
-				cc."owner", 
-				per."name" 
+				COALESCE(cc."owner", '') AS "owner", 
+				per."name",
+				ARRAY(
+					SELECT t."name" FROM purchase_tag ptag
+					INNER JOIN tag t ON ptag.id_tag = t.id
+					WHERE ptag.id_purchase = p.id
+					ORDER BY t."name"
+				) AS tags,
+				COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0) AS refunded,
+				p.version
 			FROM purchase p
 			INNER JOIN payment_type pt 
 				ON p.id_payment_type = pt.id 
 			INNER JOIN purchase_type purt	
 				ON p.id_purchase_type = purt.id 
-			INNER JOIN credit_card cc	
+			LEFT JOIN credit_card cc	
 				ON p.id_credit_card = cc.id
 			INNER JOIN person per	
 				ON p.id_person = per.id
 			LEFT JOIN installment i
-				ON p.id = i.purchase_id		
+				ON p.id = i.purchase_id
+				AND i.number > 0
 			WHERE p.id = $1
+				AND p.id_household = $2
+				AND p.deleted_at IS NULL
+			ORDER BY i.month
 			LIMIT 1;`
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return models.PurchaseResponse{}, fmt.Errorf("error trying prepare statment: %v", err)
+		return models.PurchaseResponse{}, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
 	var (
@@ -194,7 +343,7 @@ func (r repositoryPurchase) FindByID(id uuid.UUID) (models.PurchaseResponse, err
 		installmentNumber = sql.NullInt64{}
 		installment       = sql.NullFloat64{}
 	)
-	if err = stmt.QueryRow(id).Scan(
+	if err = stmt.QueryRow(id, householdID).Scan(
 		&pt.ID,
 		&pt.Description,
 		&pt.Amount,
@@ -207,22 +356,88 @@ func (r repositoryPurchase) FindByID(id uuid.UUID) (models.PurchaseResponse, err
 		&pt.PurchaseType,
 		&pt.CreditCard,
 		&pt.Person,
+		(*pq.StringArray)(&pt.Tags),
+		&pt.Refunded,
+		&pt.Version,
 	); err != nil && err != sql.ErrNoRows {
-		return models.PurchaseResponse{}, fmt.Errorf("error trying find purchase: %v", err)
+		return models.PurchaseResponse{}, fmt.Errorf("error trying find purchase: %w", err)
 	}
 
 	if err != nil && err == sql.ErrNoRows {
-		return models.PurchaseResponse{}, fmt.Errorf("does not exist purchase with this id")
+		return models.PurchaseResponse{}, models.NotFound("purchase")
 	}
 
 	if err := stmt.Close(); err != nil {
-		return models.PurchaseResponse{}, fmt.Errorf("error trying close statment: %v", err)
+		return models.PurchaseResponse{}, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return pt, nil
 }
 
-func (r repositoryPurchase) FindByDate(date string) ([]models.PurchaseResponse, error) {
+// FindEntity returns the purchase as stored, with the IDs of the records it
+// references instead of their names.
+func (r repositoryPurchase) FindEntity(ctx context.Context, id uuid.UUID) (models.Purchase, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Purchase{}, err
+	}
+
+	query := `SELECT 
+				id, 
+				description, 
+				amount, 
+				to_char("date", 'YYYY-MM-DD'), 
+				place, 
+				paid, 
+				id_payment_type, 
+				id_purchase_type, 
+				id_credit_card, 
+				id_person, 
+				id_account,
+				version
+			FROM purchase 
+			WHERE id = $1
+				AND id_household = $2
+				AND deleted_at IS NULL`
+
+	var (
+		p            models.Purchase
+		creditCardID uuid.NullUUID
+	)
+
+	err = r.db.QueryRow(query, id, householdID).Scan(
+		&p.ID,
+		&p.Description,
+		&p.Amount,
+		&p.Date,
+		&p.Place,
+		&p.Paid,
+		&p.IDPaymentType,
+		&p.IDPurchaseType,
+		&creditCardID,
+		&p.IDPerson,
+		&p.IDAccount,
+		&p.Version,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return models.Purchase{}, fmt.Errorf("error trying find purchase: %w", err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return models.Purchase{}, models.NotFound("purchase")
+	}
+
+	p.IDCreditCard = creditCardID.UUID
+
+	return p, nil
+}
+
+func (r repositoryPurchase) FindByDate(ctx context.Context, date string) ([]models.PurchaseResponse, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var purchases []models.PurchaseResponse
 
 	query := `SELECT 
@@ -230,33 +445,41 @@ func (r repositoryPurchase) FindByDate(date string) ([]models.PurchaseResponse,
 				p.description, 
 				p.amount, 
 				p."date", 
-				i.number as installment_number, 
-				i.value as installment, 
+				MAX(i.number) as installment_number, 
+				(ARRAY_AGG(i.value ORDER BY i.month))[1] as installment, 
 				p.place,
 				p.paid, 
 				pt."name",
 				purt."name", 
-				cc."owner", 
-				per."name"
+				COALESCE(cc."owner", '') AS "owner", 
+				per."name",
+				ARRAY(
+					SELECT t."name" FROM purchase_tag ptag
+					INNER JOIN tag t ON ptag.id_tag = t.id
+					WHERE ptag.id_purchase = p.id
+					ORDER BY t."name"
+				) AS tags,
+				COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0) AS refunded
 			FROM purchase p
 			INNER JOIN payment_type pt 
 				ON p.id_payment_type = pt.id 
 			INNER JOIN purchase_type purt	
 				ON p.id_purchase_type = purt.id 
-			INNER JOIN credit_card cc	
+			LEFT JOIN credit_card cc	
 				ON p.id_credit_card = cc.id
 			INNER JOIN person per	
 				ON p.id_person = per.id 
 			LEFT JOIN installment i
 				ON p.id = i.purchase_id
+				AND i.number > 0
 			WHERE "date" = $1
+				AND p.id_household = $2
+				AND p.deleted_at IS NULL
 			GROUP BY
 				p.id, 
 				p.description, 
 				p.amount, 
 				p."date", 
-				i.number, 
-				i.value, 
 				p.place,
 				p.paid, 
 				pt."name",
@@ -266,12 +489,12 @@ func (r repositoryPurchase) FindByDate(date string) ([]models.PurchaseResponse,
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("error trying prepare statment: %v", err)
+		return nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	rows, err := stmt.Query(date)
+	rows, err := stmt.Query(date, householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error trying find purchase by date: %v", err)
+		return nil, fmt.Errorf("error trying find purchase by date: %w", err)
 	}
 
 	installmentNumber := sql.NullInt64{}
@@ -292,8 +515,10 @@ func (r repositoryPurchase) FindByDate(date string) ([]models.PurchaseResponse,
 			&p.PurchaseType,
 			&p.CreditCard,
 			&p.Person,
+			(*pq.StringArray)(&p.Tags),
+			&p.Refunded,
 		); err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("error trying scan purchase: %v", err)
+			return nil, fmt.Errorf("error trying scan purchase: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -304,17 +529,22 @@ func (r repositoryPurchase) FindByDate(date string) ([]models.PurchaseResponse,
 	}
 
 	if err := rows.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close rows: %v", err)
+		return nil, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close statment: %v", err)
+		return nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return purchases, nil
 }
 
-func (r repositoryPurchase) FindByMonth(date string) ([]models.PurchaseResponse, error) {
+func (r repositoryPurchase) FindByMonth(ctx context.Context, date string) ([]models.PurchaseResponse, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var purchases []models.PurchaseResponse
 
 	query := `SELECT 
@@ -322,33 +552,41 @@ func (r repositoryPurchase) FindByMonth(date string) ([]models.PurchaseResponse,
 				p.description, 
 				p.amount, 
 				p."date", 
-				i.number as installment_number, 
-				i.value as installment, 
+				MAX(i.number) as installment_number, 
+				(ARRAY_AGG(i.value ORDER BY i.month))[1] as installment, 
 				p.place, 
 				p.paid,
 				pt."name",
 				purt."name", 
-				cc."owner", 
-				per."name"
+				COALESCE(cc."owner", '') AS "owner", 
+				per."name",
+				ARRAY(
+					SELECT t."name" FROM purchase_tag ptag
+					INNER JOIN tag t ON ptag.id_tag = t.id
+					WHERE ptag.id_purchase = p.id
+					ORDER BY t."name"
+				) AS tags,
+				COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0) AS refunded
 			FROM purchase p
 			INNER JOIN payment_type pt 
 				on p.id_payment_type = pt.id 
 			INNER JOIN purchase_type purt	
 				on p.id_purchase_type = purt.id 
-			INNER JOIN credit_card cc	
+			LEFT JOIN credit_card cc	
 				on p.id_credit_card = cc.id
 			INNER JOIN person per	
 				on p.id_person = per.id 
 			LEFT JOIN installment i
-				ON p.id = i.purchase_id	
+				ON p.id = i.purchase_id
+				AND i.number > 0
 			WHERE to_char(p."date", 'YYYY-MM') = $1
+				AND p.id_household = $2
+				AND p.deleted_at IS NULL
 			GROUP BY
 				p.id, 
 				p.description, 
 				p.amount, 
 				p."date", 
-				i.number, 
-				i.value, 
 				p.place,
 				p.paid, 
 				pt."name",
@@ -359,12 +597,12 @@ func (r repositoryPurchase) FindByMonth(date string) ([]models.PurchaseResponse,
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("error trying prepare statment: %v", err)
+		return nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	rows, err := stmt.Query(date)
+	rows, err := stmt.Query(date, householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error trying find purchase by date: %v", err)
+		return nil, fmt.Errorf("error trying find purchase by date: %w", err)
 	}
 
 	installmentNumber := sql.NullInt64{}
@@ -385,8 +623,10 @@ func (r repositoryPurchase) FindByMonth(date string) ([]models.PurchaseResponse,
 			&p.PurchaseType,
 			&p.CreditCard,
 			&p.Person,
+			(*pq.StringArray)(&p.Tags),
+			&p.Refunded,
 		); err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("error trying scan purchase: %v", err)
+			return nil, fmt.Errorf("error trying scan purchase: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -397,50 +637,75 @@ func (r repositoryPurchase) FindByMonth(date string) ([]models.PurchaseResponse,
 	}
 
 	if err := rows.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close rows: %v", err)
+		return nil, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close statment: %v", err)
+		return nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return purchases, nil
 }
 
-func (r repositoryPurchase) FindByPerson(id uuid.UUID) ([]models.PurchaseResponse, error) {
+// FindByPerson returns the purchases of a person. When a purchase is split the
+// amount and installment value are reduced to the person's share, and purchases
+// split with the person are included even if someone else registered them.
+func (r repositoryPurchase) FindByPerson(ctx context.Context, id uuid.UUID) ([]models.PurchaseResponse, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `SELECT 
 				p.id, 
 				p.description, 
-				p.amount, 
+				COALESCE(ps.amount, p.amount) as amount, 
 				p."date", 
-				i.number as installment_number, 
-				i.value as installment, 
+				MAX(i.number) as installment_number, 
+				ROUND(((ARRAY_AGG(i.value ORDER BY i.month))[1] * COALESCE(ps.amount, p.amount) / p.amount)::numeric, 2) as installment, 
 				p.place, 
 				p.paid,
 				pt."name",
 				purt."name", 
-				cc."owner", 
-				per."name"
+				COALESCE(cc."owner", '') AS "owner", 
+				per."name",
+				ARRAY(
+					SELECT t."name" FROM purchase_tag ptag
+					INNER JOIN tag t ON ptag.id_tag = t.id
+					WHERE ptag.id_purchase = p.id
+					ORDER BY t."name"
+				) AS tags,
+				ROUND((COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0) * COALESCE(ps.amount, p.amount) / p.amount)::numeric, 2) AS refunded
 			FROM purchase p
 			INNER JOIN payment_type pt 
 				ON p.id_payment_type = pt.id 
 			INNER JOIN purchase_type purt	
 				ON p.id_purchase_type = purt.id 
-			INNER JOIN credit_card cc	
+			LEFT JOIN credit_card cc	
 				ON p.id_credit_card = cc.id
 			INNER JOIN person per	
 				ON p.id_person = per.id
 			LEFT JOIN installment i
-				ON p.id = i.purchase_id	
-			WHERE p.id_person = $1
+				ON p.id = i.purchase_id
+				AND i.number > 0
+			LEFT JOIN purchase_share ps
+				ON ps.id_purchase = p.id
+				AND ps.id_person = $1
+			WHERE p.deleted_at IS NULL
+				AND p.id_household = $2
+				AND (
+					ps.id_person IS NOT NULL
+					OR (
+						p.id_person = $1 
+						AND NOT EXISTS (SELECT 1 FROM purchase_share s WHERE s.id_purchase = p.id)
+					)
+				)
 			GROUP BY
 				p.id, 
 				p.description, 
 				p.amount, 
+				ps.amount, 
 				p."date", 
-				i.number, 
-				i.value, 
 				p.place,
 				p.paid, 
 				pt."name",
@@ -451,12 +716,12 @@ func (r repositoryPurchase) FindByPerson(id uuid.UUID) ([]models.PurchaseRespons
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("error trying prepare statment: %v", err)
+		return nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	rows, err := stmt.Query(id)
+	rows, err := stmt.Query(id, householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error trying find purchase by person: %v", err)
+		return nil, fmt.Errorf("error trying find purchase by person: %w", err)
 	}
 
 	var (
@@ -480,8 +745,10 @@ func (r repositoryPurchase) FindByPerson(id uuid.UUID) ([]models.PurchaseRespons
 			&p.PurchaseType,
 			&p.CreditCard,
 			&p.Person,
+			(*pq.StringArray)(&p.Tags),
+			&p.Refunded,
 		); err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("error trying scan purchase: %v", err)
+			return nil, fmt.Errorf("error trying scan purchase: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -492,48 +759,61 @@ func (r repositoryPurchase) FindByPerson(id uuid.UUID) ([]models.PurchaseRespons
 	}
 
 	if err := rows.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close rows: %v", err)
+		return nil, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close statment: %v", err)
+		return nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return purchases, nil
 }
 
-func (r repositoryPurchase) FindAll() ([]models.PurchaseResponse, error) {
+func (r repositoryPurchase) FindAll(ctx context.Context) ([]models.PurchaseResponse, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `SELECT 
 				p.id, 
 				p.description, 
 				p.amount, 
 				p."date", 
-				i.number as installment_number, 
-				i.value as installment, 
+				MAX(i.number) as installment_number, 
+				(ARRAY_AGG(i.value ORDER BY i.month))[1] as installment, 
 				p.place, 
 				p.paid,
 				pt."name",
 				purt."name", 
-				cc."owner", 
-				per."name"
+				COALESCE(cc."owner", '') AS "owner", 
+				per."name",
+				ARRAY(
+					SELECT t."name" FROM purchase_tag ptag
+					INNER JOIN tag t ON ptag.id_tag = t.id
+					WHERE ptag.id_purchase = p.id
+					ORDER BY t."name"
+				) AS tags,
+				COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0) AS refunded
 			FROM purchase p
 			INNER JOIN payment_type pt 
 				ON p.id_payment_type = pt.id 
 			INNER JOIN purchase_type purt	
 				ON p.id_purchase_type = purt.id 
-			INNER JOIN credit_card cc	
+			LEFT JOIN credit_card cc	
 				ON p.id_credit_card = cc.id
 			INNER JOIN person per	
 				ON p.id_person = per.id
 			LEFT JOIN installment i
 				ON p.id = i.purchase_id
+				AND i.number > 0
+			WHERE p.deleted_at IS NULL
+				AND p.id_household = $1
 			GROUP BY
 				p.id, 
 				p.description, 
 				p.amount, 
 				p."date", 
-				i.number, 
-				i.value, 
 				p.place,
 				p.paid, 
 				pt."name",
@@ -542,9 +822,9 @@ func (r repositoryPurchase) FindAll() ([]models.PurchaseResponse, error) {
 				per."name"	
 			ORDER BY "date";`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, householdID)
 	if err != nil {
-		return nil, fmt.Errorf("error trying find all purchase: %v", err)
+		return nil, fmt.Errorf("error trying find all purchase: %w", err)
 	}
 
 	var (
@@ -568,8 +848,10 @@ func (r repositoryPurchase) FindAll() ([]models.PurchaseResponse, error) {
 			&p.PurchaseType,
 			&p.CreditCard,
 			&p.Person,
+			(*pq.StringArray)(&p.Tags),
+			&p.Refunded,
 		); err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("error trying scan purchase: %v", err)
+			return nil, fmt.Errorf("error trying scan purchase: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -580,7 +862,125 @@ func (r repositoryPurchase) FindAll() ([]models.PurchaseResponse, error) {
 	}
 
 	if err := rows.Close(); err != nil {
-		return nil, fmt.Errorf("error trying close rows: %v", err)
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return purchases, nil
+}
+
+// FindByTags returns the purchases linked to the given tags. When matchAll is
+// true a purchase must carry every tag, otherwise any of them is enough.
+func (r repositoryPurchase) FindByTags(ctx context.Context, tagIDs []uuid.UUID, matchAll bool) ([]models.PurchaseResponse, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				p.id, 
+				p.description, 
+				p.amount, 
+				p."date", 
+				MAX(i.number) as installment_number, 
+				(ARRAY_AGG(i.value ORDER BY i.month))[1] as installment, 
+				p.place, 
+				p.paid,
+				pt."name",
+				purt."name", 
+				COALESCE(cc."owner", '') AS "owner", 
+				per."name",
+				ARRAY(
+					SELECT t."name" FROM purchase_tag ptag
+					INNER JOIN tag t ON ptag.id_tag = t.id
+					WHERE ptag.id_purchase = p.id
+					ORDER BY t."name"
+				) AS tags,
+				COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0) AS refunded
+			FROM purchase p
+			INNER JOIN payment_type pt 
+				ON p.id_payment_type = pt.id 
+			INNER JOIN purchase_type purt	
+				ON p.id_purchase_type = purt.id 
+			LEFT JOIN credit_card cc	
+				ON p.id_credit_card = cc.id
+			INNER JOIN person per	
+				ON p.id_person = per.id
+			LEFT JOIN installment i
+				ON p.id = i.purchase_id
+				AND i.number > 0
+			WHERE p.deleted_at IS NULL
+				AND p.id_household = $3
+				AND p.id IN (
+				SELECT id_purchase 
+				FROM purchase_tag 
+				WHERE id_tag = ANY($1::uuid[])
+				GROUP BY id_purchase
+				HAVING COUNT(DISTINCT id_tag) >= $2
+			)
+			GROUP BY
+				p.id, 
+				p.description, 
+				p.amount, 
+				p."date", 
+				p.place,
+				p.paid, 
+				pt."name",
+				purt."name", 
+				cc."owner", 
+				per."name"
+			ORDER BY p."date";`
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	minMatches := 1
+	if matchAll {
+		minMatches = len(tagIDs)
+	}
+
+	rows, err := stmt.Query(pq.Array(uuidsToStrings(tagIDs)), minMatches, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find purchase by tags: %w", err)
+	}
+
+	var (
+		purchases         []models.PurchaseResponse
+		installmentNumber = sql.NullInt64{}
+		installment       = sql.NullFloat64{}
+	)
+
+	for rows.Next() {
+		var p models.PurchaseResponse
+		if err = rows.Scan(
+			&p.ID,
+			&p.Description,
+			&p.Amount,
+			&p.Date,
+			&installmentNumber,
+			&installment,
+			&p.Place,
+			&p.Paid,
+			&p.PaymentType,
+			&p.PurchaseType,
+			&p.CreditCard,
+			&p.Person,
+			(*pq.StringArray)(&p.Tags),
+			&p.Refunded,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan purchase: %w", err)
+		}
+
+		purchases = append(purchases, p)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return purchases, nil
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type PurchaseShareRepository interface {
+	Save(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID, shares []models.PurchaseShare) error
+	FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.PurchaseShare, error)
+}
+
+type purchaseShareRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseShareRepository(db *sql.DB) *purchaseShareRepository {
+	return &purchaseShareRepository{db}
+}
+
+// Save replaces the shares of a purchase inside the purchase transaction.
+func (r *purchaseShareRepository) Save(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID, shares []models.PurchaseShare) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM purchase_share WHERE id_purchase = $1 AND id_household = $2`, purchaseID, householdID); err != nil {
+		return fmt.Errorf("error trying delete purchase shares: %w", err)
+	}
+
+	query := `INSERT INTO purchase_share (id_household, id_purchase, id_person, percentage, amount) 
+			VALUES ($1, $2, $3, $4, $5)`
+
+	for _, share := range shares {
+		if _, err := tx.Exec(query, householdID, purchaseID, share.IDPerson, share.Percentage, share.Amount); err != nil {
+			return fmt.Errorf("error trying insert purchase share: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *purchaseShareRepository) FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.PurchaseShare, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ps.id_person, per."name", ps.percentage, ps.amount
+			FROM purchase_share ps
+			INNER JOIN person per
+				ON ps.id_person = per.id
+			WHERE ps.id_purchase = $1
+				AND ps.id_household = $2
+			ORDER BY per."name"`
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.Query(id, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	var shares []models.PurchaseShare
+	for rows.Next() {
+		var share models.PurchaseShare
+		if err := rows.Scan(&share.IDPerson, &share.Person, &share.Percentage, &share.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+
+		shares = append(shares, share)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return shares, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/me/finance/internal/models"
+)
+
+// newTestPurchase creates a purchase of 300 in three installments of 100.
+func newTestPurchase(ctx context.Context, t *testing.T, db *sql.DB) (models.Purchase, []models.Installment) {
+	t.Helper()
+
+	purchase := models.Purchase{Description: "Notebook", Amount: 300, Date: "2024-03-10", Version: 1}
+
+	installments := []models.Installment{
+		{Number: 3, Value: 100, Month: "2024-04-01"},
+		{Number: 3, Value: 100, Month: "2024-05-01"},
+		{Number: 3, Value: 100, Month: "2024-06-01"},
+	}
+
+	err := inTx(t, db, func(tx *sql.Tx) (err error) {
+		if purchase.IDPerson, err = NewRepositoryPerson(db).Create(ctx, tx, models.Person{Name: "Ana"}); err != nil {
+			return err
+		}
+
+		if purchase.IDPaymentType, err = NewRepositoryPaymentType(db).Create(ctx, tx, models.PaymentType{Name: "Card"}); err != nil {
+			return err
+		}
+
+		if purchase.IDPurchaseType, err = NewRepositoryPurchaseType(db).Create(ctx, tx, models.PurchaseType{Name: "Office"}); err != nil {
+			return err
+		}
+
+		if purchase.ID, err = NewRepositoryPurchase(db).Create(ctx, tx, purchase); err != nil {
+			return err
+		}
+
+		for i := range installments {
+			installments[i].PurchaseID = purchase.ID
+		}
+
+		return NewInstallmentRepository(db).CreateMany(ctx, tx, installments)
+	})
+	if err != nil {
+		t.Fatalf("creating the purchase: %v", err)
+	}
+
+	return purchase, installments
+}
+
+func TestPurchaseIsListedOnceAfterAPartialCancelRefund(t *testing.T) {
+	db := openTestDB(t)
+	home := newTestHousehold(t, db, "Home")
+
+	purchase, _ := newTestPurchase(home, t, db)
+
+	installments := NewInstallmentRepository(db)
+
+	// A cancelling refund of 50 lowers the last unpaid installment, so the
+	// purchase has installments of 100 and one of 50.
+	err := inTx(t, db, func(tx *sql.Tx) error {
+		refund := models.Refund{IDPurchase: purchase.ID, Amount: 50, Date: "2024-03-20", Mode: models.RefundModeCancel}
+		if _, err := NewRefundRepository(db).Create(home, tx, refund); err != nil {
+			return err
+		}
+
+		unpaid, err := installments.FindUnpaidByPurchaseID(home, tx, purchase.ID)
+		if err != nil {
+			return err
+		}
+
+		return installments.UpdateValue(home, tx, unpaid[0].ID, 50)
+	})
+	if err != nil {
+		t.Fatalf("refunding the purchase: %v", err)
+	}
+
+	purchases := NewRepositoryPurchase(db)
+
+	all, err := purchases.FindAll(home)
+	if err != nil {
+		t.Fatalf("listing the purchases: %v", err)
+	}
+
+	month, err := purchases.FindByMonth(home, "2024-03")
+	if err != nil {
+		t.Fatalf("listing the purchases of the month: %v", err)
+	}
+
+	for name, list := range map[string][]models.PurchaseResponse{"all": all, "month": month} {
+		var listed int
+		for _, p := range list {
+			if p.ID == purchase.ID {
+				listed++
+
+				if p.Refunded != 50 {
+					t.Errorf("%s: refunded %.2f, want 50", name, p.Refunded)
+				}
+			}
+		}
+
+		if listed != 1 {
+			t.Errorf("%s: the purchase was listed %d times, want once", name, listed)
+		}
+	}
+}
@@ -1,20 +1,22 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
+	"github.com/sagikazarmark/slog-shim"
 )
 
 type RepositoryPurchaseType interface {
-	Create(p models.PurchaseType) error
-	Update(pt models.PurchaseType) error
-	Delete(id uuid.UUID) error
-	FindByID(id uuid.UUID) (models.PurchaseType, error)
-	FindAll() ([]models.PurchaseType, error)
+	Create(ctx context.Context, tx *sql.Tx, p models.PurchaseType) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, pt models.PurchaseType) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.PurchaseType, error)
+	FindParentForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (uuid.NullUUID, error)
+	FindAll(ctx context.Context) ([]models.PurchaseType, error)
 }
 
 type repositoryPurchaseType struct {
@@ -25,113 +27,163 @@ func NewRepositoryPurchaseType(db *sql.DB) *repositoryPurchaseType {
 	return &repositoryPurchaseType{db}
 }
 
-func (r repositoryPurchaseType) Create(p models.PurchaseType) error {
-	query := `INSERT INTO purchase_type (id, name) VALUES ($1, $2)`
-	stmt, err := r.db.Prepare(query)
+func (r repositoryPurchaseType) Create(ctx context.Context, tx *sql.Tx, p models.PurchaseType) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return uuid.Nil, err
 	}
 
-	id, err := uuid.NewUUID()
+	query := `INSERT INTO purchase_type (id, id_household, name, id_parent) VALUES ($1, $2, $3, $4)`
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying create uuid: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
 	}
-	if _, err = stmt.Exec(id, p.Name); err != nil {
-		return fmt.Errorf("error trying insert purchase type type: %v", err)
+
+	id, err := newID(p.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err = stmt.Exec(id, householdID, p.Name, p.IDParent); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert purchase type type: %w", err), "purchase_type", "purchase type", id)
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
-func (r repositoryPurchaseType) Update(pt models.PurchaseType) error {
-	query := `UPDATE purchase_type SET name = $1 WHERE id = $2`
-	stmt, err := r.db.Prepare(query)
+func (r repositoryPurchaseType) Update(ctx context.Context, tx *sql.Tx, pt models.PurchaseType) error {
+	householdID, err := models.HouseholdFrom(ctx)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return err
 	}
 
-	if _, err = stmt.Exec(pt.Name, pt.ID); err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying update purchase type: %v", err)
+	query := `UPDATE purchase_type SET name = $1, id_parent = $2, version = version + 1 WHERE id = $3 AND version = $4 AND id_household = $5 AND deleted_at IS NULL`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist purchase type with this id")
+	result, err := stmt.Exec(pt.Name, pt.IDParent, pt.ID, pt.Version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying update purchase type: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "purchase_type", "purchase type", pt.ID); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return nil
 }
 
-func (r repositoryPurchaseType) Delete(id uuid.UUID) error {
-	query := `DELETE FROM purchase_type WHERE id = $1`
+func (r repositoryPurchaseType) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := r.db.Prepare(query)
+	query := `UPDATE purchase_type SET deleted_at = now(), version = version + 1 WHERE id = $1 AND version = $2 AND id_household = $3 AND deleted_at IS NULL`
+
+	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("error trying prepare statment: %v", err)
+		return fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
-	_, err = stmt.Exec(id)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("error trying delete purchase type: %v", err)
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete purchase type: %w", err)
 	}
 
-	if err != nil && err == sql.ErrNoRows {
-		return fmt.Errorf("does not exist purchase type with this id")
+	if err := checkVersion(ctx, tx, result, "purchase_type", "purchase type", id); err != nil {
+		return err
 	}
 
 	if err := stmt.Close(); err != nil {
-		return fmt.Errorf("error trying close statment: %v", err)
+		return fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return nil
 }
 
-func (r repositoryPurchaseType) FindByID(id uuid.UUID) (models.PurchaseType, error) {
-	query := "SELECT id, name FROM purchase_type WHERE id = $1"
+func (r repositoryPurchaseType) FindByID(ctx context.Context, id uuid.UUID) (models.PurchaseType, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.PurchaseType{}, err
+	}
+
+	query := "SELECT id, name, id_parent, version FROM purchase_type WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL"
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return models.PurchaseType{}, fmt.Errorf("error trying prepare statment: %v", err)
+		return models.PurchaseType{}, fmt.Errorf("error trying prepare statment: %w", err)
 	}
 
 	var pt models.PurchaseType
-	if err = stmt.QueryRow(id).Scan(&pt.ID, &pt.Name); err != nil && err != sql.ErrNoRows {
-		return models.PurchaseType{}, fmt.Errorf("error trying find purchase type: %v", err)
+	if err = stmt.QueryRow(id, householdID).Scan(&pt.ID, &pt.Name, &pt.IDParent, &pt.Version); err != nil && err != sql.ErrNoRows {
+		return models.PurchaseType{}, fmt.Errorf("error trying find purchase type: %w", err)
 	}
 
 	if err != nil && err == sql.ErrNoRows {
-		return models.PurchaseType{}, fmt.Errorf("does not exist purchase type with this id")
+		return models.PurchaseType{}, models.NotFound("purchase type")
 	}
 
 	if err := stmt.Close(); err != nil {
-		return models.PurchaseType{}, fmt.Errorf("error trying close statment: %v", err)
+		return models.PurchaseType{}, fmt.Errorf("error trying close statment: %w", err)
 	}
 
 	return pt, nil
 }
 
-func (r repositoryPurchaseType) FindAll() ([]models.PurchaseType, error) {
-	query := "SELECT id, name FROM purchase_type ORDER BY name"
+// FindParentForUpdate returns the parent of a purchase type, locking the row
+// until the transaction ends so the hierarchy cannot change while it is walked.
+func (r repositoryPurchaseType) FindParentForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (uuid.NullUUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	query := `SELECT id_parent FROM purchase_type WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL FOR UPDATE`
+
+	var parentID uuid.NullUUID
+
+	err = tx.QueryRow(query, id, householdID).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return uuid.NullUUID{}, models.NotFound("purchase type")
+	}
+
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("error trying find parent purchase type: %w", err)
+	}
+
+	return parentID, nil
+}
+
+func (r repositoryPurchaseType) FindAll(ctx context.Context) ([]models.PurchaseType, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, name, id_parent, version FROM purchase_type WHERE id_household = $1 AND deleted_at IS NULL ORDER BY name"
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, householdID)
 	if err != nil {
-		slog.Error("error trying find all purchase type: %v", err)
-		return []models.PurchaseType{}, fmt.Errorf("error trying find all purchase type: %v", err)
+		slog.Error(fmt.Sprintf("error trying find all purchase type: %v", err))
+		return []models.PurchaseType{}, fmt.Errorf("error trying find all purchase type: %w", err)
 	}
 
 	var purchases []models.PurchaseType
 
 	for rows.Next() {
 		var pt models.PurchaseType
-		if err = rows.Scan(&pt.ID, &pt.Name); err != nil && err != sql.ErrNoRows {
-			return []models.PurchaseType{}, fmt.Errorf("error trying scan purchase type: %v", err)
+		if err = rows.Scan(&pt.ID, &pt.Name, &pt.IDParent, &pt.Version); err != nil && err != sql.ErrNoRows {
+			return []models.PurchaseType{}, fmt.Errorf("error trying scan purchase type: %w", err)
 		}
 
 		if err != nil && err == sql.ErrNoRows {
@@ -142,7 +194,7 @@ func (r repositoryPurchaseType) FindAll() ([]models.PurchaseType, error) {
 	}
 
 	if err := rows.Close(); err != nil {
-		return []models.PurchaseType{}, fmt.Errorf("error trying close rows: %v", err)
+		return []models.PurchaseType{}, fmt.Errorf("error trying close rows: %w", err)
 	}
 
 	return purchases, nil
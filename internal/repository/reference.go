@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+// reference is a column pointing to a person, credit card, payment type or
+// purchase type. live filters out the referencing rows that are in the trash,
+// which do not keep the referenced record from being deleted.
+type reference struct {
+	table  string
+	column string
+	live   string
+	count  func(d *models.Dependents) *int
+}
+
+func purchasesCount(d *models.Dependents) *int       { return &d.Purchases }
+func purchaseSharesCount(d *models.Dependents) *int  { return &d.PurchaseShares }
+func purchaseTypesCount(d *models.Dependents) *int   { return &d.PurchaseTypes }
+func creditCardsCount(d *models.Dependents) *int     { return &d.CreditCards }
+func accountsCount(d *models.Dependents) *int        { return &d.Accounts }
+func incomesCount(d *models.Dependents) *int         { return &d.Incomes }
+func settlementsCount(d *models.Dependents) *int     { return &d.Settlements }
+func invoicePaymentsCount(d *models.Dependents) *int { return &d.InvoicePayments }
+
+const livePurchaseShare = `EXISTS (SELECT 1 FROM purchase p WHERE p.id = purchase_share.id_purchase AND p.deleted_at IS NULL)`
+
+var references = map[string][]reference{
+	"person": {
+		{"purchase", "id_person", "deleted_at IS NULL", purchasesCount},
+		{"purchase_share", "id_person", livePurchaseShare, purchaseSharesCount},
+		{"credit_card", "id_person", "deleted_at IS NULL", creditCardsCount},
+		{"account", "id_person", "", accountsCount},
+		{"income", "id_person", "", incomesCount},
+		{"settlement", "id_payer", "", settlementsCount},
+		{"settlement", "id_receiver", "", settlementsCount},
+	},
+	"credit_card": {
+		{"purchase", "id_credit_card", "deleted_at IS NULL", purchasesCount},
+		{"invoice_payment", "id_credit_card", "", invoicePaymentsCount},
+	},
+	"payment_type": {
+		{"purchase", "id_payment_type", "deleted_at IS NULL", purchasesCount},
+	},
+	"purchase_type": {
+		{"purchase", "id_purchase_type", "deleted_at IS NULL", purchasesCount},
+		{"purchase_type", "id_parent", "deleted_at IS NULL", purchaseTypesCount},
+	},
+}
+
+type ReferenceRepository interface {
+	CountDependents(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) (models.Dependents, error)
+	Reassign(ctx context.Context, tx *sql.Tx, table string, from, to uuid.UUID) (models.Dependents, error)
+}
+
+type referenceRepository struct {
+	db *sql.DB
+}
+
+func NewReferenceRepository(db *sql.DB) *referenceRepository {
+	return &referenceRepository{db}
+}
+
+// CountDependents counts the live records referencing the row. The row is
+// locked, so nothing can start referencing it before the transaction ends.
+// The references are not filtered by household: the foreign keys already keep
+// them in the household of the row.
+func (r *referenceRepository) CountDependents(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) (models.Dependents, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Dependents{}, err
+	}
+
+	if err := lockReferenced(tx, table, householdID, id); err != nil {
+		return models.Dependents{}, err
+	}
+
+	var dependents models.Dependents
+
+	for _, ref := range references[table] {
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s = $1`, ref.table, ref.column)
+		if ref.live != "" {
+			query += " AND " + ref.live
+		}
+
+		var count int
+		if err := tx.QueryRow(query, id).Scan(&count); err != nil {
+			return models.Dependents{}, fmt.Errorf("error trying count %s of %s: %w", ref.table, table, err)
+		}
+
+		*ref.count(&dependents) += count
+	}
+
+	return dependents, nil
+}
+
+// Reassign points every record referencing from, trashed ones included, to
+// the record to, and returns how many were moved. Shares the two persons had
+// in the same purchase are added together.
+func (r *referenceRepository) Reassign(ctx context.Context, tx *sql.Tx, table string, from, to uuid.UUID) (models.Dependents, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Dependents{}, err
+	}
+
+	if err := lockReferenced(tx, table, householdID, from); err != nil {
+		return models.Dependents{}, err
+	}
+
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL)`, table)
+	if err := tx.QueryRow(query, to, householdID).Scan(&exists); err != nil {
+		return models.Dependents{}, fmt.Errorf("error trying find %s to reassign to: %w", table, err)
+	}
+
+	if !exists {
+		return models.Dependents{}, models.NotFound(table + " to reassign to")
+	}
+
+	switch table {
+	case "person":
+		if err := mergePurchaseShares(tx, from, to); err != nil {
+			return models.Dependents{}, err
+		}
+	case "purchase_type":
+		if err := checkNotSubtype(tx, from, to); err != nil {
+			return models.Dependents{}, err
+		}
+	}
+
+	var moved models.Dependents
+
+	for _, ref := range references[table] {
+		query := fmt.Sprintf(`UPDATE %s SET %s = $2 WHERE %s = $1`, ref.table, ref.column, ref.column)
+
+		result, err := tx.Exec(query, from, to)
+		if err != nil {
+			return models.Dependents{}, fmt.Errorf("error trying reassign %s of %s: %w", ref.table, table, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return models.Dependents{}, fmt.Errorf("error trying reassign %s of %s: %w", ref.table, table, err)
+		}
+
+		*ref.count(&moved) += int(affected)
+	}
+
+	return moved, nil
+}
+
+func lockReferenced(tx *sql.Tx, table string, householdID, id uuid.UUID) error {
+	query := fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL FOR UPDATE`, table)
+
+	var locked uuid.UUID
+	err := tx.QueryRow(query, id, householdID).Scan(&locked)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error trying lock %s: %w", table, err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return models.NotFound(table)
+	}
+
+	return nil
+}
+
+// mergePurchaseShares folds the shares of from into the shares of to in the
+// purchases both take part in, since a person has a single share per purchase.
+// Settlements between the two cannot be merged and stop the reassignment.
+func mergePurchaseShares(tx *sql.Tx, from, to uuid.UUID) error {
+	query := `SELECT COUNT(*) FROM settlement
+			WHERE (id_payer = $1 AND id_receiver = $2)
+				OR (id_payer = $2 AND id_receiver = $1)`
+
+	var settlements int
+	if err := tx.QueryRow(query, from, to).Scan(&settlements); err != nil {
+		return fmt.Errorf("error trying find settlements between persons: %w", err)
+	}
+
+	if settlements > 0 {
+		return models.Conflict("cannot reassign, there are %d settlements between the two persons", settlements)
+	}
+
+	query = `UPDATE purchase_share t
+			SET amount = t.amount + f.amount,
+				percentage = t.percentage + f.percentage
+			FROM purchase_share f
+			WHERE f.id_purchase = t.id_purchase
+				AND f.id_person = $1
+				AND t.id_person = $2`
+
+	if _, err := tx.Exec(query, from, to); err != nil {
+		return fmt.Errorf("error trying merge purchase shares: %w", err)
+	}
+
+	query = `DELETE FROM purchase_share f
+			WHERE f.id_person = $1
+				AND EXISTS (SELECT 1 FROM purchase_share t WHERE t.id_purchase = f.id_purchase AND t.id_person = $2)`
+
+	if _, err := tx.Exec(query, from, to); err != nil {
+		return fmt.Errorf("error trying merge purchase shares: %w", err)
+	}
+
+	return nil
+}
+
+// checkNotSubtype refuses to move the subtypes of a purchase type under one of
+// its own descendants, which would turn the tree into a cycle.
+func checkNotSubtype(tx *sql.Tx, from, to uuid.UUID) error {
+	query := `WITH RECURSIVE descendant AS (
+				SELECT id FROM purchase_type WHERE id_parent = $1
+				UNION
+				SELECT pt.id FROM purchase_type pt
+				INNER JOIN descendant d
+					ON pt.id_parent = d.id
+			)
+			SELECT EXISTS (SELECT 1 FROM descendant WHERE id = $2)`
+
+	var subtype bool
+	if err := tx.QueryRow(query, from, to).Scan(&subtype); err != nil {
+		return fmt.Errorf("error trying find purchase subtypes: %w", err)
+	}
+
+	if subtype {
+		return models.Invalid("cannot reassign a purchase type to one of its subtypes")
+	}
+
+	return nil
+}
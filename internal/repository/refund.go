@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type RefundRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, refund models.Refund) (uuid.UUID, error)
+	TotalByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) (float64, error)
+	FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Refund, error)
+}
+
+type refundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) *refundRepository {
+	return &refundRepository{db}
+}
+
+func (r *refundRepository) Create(ctx context.Context, tx *sql.Tx, refund models.Refund) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO purchase_refund (id, id_household, id_purchase, amount, "date", reason, mode) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	id, err := newID(refund.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := tx.Exec(query, id, householdID, refund.IDPurchase, refund.Amount, refund.Date, refund.Reason, refund.Mode); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert refund: %w", err), "purchase_refund", "refund", id)
+	}
+
+	return id, nil
+}
+
+// TotalByPurchaseID locks the purchase so concurrent refunds cannot exceed its
+// amount, and returns how much of it was already refunded.
+func (r *refundRepository) TotalByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) (float64, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT id FROM purchase WHERE id = $1 AND id_household = $2 AND deleted_at IS NULL FOR UPDATE`
+
+	var locked uuid.UUID
+	if err := tx.QueryRow(query, id, householdID).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, models.NotFound("purchase")
+		}
+
+		return 0, fmt.Errorf("error trying lock purchase: %w", err)
+	}
+
+	var total float64
+
+	query = `SELECT COALESCE(SUM(amount), 0) FROM purchase_refund WHERE id_purchase = $1 AND id_household = $2`
+	if err := tx.QueryRow(query, id, householdID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("error trying find refunded amount: %w", err)
+	}
+
+	return total, nil
+}
+
+func (r *refundRepository) FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Refund, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, id_purchase, amount, "date", reason, mode 
+			FROM purchase_refund 
+			WHERE id_purchase = $1 
+				AND id_household = $2
+			ORDER BY "date"`
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.Query(id, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	var refunds []models.Refund
+	for rows.Next() {
+		var refund models.Refund
+		if err := rows.Scan(
+			&refund.ID,
+			&refund.IDPurchase,
+			&refund.Amount,
+			&refund.Date,
+			&refund.Reason,
+			&refund.Mode,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return refunds, nil
+}
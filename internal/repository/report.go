@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/me/finance/internal/models"
+)
+
+type ReportRepository interface {
+	TotalsByTag(ctx context.Context, month string) ([]models.TagTotal, error)
+	TotalsByPurchaseType(ctx context.Context, month string) ([]models.PurchaseTypeTotal, error)
+	ExpensesByMonth(ctx context.Context, month string) (float64, error)
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *reportRepository {
+	return &reportRepository{db}
+}
+
+func (r *reportRepository) TotalsByTag(ctx context.Context, month string) ([]models.TagTotal, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				t.id, 
+				t."name", 
+				COUNT(p.id), 
+				COALESCE(SUM(p.amount - COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0)), 0)
+			FROM tag t
+			INNER JOIN purchase_tag ptag
+				ON ptag.id_tag = t.id
+			INNER JOIN purchase p
+				ON p.id = ptag.id_purchase
+			WHERE to_char(p."date", 'YYYY-MM') = $1
+				AND t.id_household = $2
+				AND p.deleted_at IS NULL
+			GROUP BY t.id, t."name"
+			ORDER BY t."name";`
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.Query(month, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	var totals []models.TagTotal
+	for rows.Next() {
+		var total models.TagTotal
+		if err := rows.Scan(&total.ID, &total.Name, &total.Quantity, &total.Total); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+
+		totals = append(totals, total)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return totals, nil
+}
+
+// TotalsByPurchaseType returns the amount spent directly on each purchase
+// type in the month, including types without purchases so the tree can be
+// rebuilt by the caller.
+func (r *reportRepository) TotalsByPurchaseType(ctx context.Context, month string) ([]models.PurchaseTypeTotal, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				purt.id, 
+				purt."name", 
+				purt.id_parent,
+				COUNT(p.id), 
+				COALESCE(SUM(p.amount - COALESCE((SELECT SUM(r.amount) FROM purchase_refund r WHERE r.id_purchase = p.id), 0)), 0)
+			FROM purchase_type purt
+			LEFT JOIN purchase p
+				ON p.id_purchase_type = purt.id
+				AND to_char(p."date", 'YYYY-MM') = $1
+				AND p.deleted_at IS NULL
+			WHERE purt.id_household = $2
+			GROUP BY purt.id, purt."name", purt.id_parent, purt.deleted_at
+			HAVING purt.deleted_at IS NULL OR COUNT(p.id) > 0
+			ORDER BY purt."name";`
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.Query(month, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	var totals []models.PurchaseTypeTotal
+	for rows.Next() {
+		var total models.PurchaseTypeTotal
+		if err := rows.Scan(&total.ID, &total.Name, &total.IDParent, &total.Quantity, &total.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+
+		totals = append(totals, total)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return totals, nil
+}
+
+// ExpensesByMonth returns what is due in the month: the installments falling
+// in the month, including refund credits, plus purchases made in the month
+// that have no installments, less what was refunded of those in the month.
+func (r *reportRepository) ExpensesByMonth(ctx context.Context, month string) (float64, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT
+				COALESCE((
+					SELECT SUM(i.value) 
+					FROM installment i 
+					WHERE to_char(i.month, 'YYYY-MM') = $1
+						AND i.id_household = $2
+						AND i.deleted_at IS NULL
+				), 0)
+				+
+				COALESCE((
+					SELECT SUM(p.amount) 
+					FROM purchase p 
+					WHERE to_char(p."date", 'YYYY-MM') = $1
+						AND p.id_household = $2
+						AND p.deleted_at IS NULL
+						AND NOT EXISTS (SELECT 1 FROM installment i WHERE i.purchase_id = p.id)
+				), 0)
+				-
+				COALESCE((
+					SELECT SUM(r.amount) 
+					FROM purchase_refund r 
+					INNER JOIN purchase p
+						ON p.id = r.id_purchase
+					WHERE to_char(r."date", 'YYYY-MM') = $1
+						AND p.id_household = $2
+						AND p.deleted_at IS NULL
+						AND NOT EXISTS (SELECT 1 FROM installment i WHERE i.purchase_id = p.id)
+				), 0);`
+
+	var expenses float64
+	if err := r.db.QueryRow(query, month, householdID).Scan(&expenses); err != nil {
+		return 0, fmt.Errorf("error trying find expenses by month: %w", err)
+	}
+
+	return expenses, nil
+}
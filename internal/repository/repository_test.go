@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+// openTestDB connects to the migrated database in FINANCE_TEST_DATABASE_URL,
+// skipping the test when there is none.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("FINANCE_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("FINANCE_TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("pinging the database: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// newTestHousehold creates a household and returns a context scoped to it.
+func newTestHousehold(t *testing.T, db *sql.DB, name string) context.Context {
+	t.Helper()
+
+	households := NewHouseholdRepository(db)
+
+	tx, err := households.BeginTransaction()
+	if err != nil {
+		t.Fatalf("beginning the transaction: %v", err)
+	}
+
+	id, err := households.Create(tx, models.Household{Name: name})
+	if err != nil {
+		households.Rollback(tx)
+		t.Fatalf("creating the household: %v", err)
+	}
+
+	if err := households.Commit(tx); err != nil {
+		t.Fatalf("committing the household: %v", err)
+	}
+
+	return models.WithHousehold(context.Background(), id)
+}
+
+// inTx runs f in a transaction, committing it when f succeeds.
+func inTx(t *testing.T, db *sql.DB, f func(tx *sql.Tx) error) error {
+	t.Helper()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning the transaction: %v", err)
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
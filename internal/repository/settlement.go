@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+type SettlementRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, s models.Settlement) (uuid.UUID, error)
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Settlement, error)
+	FindAll(ctx context.Context) ([]models.Settlement, error)
+	FindDebts(ctx context.Context) ([]models.Debt, error)
+}
+
+type settlementRepository struct {
+	db *sql.DB
+}
+
+func NewSettlementRepository(db *sql.DB) *settlementRepository {
+	return &settlementRepository{db}
+}
+
+func (r *settlementRepository) Create(ctx context.Context, tx *sql.Tx, s models.Settlement) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO settlement (id, id_household, id_payer, id_receiver, amount, "date", description) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	id, err := newID(s.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err = stmt.Exec(id, householdID, s.IDPayer, s.IDReceiver, s.Amount, s.Date, s.Description); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert settlement: %w", err), "settlement", "settlement", id)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *settlementRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM settlement WHERE id = $1 AND id_household = $2`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(id, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete settlement: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("settlement")
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *settlementRepository) FindAll(ctx context.Context) ([]models.Settlement, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				s.id, 
+				s.id_payer, 
+				payer."name", 
+				s.id_receiver, 
+				receiver."name", 
+				s.amount, 
+				s."date", 
+				s.description
+			FROM settlement s
+			INNER JOIN person payer
+				ON s.id_payer = payer.id
+			INNER JOIN person receiver
+				ON s.id_receiver = receiver.id
+			WHERE s.id_household = $1
+			ORDER BY s."date";`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find all settlements: %w", err)
+	}
+
+	var settlements []models.Settlement
+
+	for rows.Next() {
+		var s models.Settlement
+		if err = rows.Scan(
+			&s.ID,
+			&s.IDPayer,
+			&s.Payer,
+			&s.IDReceiver,
+			&s.Receiver,
+			&s.Amount,
+			&s.Date,
+			&s.Description,
+		); err != nil {
+			return nil, fmt.Errorf("error trying scan settlement: %w", err)
+		}
+
+		settlements = append(settlements, s)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return settlements, nil
+}
+
+func (r *settlementRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Settlement, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Settlement{}, err
+	}
+
+	query := `SELECT 
+				s.id, 
+				s.id_payer, 
+				payer."name", 
+				s.id_receiver, 
+				receiver."name", 
+				s.amount, 
+				s."date", 
+				s.description
+			FROM settlement s
+			INNER JOIN person payer
+				ON s.id_payer = payer.id
+			INNER JOIN person receiver
+				ON s.id_receiver = receiver.id
+			WHERE s.id = $1
+				AND s.id_household = $2`
+
+	var s models.Settlement
+	if err = r.db.QueryRow(query, id, householdID).Scan(
+		&s.ID,
+		&s.IDPayer,
+		&s.Payer,
+		&s.IDReceiver,
+		&s.Receiver,
+		&s.Amount,
+		&s.Date,
+		&s.Description,
+	); err == sql.ErrNoRows {
+		return models.Settlement{}, models.NotFound("settlement")
+	}
+
+	if err != nil {
+		return models.Settlement{}, fmt.Errorf("error trying find settlement: %w", err)
+	}
+
+	return s, nil
+}
+
+// FindDebts returns what each person owes each card owner for purchases made
+// on their cards, less what was refunded in proportion to each share.
+// Settlements are returned as debts in the opposite direction so that summing
+// everything gives the net position between two persons.
+func (r *settlementRepository) FindDebts(ctx context.Context) ([]models.Debt, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT 
+				d.id_debtor, 
+				debtor."name", 
+				d.id_creditor, 
+				creditor."name", 
+				SUM(d.amount)
+			FROM (
+				SELECT 
+					COALESCE(ps.id_person, p.id_person) AS id_debtor,
+					cc.id_person AS id_creditor,
+					ROUND((COALESCE(ps.amount, p.amount) * (p.amount - COALESCE(r.amount, 0)) / p.amount)::numeric, 2) AS amount
+				FROM purchase p
+				INNER JOIN credit_card cc
+					ON p.id_credit_card = cc.id
+				LEFT JOIN purchase_share ps
+					ON ps.id_purchase = p.id
+				LEFT JOIN (
+					SELECT id_purchase, SUM(amount) AS amount
+					FROM purchase_refund
+					WHERE id_household = $1
+					GROUP BY id_purchase
+				) r
+					ON r.id_purchase = p.id
+				WHERE cc.id_person IS NOT NULL
+					AND p.id_household = $1
+					AND p.deleted_at IS NULL
+				UNION ALL
+				SELECT id_receiver, id_payer, amount
+				FROM settlement
+				WHERE id_household = $1
+			) d
+			INNER JOIN person debtor
+				ON d.id_debtor = debtor.id
+			INNER JOIN person creditor
+				ON d.id_creditor = creditor.id
+			WHERE d.id_debtor <> d.id_creditor
+			GROUP BY d.id_debtor, debtor."name", d.id_creditor, creditor."name";`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find debts: %w", err)
+	}
+
+	var debts []models.Debt
+
+	for rows.Next() {
+		var d models.Debt
+		if err = rows.Scan(&d.IDDebtor, &d.Debtor, &d.IDCreditor, &d.Creditor, &d.Amount); err != nil {
+			return nil, fmt.Errorf("error trying scan debt: %w", err)
+		}
+
+		debts = append(debts, d)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return debts, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+type TagRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, t models.Tag) (uuid.UUID, error)
+	Update(ctx context.Context, tx *sql.Tx, t models.Tag) error
+	Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Tag, error)
+	FindAll(ctx context.Context) ([]models.Tag, error)
+	SetPurchaseTags(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID, tagIDs []uuid.UUID) error
+	FindPurchaseTagIDs(ctx context.Context, purchaseID uuid.UUID) ([]uuid.UUID, error)
+	DeletePurchaseTags(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error
+}
+
+type tagRepository struct {
+	db *sql.DB
+}
+
+func NewRepositoryTag(db *sql.DB) *tagRepository {
+	return &tagRepository{db}
+}
+
+func (r tagRepository) Create(ctx context.Context, tx *sql.Tx, t models.Tag) (uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `INSERT INTO tag (id, id_household, name) VALUES ($1, $2, $3)`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	id, err := newID(t.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err = stmt.Exec(id, householdID, t.Name); err != nil {
+		return uuid.Nil, duplicateID(fmt.Errorf("error trying insert tag: %w", err), "tag", "tag", id)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return uuid.Nil, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r tagRepository) Update(ctx context.Context, tx *sql.Tx, t models.Tag) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE tag SET name = $1, version = version + 1 WHERE id = $2 AND version = $3 AND id_household = $4`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(t.Name, t.ID, t.Version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying update tag: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "tag", "tag", t.ID); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r tagRepository) Delete(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM tag WHERE id = $1 AND version = $2 AND id_household = $3`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	result, err := stmt.Exec(id, version, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying delete tag: %w", err)
+	}
+
+	if err := checkVersion(ctx, tx, result, "tag", "tag", id); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return nil
+}
+
+func (r tagRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Tag, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	query := "SELECT id, name, version FROM tag WHERE id = $1 AND id_household = $2"
+
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return models.Tag{}, fmt.Errorf("error trying prepare statment: %w", err)
+	}
+
+	var t models.Tag
+	if err = stmt.QueryRow(id, householdID).Scan(&t.ID, &t.Name, &t.Version); err != nil && err != sql.ErrNoRows {
+		return models.Tag{}, fmt.Errorf("error trying find tag: %w", err)
+	}
+
+	if err != nil && err == sql.ErrNoRows {
+		return models.Tag{}, models.NotFound("tag")
+	}
+
+	if err := stmt.Close(); err != nil {
+		return models.Tag{}, fmt.Errorf("error trying close statment: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r tagRepository) FindAll(ctx context.Context) ([]models.Tag, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, name, version FROM tag WHERE id_household = $1 ORDER BY name"
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return []models.Tag{}, fmt.Errorf("error trying find all tags: %w", err)
+	}
+
+	var tags []models.Tag
+
+	for rows.Next() {
+		var t models.Tag
+		if err = rows.Scan(&t.ID, &t.Name, &t.Version); err != nil {
+			return []models.Tag{}, fmt.Errorf("error trying scan tag: %w", err)
+		}
+
+		tags = append(tags, t)
+	}
+
+	if err := rows.Close(); err != nil {
+		return []models.Tag{}, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return tags, nil
+}
+
+// SetPurchaseTags replaces the tags linked to a purchase inside the purchase transaction.
+func (r tagRepository) SetPurchaseTags(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID, tagIDs []uuid.UUID) error {
+	if err := r.DeletePurchaseTags(ctx, tx, purchaseID); err != nil {
+		return err
+	}
+
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO purchase_tag (id_household, id_purchase, id_tag)
+			SELECT $1, $2, unnest($3::uuid[])
+			ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(query, householdID, purchaseID, pq.Array(uuidsToStrings(tagIDs))); err != nil {
+		return fmt.Errorf("error trying insert purchase tags: %w", err)
+	}
+
+	return nil
+}
+
+// FindPurchaseTagIDs returns the IDs of the tags linked to a purchase.
+func (r tagRepository) FindPurchaseTagIDs(ctx context.Context, purchaseID uuid.UUID) ([]uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT id_tag FROM purchase_tag WHERE id_purchase = $1 AND id_household = $2`, purchaseID, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find purchase tags: %w", err)
+	}
+
+	var tagIDs []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error trying scan purchase tag: %w", err)
+		}
+
+		tagIDs = append(tagIDs, id)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return tagIDs, nil
+}
+
+func (r tagRepository) DeletePurchaseTags(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM purchase_tag WHERE id_purchase = $1 AND id_household = $2`, purchaseID, householdID); err != nil {
+		return fmt.Errorf("error trying delete purchase tags: %w", err)
+	}
+
+	return nil
+}
+
+func uuidsToStrings(ids []uuid.UUID) []string {
+	values := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		values = append(values, id.String())
+	}
+
+	return values
+}
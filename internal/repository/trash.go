@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+// foreignKeyViolation is the Postgres error code raised when a purged row is
+// still referenced by another one.
+const foreignKeyViolation = "23503"
+
+var ErrReferenced = errors.New("the record is still referenced by other records")
+
+type TrashRepository interface {
+	FindAll(ctx context.Context) ([]models.TrashItem, error)
+	Restore(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) error
+	FindPurgeable(ctx context.Context, table string, before time.Time) ([]uuid.UUID, error)
+	Purge(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) error
+}
+
+type trashRepository struct {
+	db *sql.DB
+}
+
+func NewTrashRepository(db *sql.DB) *trashRepository {
+	return &trashRepository{db}
+}
+
+func (r *trashRepository) FindAll(ctx context.Context) ([]models.TrashItem, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT t.entity, t.id, t.description, t.deleted_at FROM (
+				SELECT 'persons' AS entity, id, "name" AS description, deleted_at
+				FROM person
+				WHERE deleted_at IS NOT NULL
+					AND id_household = $1
+				UNION ALL
+				SELECT 'creditCards', id, "owner" || ' ' || final_card_num, deleted_at
+				FROM credit_card
+				WHERE deleted_at IS NOT NULL
+					AND id_household = $1
+				UNION ALL
+				SELECT 'paymentTypes', id, "name", deleted_at
+				FROM payment_type
+				WHERE deleted_at IS NOT NULL
+					AND id_household = $1
+				UNION ALL
+				SELECT 'purchaseTypes', id, "name", deleted_at
+				FROM purchase_type
+				WHERE deleted_at IS NOT NULL
+					AND id_household = $1
+				UNION ALL
+				SELECT 'purchases', id, description, deleted_at
+				FROM purchase
+				WHERE deleted_at IS NOT NULL
+					AND id_household = $1
+			) t
+			ORDER BY t.deleted_at DESC`
+
+	rows, err := r.db.Query(query, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find trash: %w", err)
+	}
+
+	var items []models.TrashItem
+
+	for rows.Next() {
+		var item models.TrashItem
+		if err := rows.Scan(&item.Entity, &item.ID, &item.Description, &item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("error trying scan trash item: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return items, nil
+}
+
+// Restore takes a row out of the trash. The table must come from
+// models.TrashTables, it is never taken from the request.
+func (r *trashRepository) Restore(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND id_household = $2 AND deleted_at IS NOT NULL`, table)
+
+	result, err := tx.Exec(query, id, householdID)
+	if err != nil {
+		return fmt.Errorf("error trying restore %s: %w", table, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error trying restore %s: %w", table, err)
+	}
+
+	if affected == 0 {
+		return models.NotFound("deleted " + table)
+	}
+
+	return nil
+}
+
+// FindPurgeable returns the rows of the table trashed before the date.
+func (r *trashRepository) FindPurgeable(ctx context.Context, table string, before time.Time) ([]uuid.UUID, error) {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT id FROM %s WHERE deleted_at < $1 AND id_household = $2`, table)
+
+	rows, err := r.db.Query(query, before, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("error trying find %s to purge: %w", table, err)
+	}
+
+	var ids []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error trying scan %s to purge: %w", table, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("error trying close rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Purge removes a trashed row for good, purchases along with their
+// installments. It returns ErrReferenced when live records still point to it,
+// and for a purchase with refunds or journal entries: its refunds, shares and
+// tags would be deleted with it, and the entries of the append only journal
+// would be left pointing at nothing.
+func (r *trashRepository) Purge(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) error {
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if table == "purchase" {
+		query := `SELECT EXISTS (
+					SELECT 1 FROM purchase_refund WHERE id_purchase = $1 AND id_household = $2
+				) OR EXISTS (
+					SELECT 1 FROM journal_entry WHERE source_type = $3 AND source_id = $1
+				) OR EXISTS (
+					SELECT 1 
+					FROM journal_entry e
+					INNER JOIN installment i
+						ON e.source_id = i.id
+					WHERE e.source_type = $4
+						AND i.purchase_id = $1
+						AND i.id_household = $2
+				)`
+
+		var recorded bool
+		if err := tx.QueryRow(query, id, householdID, models.LedgerSourcePurchase, models.LedgerSourceInstallmentPayment).Scan(&recorded); err != nil {
+			return fmt.Errorf("error trying find the history of the purchase: %w", err)
+		}
+
+		if recorded {
+			return ErrReferenced
+		}
+
+		if _, err := tx.Exec(`DELETE FROM installment WHERE purchase_id = $1 AND id_household = $2`, id, householdID); err != nil {
+			return fmt.Errorf("error trying purge installments: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND id_household = $2 AND deleted_at IS NOT NULL`, table), id, householdID)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrReferenced
+	}
+
+	if err != nil {
+		return fmt.Errorf("error trying purge %s: %w", table, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/me/finance/internal/models"
+)
+
+func TestPurgeKeepsARefundedPurchase(t *testing.T) {
+	db := openTestDB(t)
+	home := newTestHousehold(t, db, "Home")
+
+	purchase, _ := newTestPurchase(home, t, db)
+
+	refunds := NewRefundRepository(db)
+
+	err := inTx(t, db, func(tx *sql.Tx) error {
+		refund := models.Refund{IDPurchase: purchase.ID, Amount: 50, Date: "2024-03-20", Mode: models.RefundModeCredit}
+		if _, err := refunds.Create(home, tx, refund); err != nil {
+			return err
+		}
+
+		return NewRepositoryPurchase(db).Delete(home, tx, purchase.ID, 1)
+	})
+	if err != nil {
+		t.Fatalf("refunding and trashing the purchase: %v", err)
+	}
+
+	err = inTx(t, db, func(tx *sql.Tx) error {
+		return NewTrashRepository(db).Purge(home, tx, "purchase", purchase.ID)
+	})
+	if !errors.Is(err, ErrReferenced) {
+		t.Fatalf("got %v, want the purchase kept", err)
+	}
+
+	history, err := refunds.FindByPurchaseID(home, purchase.ID)
+	if err != nil {
+		t.Fatalf("finding the refunds: %v", err)
+	}
+
+	if len(history) != 1 {
+		t.Errorf("got %d refunds, want the refund kept", len(history))
+	}
+}
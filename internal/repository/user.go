@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/me/finance/internal/models"
+)
+
+// uniqueViolation is the Postgres error code raised when the email of a new
+// user is already taken.
+const uniqueViolation = "23505"
+
+type UserRepository interface {
+	Create(u models.User) (uuid.UUID, error)
+	FindByID(id uuid.UUID) (models.User, error)
+	FindByEmail(email string) (models.User, error)
+	Count() (int, error)
+	CreateSession(s models.Session) error
+	FindSession(id uuid.UUID) (models.Session, error)
+	RotateSession(id, refreshID, newRefreshID uuid.UUID, expiresAt time.Time) (bool, error)
+	RevokeSession(id uuid.UUID) error
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *userRepository {
+	return &userRepository{db}
+}
+
+func (r *userRepository) Create(u models.User) (uuid.UUID, error) {
+	query := `INSERT INTO app_user (id, email, name, password_hash, admin) VALUES ($1, $2, $3, $4, $5)`
+
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying create uuid: %w", err)
+	}
+
+	_, err = r.db.Exec(query, id, u.Email, u.Name, u.PasswordHash, u.Admin)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return uuid.Nil, models.Conflict("a user with the email %s already exists", u.Email)
+	}
+
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error trying insert user: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *userRepository) FindByID(id uuid.UUID) (models.User, error) {
+	query := `SELECT id, email, name, password_hash, admin, created_at FROM app_user WHERE id = $1`
+
+	return r.findOne(query, id)
+}
+
+func (r *userRepository) FindByEmail(email string) (models.User, error) {
+	query := `SELECT id, email, name, password_hash, admin, created_at FROM app_user WHERE lower(email) = lower($1)`
+
+	return r.findOne(query, email)
+}
+
+func (r *userRepository) findOne(query string, arg any) (models.User, error) {
+	var u models.User
+
+	err := r.db.QueryRow(query, arg).Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Admin, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.User{}, models.NotFound("user")
+	}
+
+	if err != nil {
+		return models.User{}, fmt.Errorf("error trying find user: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *userRepository) Count() (int, error) {
+	var count int
+
+	if err := r.db.QueryRow(`SELECT count(*) FROM app_user`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error trying count users: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *userRepository) CreateSession(s models.Session) error {
+	query := `INSERT INTO session (id, id_user, refresh_id, expires_at) VALUES ($1, $2, $3, $4)`
+
+	if _, err := r.db.Exec(query, s.ID, s.IDUser, s.RefreshID, s.ExpiresAt); err != nil {
+		return fmt.Errorf("error trying insert session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userRepository) FindSession(id uuid.UUID) (models.Session, error) {
+	query := `SELECT id, id_user, refresh_id, expires_at, revoked_at FROM session WHERE id = $1`
+
+	var s models.Session
+
+	err := r.db.QueryRow(query, id).Scan(&s.ID, &s.IDUser, &s.RefreshID, &s.ExpiresAt, &s.RevokedAt)
+	if err == sql.ErrNoRows {
+		return models.Session{}, models.NotFound("session")
+	}
+
+	if err != nil {
+		return models.Session{}, fmt.Errorf("error trying find session: %w", err)
+	}
+
+	return s, nil
+}
+
+// RotateSession replaces the refresh token of an active session, only when
+// refreshID is still the current one. It reports whether it was replaced.
+func (r *userRepository) RotateSession(id, refreshID, newRefreshID uuid.UUID, expiresAt time.Time) (bool, error) {
+	query := `UPDATE session SET refresh_id = $1, expires_at = $2
+			WHERE id = $3 AND refresh_id = $4 AND revoked_at IS NULL AND expires_at > now()`
+
+	result, err := r.db.Exec(query, newRefreshID, expiresAt, id, refreshID)
+	if err != nil {
+		return false, fmt.Errorf("error trying rotate session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error trying rotate session: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+func (r *userRepository) RevokeSession(id uuid.UUID) error {
+	query := `UPDATE session SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("error trying revoke session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+// checkVersion inspects the result of an update or delete guarded by the
+// record version. When no row matched it returns models.ErrVersionConflict if
+// the record is still there, and a not found error otherwise.
+func checkVersion(ctx context.Context, tx *sql.Tx, result sql.Result, table, name string, id uuid.UUID) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error trying check %s version: %w", name, err)
+	}
+
+	if affected > 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`SELECT EXISTS (
+				SELECT 1 FROM %s t WHERE t.id = $1 AND t.id_household = $2 AND to_jsonb(t) ->> 'deleted_at' IS NULL
+			)`, table)
+
+	householdID, err := models.HouseholdFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := tx.QueryRow(query, id, householdID).Scan(&exists); err != nil {
+		return fmt.Errorf("error trying check %s version: %w", name, err)
+	}
+
+	if exists {
+		return models.ErrVersionConflict
+	}
+
+	return models.NotFound(name)
+}
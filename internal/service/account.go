@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type AccountService interface {
+	CreateAccount(ctx context.Context, account models.Account) (models.Account, error)
+	UpdateAccount(ctx context.Context, account models.Account) error
+	DeleteAccount(ctx context.Context, id uuid.UUID, version int) error
+	FindAccountByID(ctx context.Context, id uuid.UUID) (models.Account, error)
+	FindAllAccounts(ctx context.Context) ([]models.Account, error)
+	CreateTransfer(ctx context.Context, transfer models.AccountTransfer) (models.AccountTransfer, error)
+	PayInvoice(ctx context.Context, payment models.InvoicePayment) (models.InvoicePayment, error)
+	FindAccountStatement(ctx context.Context, id uuid.UUID, date string) (models.AccountStatement, error)
+}
+
+type Account struct {
+	accountRepository repository.AccountRepository
+	ledgerRepository  repository.LedgerRepository
+	auditRepository   repository.AuditRepository
+}
+
+func NewAccountService(r repository.AccountRepository, l repository.LedgerRepository, a repository.AuditRepository) AccountService {
+	return &Account{
+		accountRepository: r,
+		ledgerRepository:  l,
+		auditRepository:   a,
+	}
+}
+
+func (a *Account) CreateAccount(ctx context.Context, account models.Account) (models.Account, error) {
+	id, err := audited(ctx, a.auditRepository, "account", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return a.accountRepository.Create(ctx, tx, account)
+	})
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	return a.FindAccountByID(ctx, id)
+}
+
+func (a *Account) UpdateAccount(ctx context.Context, account models.Account) error {
+	_, err := audited(ctx, a.auditRepository, "account", models.AuditUpdate, account.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		return account.ID, a.accountRepository.Update(ctx, tx, account)
+	})
+
+	return err
+}
+
+func (a *Account) DeleteAccount(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, a.auditRepository, "account", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		return id, a.accountRepository.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+func (a *Account) FindAccountByID(ctx context.Context, id uuid.UUID) (models.Account, error) {
+	account, err := a.accountRepository.FindByID(ctx, id)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	return account, nil
+}
+
+func (a *Account) FindAllAccounts(ctx context.Context) ([]models.Account, error) {
+	accounts, err := a.accountRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (a *Account) CreateTransfer(ctx context.Context, transfer models.AccountTransfer) (models.AccountTransfer, error) {
+	id, err := audited(ctx, a.auditRepository, "account_transfer", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return a.accountRepository.CreateTransfer(ctx, tx, transfer)
+	})
+	if err != nil {
+		return models.AccountTransfer{}, err
+	}
+
+	transfer.ID = id
+
+	return transfer, nil
+}
+
+// PayInvoice pays what is open in the invoice of the card for the month. The
+// installments are paid as a whole, so an amount, when given, must be the
+// invoice total, and an invoice already paid cannot be paid again.
+func (a *Account) PayInvoice(ctx context.Context, payment models.InvoicePayment) (models.InvoicePayment, error) {
+	tx, err := a.ledgerRepository.BeginTransaction()
+	if err != nil {
+		return models.InvoicePayment{}, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	installments, err := a.accountRepository.FindInvoiceInstallments(ctx, tx, payment.IDCreditCard, payment.Month)
+	if err != nil {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, err
+	}
+
+	paid, err := a.accountRepository.InvoicePaid(ctx, tx, payment.IDCreditCard, payment.Month)
+	if err != nil {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, err
+	}
+
+	if paid {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, models.Conflict("the invoice of %s was already paid", payment.Month)
+	}
+
+	var (
+		total float64
+		ids   = make([]uuid.UUID, len(installments))
+	)
+
+	for i, installment := range installments {
+		total += installment.Value
+		ids[i] = installment.ID
+	}
+
+	if total = roundCents(total); total <= 0 {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, models.Conflict("there is nothing to pay in this invoice")
+	}
+
+	if payment.Amount != 0 && roundCents(payment.Amount) != total {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, models.InvalidField("amount", "the amount must be the invoice total of %.2f", total)
+	}
+
+	payment.Amount = total
+
+	before := make([]json.RawMessage, len(ids))
+	for i, id := range ids {
+		if before[i], err = a.auditRepository.Snapshot(ctx, tx, "installment", id); err != nil {
+			a.ledgerRepository.Rollback(tx)
+
+			return models.InvoicePayment{}, err
+		}
+	}
+
+	payment, err = a.accountRepository.CreateInvoicePayment(ctx, tx, payment, ids)
+	if err != nil {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, err
+	}
+
+	if err := NewLedgerService(a.ledgerRepository).RecordInvoicePayment(ctx, tx, payment); err != nil {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, err
+	}
+
+	if err := recordAudit(ctx, a.auditRepository, tx, "invoice_payment", models.AuditPay, payment.ID, nil); err != nil {
+		a.ledgerRepository.Rollback(tx)
+
+		return models.InvoicePayment{}, err
+	}
+
+	for i, id := range ids {
+		if err := recordAudit(ctx, a.auditRepository, tx, "installment", models.AuditPay, id, before[i]); err != nil {
+			a.ledgerRepository.Rollback(tx)
+
+			return models.InvoicePayment{}, err
+		}
+	}
+
+	if err := a.ledgerRepository.Commit(tx); err != nil {
+		return models.InvoicePayment{}, err
+	}
+
+	return payment, nil
+}
+
+// FindAccountStatement returns the movements of the account up to the date
+// (today when empty) with the running balance after each one.
+func (a *Account) FindAccountStatement(ctx context.Context, id uuid.UUID, date string) (models.AccountStatement, error) {
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	account, err := a.accountRepository.FindByID(ctx, id)
+	if err != nil {
+		return models.AccountStatement{}, err
+	}
+
+	entries, err := a.accountRepository.FindEntries(ctx, id, date)
+	if err != nil {
+		return models.AccountStatement{}, fmt.Errorf("error finding account entries: %w", err)
+	}
+
+	statement := models.AccountStatement{Account: account}
+
+	for _, entry := range entries {
+		statement.Balance = roundCents(statement.Balance + entry.Amount)
+		entry.Balance = statement.Balance
+		statement.Entries = append(statement.Entries, entry)
+	}
+
+	return statement, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+func newFakeAccountService() (*Account, *fakeAccountRepository, *fakeLedgerRepository, *fakeAuditRepository) {
+	accounts := &fakeAccountRepository{
+		installments: []models.Installment{
+			{ID: uuid.New(), Number: 3, Value: 100, Month: "2024-04-01"},
+			{ID: uuid.New(), Number: 2, Value: 50, Month: "2024-04-01"},
+		},
+	}
+	ledger, audit := &fakeLedgerRepository{}, &fakeAuditRepository{}
+
+	return &Account{accountRepository: accounts, ledgerRepository: ledger, auditRepository: audit}, accounts, ledger, audit
+}
+
+var invoiceCard = uuid.New()
+
+func invoicePayment(amount float64) models.InvoicePayment {
+	return models.InvoicePayment{
+		IDCreditCard: invoiceCard,
+		IDAccount:    uuid.New(),
+		Month:        "2024-04",
+		Amount:       amount,
+		Date:         "2024-04-10",
+	}
+}
+
+func TestPayInvoicePaysTheWholeInvoice(t *testing.T) {
+	a, accounts, ledger, audit := newFakeAccountService()
+
+	payment, err := a.PayInvoice(testContext(), invoicePayment(0))
+	if err != nil {
+		t.Fatalf("paying the invoice: %v", err)
+	}
+
+	if payment.Amount != 150 {
+		t.Errorf("paid %.2f, want the invoice total of 150", payment.Amount)
+	}
+
+	for _, installment := range accounts.installments {
+		if !installment.Paid {
+			t.Errorf("installment %s was left unpaid", installment.ID)
+		}
+	}
+
+	if len(ledger.entries) != 1 || ledger.entries[0].Postings[0].Amount != 150 {
+		t.Errorf("got ledger entries %+v, want one of 150", ledger.entries)
+	}
+
+	paid := map[string]int{}
+	for _, event := range audit.events {
+		if event.Action == models.AuditPay {
+			paid[event.Entity]++
+		}
+	}
+
+	if paid["invoice_payment"] != 1 || paid["installment"] != 2 {
+		t.Errorf("got pay events %v, want the payment and each installment", paid)
+	}
+}
+
+func TestPayInvoiceRejectsAPartialAmount(t *testing.T) {
+	a, accounts, ledger, _ := newFakeAccountService()
+
+	_, err := a.PayInvoice(testContext(), invoicePayment(60))
+
+	var invalid *models.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+
+	if len(accounts.payments) != 0 || len(ledger.entries) != 0 || accounts.installments[0].Paid {
+		t.Errorf("a partial payment was recorded")
+	}
+}
+
+func TestPayInvoiceRefusesAPaidInvoice(t *testing.T) {
+	a, accounts, ledger, _ := newFakeAccountService()
+
+	if _, err := a.PayInvoice(testContext(), invoicePayment(150)); err != nil {
+		t.Fatalf("paying the invoice: %v", err)
+	}
+
+	// A purchase billed later in the month leaves something open again.
+	accounts.installments = append(accounts.installments, models.Installment{ID: uuid.New(), Number: 1, Value: 30, Month: "2024-04-01"})
+
+	_, err := a.PayInvoice(testContext(), invoicePayment(30))
+
+	var conflict *models.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("got %v, want a conflict", err)
+	}
+
+	if len(accounts.payments) != 1 || len(ledger.entries) != 1 {
+		t.Errorf("the invoice was paid twice")
+	}
+}
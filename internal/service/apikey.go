@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear to tell the keys of
+// a user apart: the fixed prefix and the first characters of the secret.
+const apiKeyPrefixLength = len(models.APIKeyPrefix) + 8
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, request models.APIKeyRequest) (models.APIKeyCreated, error)
+	FindAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+type APIKey struct {
+	apiKeyRepository repository.APIKeyRepository
+}
+
+func NewAPIKeyService(r repository.APIKeyRepository) APIKeyService {
+	return &APIKey{apiKeyRepository: r}
+}
+
+// CreateAPIKey creates a key for the user of the request. The key is only
+// returned here, the database keeps its hash.
+func (a *APIKey) CreateAPIKey(ctx context.Context, request models.APIKeyRequest) (models.APIKeyCreated, error) {
+	principal, err := a.manager(ctx)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	if err := request.Validate(); err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIKeyCreated{}, fmt.Errorf("error generating api key: %w", err)
+	}
+
+	key := models.APIKeyPrefix + hex.EncodeToString(secret)
+
+	apiKey := models.APIKey{
+		IDUser:  principal.User.ID,
+		Name:    request.Name,
+		Prefix:  key[:apiKeyPrefixLength],
+		KeyHash: hashAPIKey(key),
+		Scopes:  request.Scopes,
+	}
+
+	if request.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, request.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	id, err := a.apiKeyRepository.Create(apiKey)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	apiKey, err = a.apiKeyRepository.FindByID(id)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	return models.APIKeyCreated{APIKey: apiKey, Key: key}, nil
+}
+
+func (a *APIKey) FindAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	principal, err := a.manager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.apiKeyRepository.FindByUser(principal.User.ID)
+}
+
+func (a *APIKey) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	principal, err := a.manager(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.apiKeyRepository.Revoke(id, principal.User.ID)
+}
+
+// manager returns the principal of the request when it may manage API keys:
+// a session, or a key with the admin scope, so a leaked read-only key cannot
+// mint itself a stronger one.
+func (a *APIKey) manager(ctx context.Context) (models.Principal, error) {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return models.Principal{}, models.Unauthorized("authentication required")
+	}
+
+	if principal.APIKey != nil && !slices.Contains(principal.APIKey.Scopes, models.ScopeAdmin) {
+		return models.Principal{}, models.Forbidden("only sessions and API keys with the admin scope can manage API keys")
+	}
+
+	return principal, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type AuditService interface {
+	FindAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type Audit struct {
+	auditRepository repository.AuditRepository
+}
+
+func NewAuditService(r repository.AuditRepository) AuditService {
+	return &Audit{
+		auditRepository: r,
+	}
+}
+
+func (a *Audit) FindAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	events, err := a.auditRepository.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// audited runs change in a transaction and records it in the audit log before
+// committing. The id is the row being changed, or uuid.Nil when change creates
+// it and returns its id.
+func audited(ctx context.Context, r repository.AuditRepository, table, action string, id uuid.UUID, change func(tx *sql.Tx) (uuid.UUID, error)) (uuid.UUID, error) {
+	tx, err := r.BeginTransaction()
+	if err != nil {
+		return uuid.Nil, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	var before json.RawMessage
+	if id != uuid.Nil {
+		if before, err = r.Snapshot(ctx, tx, table, id); err != nil {
+			r.Rollback(tx)
+
+			return uuid.Nil, err
+		}
+	}
+
+	changedID, err := change(tx)
+	if err != nil {
+		r.Rollback(tx)
+
+		return uuid.Nil, err
+	}
+
+	if changedID != uuid.Nil {
+		id = changedID
+	}
+
+	if err := recordAudit(ctx, r, tx, table, action, id, before); err != nil {
+		r.Rollback(tx)
+
+		return uuid.Nil, err
+	}
+
+	if err := r.Commit(tx); err != nil {
+		return uuid.Nil, fmt.Errorf("error on commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// recordAudit snapshots the row as it is now in the transaction and records the
+// change from before. Services that manage their own transaction take the
+// before snapshot themselves and call it prior to committing.
+func recordAudit(ctx context.Context, r repository.AuditRepository, tx *sql.Tx, table, action string, id uuid.UUID, before json.RawMessage) error {
+	after, err := r.Snapshot(ctx, tx, table, id)
+	if err != nil {
+		return err
+	}
+
+	diff, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	metadata := models.AuditMetadataFrom(ctx)
+
+	return r.Create(ctx, tx, models.AuditEvent{
+		Entity:    table,
+		EntityID:  id,
+		Action:    action,
+		Actor:     metadata.Actor,
+		RequestID: metadata.RequestID,
+		Before:    before,
+		After:     after,
+		Diff:      diff,
+	})
+}
+
+// auditDiff returns the fields whose value differs between the snapshots, each
+// with its value before and after.
+func auditDiff(before, after json.RawMessage) (json.RawMessage, error) {
+	var previous, current map[string]any
+
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &previous); err != nil {
+			return nil, fmt.Errorf("error decoding audit snapshot: %w", err)
+		}
+	}
+
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &current); err != nil {
+			return nil, fmt.Errorf("error decoding audit snapshot: %w", err)
+		}
+	}
+
+	type change struct {
+		Before any `json:"before"`
+		After  any `json:"after"`
+	}
+
+	diff := map[string]change{}
+
+	for field, value := range previous {
+		if !reflect.DeepEqual(value, current[field]) {
+			diff[field] = change{Before: value, After: current[field]}
+		}
+	}
+
+	for field, value := range current {
+		if _, ok := previous[field]; !ok {
+			diff[field] = change{After: value}
+		}
+	}
+
+	return json.Marshal(diff)
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/config"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const tokenIssuer = "finance"
+
+// dummyHash is compared against when the email of a login is unknown, so a
+// wrong email takes as long as a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+
+type AuthService interface {
+	Register(ctx context.Context, request models.RegisterRequest) (models.User, error)
+	BootstrapAdmin(request models.RegisterRequest) (models.User, error)
+	Login(request models.LoginRequest) (models.TokenResponse, error)
+	Refresh(refreshToken string) (models.TokenResponse, error)
+	Logout(ctx context.Context) error
+	Authenticate(accessToken string) (models.Principal, error)
+}
+
+type Auth struct {
+	userRepository   repository.UserRepository
+	apiKeyRepository repository.APIKeyRepository
+	config           config.AuthConfig
+}
+
+func NewAuthService(r repository.UserRepository, k repository.APIKeyRepository, cfg config.AuthConfig) AuthService {
+	return &Auth{
+		userRepository:   r,
+		apiKeyRepository: k,
+		config:           cfg,
+	}
+}
+
+// tokenClaims are the claims of both tokens of a session: Type tells an access
+// token from a refresh token and SessionID ties them to the session they
+// belong to, so revoking the session revokes both.
+type tokenClaims struct {
+	Type      string `json:"typ"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// Register creates a user. Unless registration is open, only admins can
+// register users, and only admins can ever create other admins.
+func (a *Auth) Register(ctx context.Context, request models.RegisterRequest) (models.User, error) {
+	principal, authenticated := models.PrincipalFrom(ctx)
+	admin := authenticated && principal.User.Admin
+
+	if !admin && !a.config.AllowRegistration {
+		return models.User{}, models.Forbidden("only admins can register users")
+	}
+
+	if request.Admin && !admin {
+		return models.User{}, models.Forbidden("only admins can register admins")
+	}
+
+	return a.createUser(request)
+}
+
+// BootstrapAdmin creates the first admin of a new installation. It is only
+// reachable from the command line and refuses to run once any user exists.
+func (a *Auth) BootstrapAdmin(request models.RegisterRequest) (models.User, error) {
+	count, err := a.userRepository.Count()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if count > 0 {
+		return models.User{}, models.Conflict("there are users already, the admin can only be bootstrapped on the first run")
+	}
+
+	request.Admin = true
+
+	return a.createUser(request)
+}
+
+func (a *Auth) createUser(request models.RegisterRequest) (models.User, error) {
+	if err := request.Validate(); err != nil {
+		return models.User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	user := models.User{
+		Email:        request.Email,
+		Name:         request.Name,
+		PasswordHash: string(hash),
+		Admin:        request.Admin,
+	}
+
+	id, err := a.userRepository.Create(user)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return a.userRepository.FindByID(id)
+}
+
+func (a *Auth) Login(request models.LoginRequest) (models.TokenResponse, error) {
+	if err := request.Validate(); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	user, err := a.userRepository.FindByEmail(request.Email)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return models.TokenResponse{}, err
+	}
+
+	hash := []byte(user.PasswordHash)
+	if user.ID == uuid.Nil {
+		hash = dummyHash
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(request.Password)); err != nil || user.ID == uuid.Nil {
+		return models.TokenResponse{}, models.Unauthorized("invalid email or password")
+	}
+
+	session := models.Session{
+		ID:        uuid.New(),
+		IDUser:    user.ID,
+		RefreshID: uuid.New(),
+		ExpiresAt: time.Now().Add(a.config.RefreshTTL),
+	}
+
+	if err := a.userRepository.CreateSession(session); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return a.issueTokens(session)
+}
+
+// Refresh trades a refresh token for a new pair of tokens. Each refresh token
+// can be used once: using an old one again means it leaked, and the whole
+// session is revoked.
+func (a *Auth) Refresh(refreshToken string) (models.TokenResponse, error) {
+	claims, err := a.parse(refreshToken, models.TokenTypeRefresh)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	sessionID, err1 := uuid.Parse(claims.SessionID)
+	refreshID, err2 := uuid.Parse(claims.ID)
+	if err1 != nil || err2 != nil {
+		return models.TokenResponse{}, models.Unauthorized("the refresh token is invalid")
+	}
+
+	session := models.Session{
+		ID:        sessionID,
+		RefreshID: uuid.New(),
+		ExpiresAt: time.Now().Add(a.config.RefreshTTL),
+	}
+
+	rotated, err := a.userRepository.RotateSession(sessionID, refreshID, session.RefreshID, session.ExpiresAt)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	if !rotated {
+		if err := a.userRepository.RevokeSession(sessionID); err != nil {
+			return models.TokenResponse{}, err
+		}
+
+		return models.TokenResponse{}, models.Unauthorized("the refresh token was already used or the session has ended")
+	}
+
+	if session.IDUser, err = uuid.Parse(claims.Subject); err != nil {
+		return models.TokenResponse{}, models.Unauthorized("the refresh token is invalid")
+	}
+
+	return a.issueTokens(session)
+}
+
+// Logout revokes the session of the request, which invalidates its access and
+// refresh tokens at once.
+func (a *Auth) Logout(ctx context.Context) error {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return models.Unauthorized("authentication required")
+	}
+
+	if principal.APIKey != nil {
+		return models.Invalid("an API key has no session to log out of, revoke the key instead")
+	}
+
+	return a.userRepository.RevokeSession(principal.SessionID)
+}
+
+// Authenticate checks an access token and that its session is still active,
+// or an API key and that it is neither expired nor revoked, returning who it
+// belongs to.
+func (a *Auth) Authenticate(accessToken string) (models.Principal, error) {
+	if strings.HasPrefix(accessToken, models.APIKeyPrefix) {
+		return a.authenticateAPIKey(accessToken)
+	}
+
+	claims, err := a.parse(accessToken, models.TokenTypeAccess)
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return models.Principal{}, models.Unauthorized("the access token is invalid")
+	}
+
+	session, err := a.userRepository.FindSession(sessionID)
+	if errors.Is(err, models.ErrNotFound) || err == nil && !session.Active(time.Now()) {
+		return models.Principal{}, models.Unauthorized("the session has ended, log in again")
+	}
+
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	user, err := a.userRepository.FindByID(session.IDUser)
+	if errors.Is(err, models.ErrNotFound) {
+		return models.Principal{}, models.Unauthorized("the user no longer exists")
+	}
+
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	return models.Principal{User: user, SessionID: session.ID}, nil
+}
+
+func (a *Auth) authenticateAPIKey(key string) (models.Principal, error) {
+	apiKey, err := a.apiKeyRepository.FindByHash(hashAPIKey(key))
+	if errors.Is(err, models.ErrNotFound) || err == nil && !apiKey.Active(time.Now()) {
+		return models.Principal{}, models.Unauthorized("the API key is invalid, expired or revoked")
+	}
+
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	user, err := a.userRepository.FindByID(apiKey.IDUser)
+	if errors.Is(err, models.ErrNotFound) {
+		return models.Principal{}, models.Unauthorized("the user no longer exists")
+	}
+
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	if err := a.apiKeyRepository.Touch(apiKey.ID); err != nil {
+		return models.Principal{}, err
+	}
+
+	return models.Principal{User: user, APIKey: &apiKey}, nil
+}
+
+func (a *Auth) issueTokens(session models.Session) (models.TokenResponse, error) {
+	now := time.Now()
+
+	access, err := a.sign(models.TokenTypeAccess, session, uuid.New(), now.Add(a.config.AccessTTL))
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	refresh, err := a.sign(models.TokenTypeRefresh, session, session.RefreshID, session.ExpiresAt)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.config.AccessTTL.Seconds()),
+	}, nil
+}
+
+func (a *Auth) sign(tokenType string, session models.Session, id uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := tokenClaims{
+		Type:      tokenType,
+		SessionID: session.ID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id.String(),
+			Issuer:    tokenIssuer,
+			Subject:   session.IDUser.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.config.Secret)
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (a *Auth) parse(token, tokenType string) (tokenClaims, error) {
+	var claims tokenClaims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return a.config.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil || claims.Type != tokenType {
+		return tokenClaims{}, models.Unauthorized("the %s token is invalid or expired", tokenType)
+	}
+
+	return claims, nil
+}
@@ -1,55 +1,105 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/repository"
 )
 
 type CreditCardService interface {
-	CreateCreditCard(cc models.CreditCard) error
-	UpdateCreditCard(cc models.CreditCard) error
-	DeleteCreditCard(id uuid.UUID) error
-	FindCreditCardByID(id uuid.UUID) (models.CreditCard, error)
-	FindAllCreditCards() ([]models.CreditCard, error)
+	CreateCreditCard(ctx context.Context, cc models.CreditCard) (models.CreditCard, error)
+	UpdateCreditCard(ctx context.Context, cc models.CreditCard) error
+	PatchCreditCard(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.CreditCard, error)
+	DeleteCreditCard(ctx context.Context, id uuid.UUID, version int) error
+	ReassignCreditCard(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error)
+	FindCreditCardByID(ctx context.Context, id uuid.UUID) (models.CreditCard, error)
+	FindAllCreditCards(ctx context.Context) ([]models.CreditCard, error)
 }
 
 type CreditCard struct {
 	creditCardRepository repository.CreditCardRepository
+	referenceRepository  repository.ReferenceRepository
+	ledgerRepository     repository.LedgerRepository
+	auditRepository      repository.AuditRepository
 }
 
-func NewCreditCardService(r repository.CreditCardRepository) CreditCardService {
+func NewCreditCardService(r repository.CreditCardRepository, ref repository.ReferenceRepository, l repository.LedgerRepository, a repository.AuditRepository) CreditCardService {
 	return &CreditCard{
 		creditCardRepository: r,
+		referenceRepository:  ref,
+		ledgerRepository:     l,
+		auditRepository:      a,
 	}
 }
 
-func (c *CreditCard) CreateCreditCard(cc models.CreditCard) error {
-	if err := c.creditCardRepository.Create(cc); err != nil {
-		return err
+func (c *CreditCard) CreateCreditCard(ctx context.Context, cc models.CreditCard) (models.CreditCard, error) {
+	id, err := audited(ctx, c.auditRepository, "credit_card", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return c.creditCardRepository.Create(ctx, tx, cc)
+	})
+	if err != nil {
+		return models.CreditCard{}, err
 	}
 
-	return nil
+	return c.FindCreditCardByID(ctx, id)
 }
 
-func (c *CreditCard) UpdateCreditCard(cc models.CreditCard) error {
-	if err := c.creditCardRepository.Update(cc); err != nil {
-		return err
-	}
+func (c *CreditCard) UpdateCreditCard(ctx context.Context, cc models.CreditCard) error {
+	_, err := audited(ctx, c.auditRepository, "credit_card", models.AuditUpdate, cc.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		return cc.ID, c.creditCardRepository.Update(ctx, tx, cc)
+	})
 
-	return nil
+	return err
 }
 
-func (c *CreditCard) DeleteCreditCard(id uuid.UUID) error {
-	if err := c.creditCardRepository.Delete(id); err != nil {
-		return err
+func (c *CreditCard) PatchCreditCard(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.CreditCard, error) {
+	current, err := c.FindCreditCardByID(ctx, id)
+	if err != nil {
+		return models.CreditCard{}, err
+	}
+
+	cc, err := patched(current, patch)
+	if err != nil {
+		return models.CreditCard{}, err
 	}
 
-	return nil
+	cc.ID, cc.Version = id, version
+
+	if err := cc.Validate(false); err != nil {
+		return models.CreditCard{}, err
+	}
+
+	if err := c.UpdateCreditCard(ctx, cc); err != nil {
+		return models.CreditCard{}, err
+	}
+
+	return c.FindCreditCardByID(ctx, id)
+}
+
+func (c *CreditCard) DeleteCreditCard(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, c.auditRepository, "credit_card", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		if err := checkUnused(ctx, tx, c.referenceRepository, "credit_card", "credit card", id); err != nil {
+			return id, err
+		}
+
+		return id, c.creditCardRepository.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+// ReassignCreditCard deletes the credit card after moving everything that
+// references it to another one, which is also how duplicates are merged.
+func (c *CreditCard) ReassignCreditCard(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error) {
+	return reassignAndDelete(ctx, c.auditRepository, c.referenceRepository, c.ledgerRepository, "credit_card", id, to, models.LedgerCreditCardAccount, func(tx *sql.Tx) error {
+		return c.creditCardRepository.Delete(ctx, tx, id, version)
+	})
 }
 
-func (c *CreditCard) FindCreditCardByID(id uuid.UUID) (models.CreditCard, error) {
-	cc, err := c.creditCardRepository.FindByID(id)
+func (c *CreditCard) FindCreditCardByID(ctx context.Context, id uuid.UUID) (models.CreditCard, error) {
+	cc, err := c.creditCardRepository.FindByID(ctx, id)
 	if err != nil {
 		return models.CreditCard{}, err
 	}
@@ -57,8 +107,8 @@ func (c *CreditCard) FindCreditCardByID(id uuid.UUID) (models.CreditCard, error)
 	return cc, nil
 }
 
-func (c *CreditCard) FindAllCreditCards() ([]models.CreditCard, error) {
-	cc, err := c.creditCardRepository.FindAll()
+func (c *CreditCard) FindAllCreditCards(ctx context.Context) ([]models.CreditCard, error) {
+	cc, err := c.creditCardRepository.FindAll(ctx)
 	if err != nil {
 		return []models.CreditCard{}, err
 	}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+const exportCommodity = "BRL"
+
+type ExportService interface {
+	Export(ctx context.Context, format, from, to string) (string, error)
+}
+
+type Export struct {
+	exportRepository       repository.ExportRepository
+	purchaseTypeRepository repository.RepositoryPurchaseType
+}
+
+func NewExportService(r repository.ExportRepository, pt repository.RepositoryPurchaseType) ExportService {
+	return &Export{
+		exportRepository:       r,
+		purchaseTypeRepository: pt,
+	}
+}
+
+// Export renders purchases, refunds and payments between the dates as a plain-text
+// accounting journal. Transactions carry the ID of the record they come from
+// and are sorted by date and ID, so exporting the same data twice gives the
+// same output.
+func (e *Export) Export(ctx context.Context, format, from, to string) (string, error) {
+	if !models.ValidateExportFormat(format) {
+		return "", models.InvalidField("format", "the format must be ledger, hledger or beancount")
+	}
+
+	purchaseTypes, err := e.purchaseTypeRepository.FindAll(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	purchases, err := e.exportRepository.FindPurchases(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	refunds, err := e.exportRepository.FindRefunds(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	payments, err := e.exportRepository.FindPayments(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	transactions := exportTransactions(purchaseTypeAccounts(purchaseTypes), purchases, refunds, payments)
+
+	if format == models.ExportFormatBeancount {
+		return renderBeancount(transactions), nil
+	}
+
+	return renderLedger(transactions), nil
+}
+
+// purchaseTypeAccounts maps every purchase type to its expense account, using
+// the parent chain so subcategories become sub-accounts.
+func purchaseTypeAccounts(purchaseTypes []models.PurchaseType) map[uuid.UUID]string {
+	byID := map[uuid.UUID]models.PurchaseType{}
+	for _, pt := range purchaseTypes {
+		byID[pt.ID] = pt
+	}
+
+	accounts := map[uuid.UUID]string{}
+
+	for _, pt := range purchaseTypes {
+		var (
+			path    []string
+			visited = map[uuid.UUID]bool{}
+			current = pt
+		)
+
+		for {
+			path = append([]string{accountComponent(current.Name)}, path...)
+			visited[current.ID] = true
+
+			parent, ok := byID[current.IDParent.UUID]
+			if !current.IDParent.Valid || !ok || visited[parent.ID] {
+				break
+			}
+
+			current = parent
+		}
+
+		accounts[pt.ID] = "Expenses:" + strings.Join(path, ":")
+	}
+
+	return accounts
+}
+
+func exportTransactions(expenseAccounts map[uuid.UUID]string, purchases []models.ExportPurchase, refunds []models.ExportRefund, payments []models.ExportPayment) []models.ExportTransaction {
+	var transactions []models.ExportTransaction
+
+	for _, p := range purchases {
+		t := models.ExportTransaction{ID: p.ID, Date: p.Date, Description: exportDescription(p)}
+		t.Postings = purchasePostings(expenseAccounts, p, p.Amount)
+
+		transactions = append(transactions, t)
+	}
+
+	// A refund reverses the refunded part of the expense, split between the
+	// persons as the purchase was.
+	for _, r := range refunds {
+		t := models.ExportTransaction{ID: r.ID, Date: r.Date, Description: "Estorno " + exportDescription(r.Purchase)}
+
+		for _, posting := range purchasePostings(expenseAccounts, r.Purchase, r.Amount) {
+			t.Postings = append(t.Postings, models.Posting{Account: posting.Account, Amount: -posting.Amount})
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	for _, p := range payments {
+		source := "Assets:Cash"
+		if p.Account != "" {
+			source = "Assets:" + accountComponent(p.Account)
+		}
+
+		transactions = append(transactions, models.ExportTransaction{
+			ID:          p.ID,
+			Date:        p.Date,
+			Description: p.Description,
+			Postings: []models.Posting{
+				{Account: "Liabilities:CreditCard:" + accountComponent(p.CreditCard), Amount: p.Amount},
+				{Account: source, Amount: -p.Amount},
+			},
+		})
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if transactions[i].Date != transactions[j].Date {
+			return transactions[i].Date < transactions[j].Date
+		}
+
+		return transactions[i].ID.String() < transactions[j].ID.String()
+	})
+
+	return transactions
+}
+
+func exportDescription(p models.ExportPurchase) string {
+	if p.Place != "" {
+		return fmt.Sprintf("%s - %s", p.Place, p.Description)
+	}
+
+	return p.Description
+}
+
+// purchasePostings charges amount of the purchase to the expense account of
+// each person it is split with and credits it to the card or account it was
+// paid with. An amount other than the purchase amount is split in the same
+// proportions as the shares.
+func purchasePostings(expenseAccounts map[uuid.UUID]string, p models.ExportPurchase, amount float64) []models.Posting {
+	expense, ok := expenseAccounts[p.IDPurchaseType]
+	if !ok {
+		expense = "Expenses:Uncategorized"
+	}
+
+	source := "Assets:" + accountComponent(p.Account)
+	if p.CreditCard != "" {
+		source = "Liabilities:CreditCard:" + accountComponent(p.CreditCard)
+	}
+
+	var postings []models.Posting
+
+	if len(p.Shares) == 0 {
+		postings = append(postings, models.Posting{Account: expense + ":" + accountComponent(p.Person), Amount: amount})
+	}
+
+	shares := p.Shares
+	if amount != p.Amount && len(shares) > 0 {
+		shares = scaleShares(shares, p.Amount, amount)
+	}
+
+	for _, share := range shares {
+		postings = append(postings, models.Posting{Account: expense + ":" + accountComponent(share.Person), Amount: share.Amount})
+	}
+
+	return append(postings, models.Posting{Account: source, Amount: -amount})
+}
+
+func renderLedger(transactions []models.ExportTransaction) string {
+	var b strings.Builder
+
+	for _, t := range transactions {
+		fmt.Fprintf(&b, "%s * %s\n", t.Date, strings.ReplaceAll(t.Description, "\n", " "))
+		fmt.Fprintf(&b, "    ; id: %s\n", t.ID)
+
+		for _, p := range t.Postings {
+			fmt.Fprintf(&b, "    %-60s  %.2f %s\n", p.Account, p.Amount, exportCommodity)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func renderBeancount(transactions []models.ExportTransaction) string {
+	var (
+		b        strings.Builder
+		accounts = map[string]bool{}
+		names    []string
+	)
+
+	for _, t := range transactions {
+		for _, p := range t.Postings {
+			if !accounts[p.Account] {
+				accounts[p.Account] = true
+				names = append(names, p.Account)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintf(&b, "option \"operating_currency\" \"%s\"\n\n", exportCommodity)
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "1970-01-01 open %s %s\n", name, exportCommodity)
+	}
+
+	b.WriteString("\n")
+
+	for _, t := range transactions {
+		fmt.Fprintf(&b, "%s * %s\n", t.Date, beancountString(t.Description))
+		fmt.Fprintf(&b, "  id: %s\n", beancountString(t.ID.String()))
+
+		for _, p := range t.Postings {
+			fmt.Fprintf(&b, "  %-60s  %.2f %s\n", p.Account, p.Amount, exportCommodity)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func beancountString(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "\"", "\\\"")
+	value = strings.ReplaceAll(value, "\n", " ")
+
+	return "\"" + value + "\""
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+)
+
+// accountComponent turns a name into an account name component accepted by
+// ledger, hledger and beancount: ASCII letters, digits and dashes, starting
+// with an uppercase letter or a digit.
+func accountComponent(name string) string {
+	var (
+		b    strings.Builder
+		dash bool
+	)
+
+	for _, r := range accentReplacer.Replace(strings.TrimSpace(name)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	component := strings.TrimRight(b.String(), "-")
+	if component == "" {
+		return "Unknown"
+	}
+
+	return strings.ToUpper(component[:1]) + component[1:]
+}
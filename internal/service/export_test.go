@@ -0,0 +1,47 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+func TestExportReversesRefundsInTheAccountsOfThePurchase(t *testing.T) {
+	groceries := uuid.New()
+
+	purchase := models.ExportPurchase{
+		ID:             uuid.New(),
+		Date:           "2024-03-10",
+		Description:    "Pizza",
+		Amount:         7,
+		IDPurchaseType: groceries,
+		Person:         "Ana",
+		CreditCard:     "Ana 1234",
+		Shares: []models.PurchaseShare{
+			{Person: "Ana", Amount: 2.33},
+			{Person: "Bia", Amount: 2.33},
+			{Person: "Caio", Amount: 2.34},
+		},
+	}
+
+	refund := models.ExportRefund{ID: uuid.New(), Date: "2024-03-20", Amount: 3.5, Purchase: purchase}
+
+	transactions := exportTransactions(map[uuid.UUID]string{groceries: "Expenses:Food"}, []models.ExportPurchase{purchase}, []models.ExportRefund{refund}, nil)
+
+	if len(transactions) != 2 || transactions[1].ID != refund.ID {
+		t.Fatalf("got transactions %+v, want the purchase and the refund", transactions)
+	}
+
+	want := []models.Posting{
+		{Account: "Expenses:Food:Ana", Amount: -1.17},
+		{Account: "Expenses:Food:Bia", Amount: -1.17},
+		{Account: "Expenses:Food:Caio", Amount: -1.16},
+		{Account: "Liabilities:CreditCard:Ana-1234", Amount: 3.5},
+	}
+
+	if got := transactions[1].Postings; !reflect.DeepEqual(got, want) {
+		t.Errorf("got refund postings %+v, want %+v", got, want)
+	}
+}
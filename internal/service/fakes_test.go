@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+// The fakes keep the records in memory and implement only what the tests go
+// through; calling any other method panics on the nil interface they embed.
+
+type fakePurchaseRepository struct {
+	repository.PurchaseRepository
+	purchases map[uuid.UUID]models.Purchase
+	updated   int
+
+	commitErr                       error
+	committed, rolledBack           int
+	savepoints, released, restarted int
+}
+
+func (r *fakePurchaseRepository) BeginTransaction() (*sql.Tx, error) { return nil, nil }
+
+func (r *fakePurchaseRepository) Commit(tx *sql.Tx) error {
+	r.committed++
+
+	return r.commitErr
+}
+
+func (r *fakePurchaseRepository) Rollback(tx *sql.Tx) error {
+	r.rolledBack++
+
+	return nil
+}
+
+func (r *fakePurchaseRepository) Savepoint(tx *sql.Tx) error {
+	r.savepoints++
+
+	return nil
+}
+
+func (r *fakePurchaseRepository) ReleaseSavepoint(tx *sql.Tx) error {
+	r.released++
+
+	return nil
+}
+
+func (r *fakePurchaseRepository) RollbackToSavepoint(tx *sql.Tx) error {
+	r.restarted++
+
+	return nil
+}
+
+func (r *fakePurchaseRepository) Create(ctx context.Context, tx *sql.Tx, p models.Purchase) (uuid.UUID, error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	r.purchases[p.ID] = p
+
+	return p.ID, nil
+}
+
+func (r *fakePurchaseRepository) FindEntity(ctx context.Context, id uuid.UUID) (models.Purchase, error) {
+	p, ok := r.purchases[id]
+	if !ok {
+		return models.Purchase{}, models.NotFound("purchase")
+	}
+
+	return p, nil
+}
+
+func (r *fakePurchaseRepository) FindByID(ctx context.Context, id uuid.UUID) (models.PurchaseResponse, error) {
+	p, ok := r.purchases[id]
+	if !ok {
+		return models.PurchaseResponse{}, models.NotFound("purchase")
+	}
+
+	return models.PurchaseResponse{ID: p.ID, Description: p.Description, Amount: p.Amount}, nil
+}
+
+func (r *fakePurchaseRepository) Update(ctx context.Context, tx *sql.Tx, p models.Purchase) error {
+	r.updated++
+	r.purchases[p.ID] = p
+
+	return nil
+}
+
+type fakeInstallmentRepository struct {
+	repository.InstallmentRepository
+	installments []models.Installment
+	deleted      int
+}
+
+func (r *fakeInstallmentRepository) FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Installment, error) {
+	var installments []models.Installment
+	for _, installment := range r.installments {
+		if installment.PurchaseID == id {
+			installments = append(installments, installment)
+		}
+	}
+
+	return installments, nil
+}
+
+func (r *fakeInstallmentRepository) HasPaidByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) (bool, error) {
+	for _, installment := range r.installments {
+		if installment.PurchaseID == id && installment.Paid {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeInstallmentRepository) DeleteByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	r.deleted++
+
+	kept := r.installments[:0]
+	for _, installment := range r.installments {
+		if installment.PurchaseID != id {
+			kept = append(kept, installment)
+		}
+	}
+	r.installments = kept
+
+	return nil
+}
+
+type fakeRefundRepository struct {
+	repository.RefundRepository
+	refunded map[uuid.UUID]float64
+}
+
+func (r *fakeRefundRepository) Create(ctx context.Context, tx *sql.Tx, refund models.Refund) (uuid.UUID, error) {
+	r.refunded[refund.IDPurchase] += refund.Amount
+
+	return uuid.New(), nil
+}
+
+func (r *fakeRefundRepository) TotalByPurchaseID(ctx context.Context, tx *sql.Tx, id uuid.UUID) (float64, error) {
+	return r.refunded[id], nil
+}
+
+type fakeTagRepository struct {
+	repository.TagRepository
+}
+
+func (r *fakeTagRepository) FindPurchaseTagIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *fakeTagRepository) SetPurchaseTags(ctx context.Context, tx *sql.Tx, id uuid.UUID, tags []uuid.UUID) error {
+	return nil
+}
+
+type fakeShareRepository struct {
+	repository.PurchaseShareRepository
+	shares map[uuid.UUID][]models.PurchaseShare
+}
+
+func (r *fakeShareRepository) FindByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.PurchaseShare, error) {
+	return r.shares[id], nil
+}
+
+func (r *fakeShareRepository) Save(ctx context.Context, tx *sql.Tx, id uuid.UUID, shares []models.PurchaseShare) error {
+	r.shares[id] = shares
+
+	return nil
+}
+
+type fakeLedgerRepository struct {
+	repository.LedgerRepository
+	entries []models.JournalEntry
+}
+
+func (r *fakeLedgerRepository) BeginTransaction() (*sql.Tx, error) { return nil, nil }
+func (r *fakeLedgerRepository) Commit(tx *sql.Tx) error            { return nil }
+func (r *fakeLedgerRepository) Rollback(tx *sql.Tx) error          { return nil }
+
+func (r *fakeLedgerRepository) FindNetBySource(ctx context.Context, tx *sql.Tx, sourceType string, id uuid.UUID) ([]models.Posting, error) {
+	return nil, nil
+}
+
+func (r *fakeLedgerRepository) CreateEntry(ctx context.Context, tx *sql.Tx, e models.JournalEntry) error {
+	r.entries = append(r.entries, e)
+
+	return nil
+}
+
+type fakeAuditRepository struct {
+	repository.AuditRepository
+	events []models.AuditEvent
+}
+
+func (r *fakeAuditRepository) Snapshot(ctx context.Context, tx *sql.Tx, table string, id uuid.UUID) (json.RawMessage, error) {
+	return nil, nil
+}
+
+func (r *fakeAuditRepository) Create(ctx context.Context, tx *sql.Tx, event models.AuditEvent) error {
+	r.events = append(r.events, event)
+
+	return nil
+}
+
+// fakeAccountRepository bills every installment on the same card and month.
+type fakeAccountRepository struct {
+	repository.AccountRepository
+	installments []models.Installment
+	payments     []models.InvoicePayment
+}
+
+func (r *fakeAccountRepository) FindInvoiceInstallments(ctx context.Context, tx *sql.Tx, creditCardID uuid.UUID, month string) ([]models.Installment, error) {
+	var unpaid []models.Installment
+	for _, installment := range r.installments {
+		if !installment.Paid {
+			unpaid = append(unpaid, installment)
+		}
+	}
+
+	return unpaid, nil
+}
+
+func (r *fakeAccountRepository) InvoicePaid(ctx context.Context, tx *sql.Tx, creditCardID uuid.UUID, month string) (bool, error) {
+	for _, payment := range r.payments {
+		if payment.IDCreditCard == creditCardID && payment.Month == month {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeAccountRepository) CreateInvoicePayment(ctx context.Context, tx *sql.Tx, ip models.InvoicePayment, installments []uuid.UUID) (models.InvoicePayment, error) {
+	ip.ID = uuid.New()
+	r.payments = append(r.payments, ip)
+
+	for _, id := range installments {
+		for i := range r.installments {
+			if r.installments[i].ID == id {
+				r.installments[i].Paid = true
+			}
+		}
+	}
+
+	return ip, nil
+}
+
+type fakePurchaseStore struct {
+	purchases    *fakePurchaseRepository
+	installments *fakeInstallmentRepository
+	refunds      *fakeRefundRepository
+	shares       *fakeShareRepository
+}
+
+func newFakePurchaseService() (*Purchase, fakePurchaseStore) {
+	store := fakePurchaseStore{
+		purchases:    &fakePurchaseRepository{purchases: map[uuid.UUID]models.Purchase{}},
+		installments: &fakeInstallmentRepository{},
+		refunds:      &fakeRefundRepository{refunded: map[uuid.UUID]float64{}},
+		shares:       &fakeShareRepository{shares: map[uuid.UUID][]models.PurchaseShare{}},
+	}
+
+	p := &Purchase{
+		purchaseRepository:    store.purchases,
+		installmentRepository: store.installments,
+		tagRepository:         &fakeTagRepository{},
+		shareRepository:       store.shares,
+		ledgerRepository:      &fakeLedgerRepository{},
+		refundRepository:      store.refunds,
+		auditRepository:       &fakeAuditRepository{},
+	}
+
+	return p, store
+}
+
+func testContext() context.Context {
+	return models.WithHousehold(context.Background(), uuid.New())
+}
+
+type fakeHouseholdRepository struct {
+	repository.HouseholdRepository
+	roles map[[2]uuid.UUID]models.Role
+}
+
+func (r *fakeHouseholdRepository) FindRole(householdID, userID uuid.UUID) (models.Role, error) {
+	role, ok := r.roles[[2]uuid.UUID{householdID, userID}]
+	if !ok {
+		return "", models.NotFound("household")
+	}
+
+	return role, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type HouseholdService interface {
+	CreateHousehold(ctx context.Context, household models.Household) (models.Household, error)
+	FindHouseholds(ctx context.Context) ([]models.Household, error)
+	FindMembers(ctx context.Context, id uuid.UUID) ([]models.HouseholdMember, error)
+	FindMember(ctx context.Context, id, userID uuid.UUID) (models.HouseholdMember, error)
+	AddMember(ctx context.Context, id uuid.UUID, request models.MemberRequest) (models.HouseholdMember, error)
+	UpdateMemberRole(ctx context.Context, id, userID uuid.UUID, request models.RoleRequest) error
+	RemoveMember(ctx context.Context, id, userID uuid.UUID) error
+	Resolve(ctx context.Context, requested string) (models.Membership, error)
+	AdoptOrphans(userID uuid.UUID) error
+}
+
+type Household struct {
+	householdRepository repository.HouseholdRepository
+	userRepository      repository.UserRepository
+}
+
+func NewHouseholdService(h repository.HouseholdRepository, u repository.UserRepository) HouseholdService {
+	return &Household{
+		householdRepository: h,
+		userRepository:      u,
+	}
+}
+
+// CreateHousehold creates a household with the user of the request as its
+// owner.
+func (h *Household) CreateHousehold(ctx context.Context, household models.Household) (models.Household, error) {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return models.Household{}, models.Unauthorized("authentication required")
+	}
+
+	if err := household.Validate(); err != nil {
+		return models.Household{}, err
+	}
+
+	tx, err := h.householdRepository.BeginTransaction()
+	if err != nil {
+		return models.Household{}, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	id, err := h.householdRepository.Create(tx, household)
+	if err != nil {
+		h.householdRepository.Rollback(tx)
+
+		return models.Household{}, err
+	}
+
+	if err := h.householdRepository.AddMember(tx, id, principal.User.ID, models.RoleOwner); err != nil {
+		h.householdRepository.Rollback(tx)
+
+		return models.Household{}, err
+	}
+
+	if err := h.householdRepository.Commit(tx); err != nil {
+		return models.Household{}, fmt.Errorf("error on commit transaction: %w", err)
+	}
+
+	household, err = h.householdRepository.FindByID(id)
+	if err != nil {
+		return models.Household{}, err
+	}
+
+	household.Role = models.RoleOwner
+
+	return household, nil
+}
+
+func (h *Household) FindHouseholds(ctx context.Context) ([]models.Household, error) {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return nil, models.Unauthorized("authentication required")
+	}
+
+	return h.householdRepository.FindByUser(principal.User.ID)
+}
+
+func (h *Household) FindMembers(ctx context.Context, id uuid.UUID) ([]models.HouseholdMember, error) {
+	if _, err := h.authorize(ctx, id, models.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return h.householdRepository.FindMembers(id)
+}
+
+func (h *Household) FindMember(ctx context.Context, id, userID uuid.UUID) (models.HouseholdMember, error) {
+	if _, err := h.authorize(ctx, id, models.PermissionRead); err != nil {
+		return models.HouseholdMember{}, err
+	}
+
+	return h.householdRepository.FindMember(id, userID)
+}
+
+// AddMember adds the user with the email to the household and returns it as
+// a member.
+func (h *Household) AddMember(ctx context.Context, id uuid.UUID, request models.MemberRequest) (models.HouseholdMember, error) {
+	if err := request.Validate(); err != nil {
+		return models.HouseholdMember{}, err
+	}
+
+	if _, err := h.authorize(ctx, id, models.PermissionManage); err != nil {
+		return models.HouseholdMember{}, err
+	}
+
+	user, err := h.userRepository.FindByEmail(request.Email)
+	if err != nil {
+		return models.HouseholdMember{}, err
+	}
+
+	tx, err := h.householdRepository.BeginTransaction()
+	if err != nil {
+		return models.HouseholdMember{}, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	if err := h.householdRepository.AddMember(tx, id, user.ID, request.Role); err != nil {
+		h.householdRepository.Rollback(tx)
+
+		return models.HouseholdMember{}, err
+	}
+
+	if err := h.householdRepository.Commit(tx); err != nil {
+		return models.HouseholdMember{}, fmt.Errorf("error on commit transaction: %w", err)
+	}
+
+	return h.householdRepository.FindMember(id, user.ID)
+}
+
+// UpdateMemberRole changes the role of a member. Only owners can do it, and
+// the last owner cannot step down, or nobody could manage the household.
+func (h *Household) UpdateMemberRole(ctx context.Context, id, userID uuid.UUID, request models.RoleRequest) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := h.authorize(ctx, id, models.PermissionManage); err != nil {
+		return err
+	}
+
+	if request.Role != models.RoleOwner {
+		if err := h.checkNotLastOwner(id, userID); err != nil {
+			return err
+		}
+	}
+
+	return h.householdRepository.UpdateRole(id, userID, request.Role)
+}
+
+// RemoveMember takes a user out of the household. Owners can remove anyone
+// and every member can leave, except the last owner.
+func (h *Household) RemoveMember(ctx context.Context, id, userID uuid.UUID) error {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return models.Unauthorized("authentication required")
+	}
+
+	permission := models.PermissionManage
+	if userID == principal.User.ID {
+		permission = models.PermissionRead
+	}
+
+	if _, err := h.authorize(ctx, id, permission); err != nil {
+		return err
+	}
+
+	if err := h.checkNotLastOwner(id, userID); err != nil {
+		return err
+	}
+
+	return h.householdRepository.RemoveMember(id, userID)
+}
+
+// Resolve returns the household a request is scoped to, with the role of its
+// user there: the one it asks for, if the user is a member of it, or the only
+// household of the user when it asks for none.
+func (h *Household) Resolve(ctx context.Context, requested string) (models.Membership, error) {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return models.Membership{}, models.Unauthorized("authentication required")
+	}
+
+	if requested != "" {
+		id, err := uuid.Parse(requested)
+		if err != nil {
+			return models.Membership{}, models.Invalid("the household id %q is not a valid id", requested)
+		}
+
+		role, err := h.authorize(ctx, id, models.PermissionRead)
+		if err != nil {
+			return models.Membership{}, err
+		}
+
+		return models.Membership{IDHousehold: id, Role: role}, nil
+	}
+
+	households, err := h.householdRepository.FindByUser(principal.User.ID)
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	if len(households) != 1 {
+		return models.Membership{}, models.Forbidden("you are a member of %d households, send the one to use in X-Household-ID", len(households))
+	}
+
+	return models.Membership{IDHousehold: households[0].ID, Role: households[0].Role}, nil
+}
+
+func (h *Household) AdoptOrphans(userID uuid.UUID) error {
+	return h.householdRepository.AdoptOrphans(userID)
+}
+
+// authorize returns the role of the user in the household when it grants the
+// permission. A household the user is not a member of is answered as if it
+// did not exist, so its ids cannot be probed.
+func (h *Household) authorize(ctx context.Context, id uuid.UUID, permission models.Permission) (models.Role, error) {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return "", models.Unauthorized("authentication required")
+	}
+
+	role, err := h.householdRepository.FindRole(id, principal.User.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := models.Authorize(role, permission); err != nil {
+		return "", err
+	}
+
+	return role, nil
+}
+
+// checkNotLastOwner refuses to take the owner role from the only owner of the
+// household.
+func (h *Household) checkNotLastOwner(id, userID uuid.UUID) error {
+	members, err := h.householdRepository.FindMembers(id)
+	if err != nil {
+		return err
+	}
+
+	owners, isOwner := 0, false
+	for _, member := range members {
+		if member.Role == models.RoleOwner {
+			owners++
+			isOwner = isOwner || member.IDUser == userID
+		}
+	}
+
+	if isOwner && owners == 1 {
+		return models.Conflict("the household needs at least one owner")
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+func TestResolveHouseholdOfAnotherUser(t *testing.T) {
+	var (
+		user  = models.User{ID: uuid.New()}
+		home  = uuid.New()
+		other = uuid.New()
+	)
+
+	h := &Household{householdRepository: &fakeHouseholdRepository{
+		roles: map[[2]uuid.UUID]models.Role{{home, user.ID}: models.RoleViewer},
+	}}
+
+	ctx := models.WithPrincipal(context.Background(), models.Principal{User: user})
+
+	membership, err := h.Resolve(ctx, home.String())
+	if err != nil {
+		t.Fatalf("resolving a household of the user: %v", err)
+	}
+
+	if membership.IDHousehold != home || membership.Role != models.RoleViewer {
+		t.Errorf("got %+v, want the household of the user as a viewer", membership)
+	}
+
+	_, err = h.Resolve(ctx, other.String())
+
+	var notFound *models.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("resolving a household of another user: got %v, want not found", err)
+	}
+}
+
+func TestMembersAreManagedByOwnersOnly(t *testing.T) {
+	var (
+		editor = models.User{ID: uuid.New()}
+		viewer = models.User{ID: uuid.New()}
+		home   = uuid.New()
+	)
+
+	h := &Household{householdRepository: &fakeHouseholdRepository{
+		roles: map[[2]uuid.UUID]models.Role{
+			{home, editor.ID}: models.RoleEditor,
+			{home, viewer.ID}: models.RoleViewer,
+		},
+	}}
+
+	for _, user := range []models.User{editor, viewer} {
+		ctx := models.WithPrincipal(context.Background(), models.Principal{User: user})
+
+		var forbidden *models.ForbiddenError
+
+		_, err := h.AddMember(ctx, home, models.MemberRequest{Email: "new@example.com", Role: models.RoleViewer})
+		if !errors.As(err, &forbidden) {
+			t.Errorf("adding a member: got %v, want forbidden", err)
+		}
+
+		err = h.UpdateMemberRole(ctx, home, uuid.New(), models.RoleRequest{Role: models.RoleOwner})
+		if !errors.As(err, &forbidden) {
+			t.Errorf("changing a role: got %v, want forbidden", err)
+		}
+
+		err = h.RemoveMember(ctx, home, uuid.New())
+		if !errors.As(err, &forbidden) {
+			t.Errorf("removing another member: got %v, want forbidden", err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+	"github.com/sagikazarmark/slog-shim"
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, key, requestHash string) (*models.IdempotentResponse, error)
+	Complete(ctx context.Context, key string, response models.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+	RunCleanup(interval time.Duration)
+}
+
+type Idempotency struct {
+	idempotencyRepository repository.IdempotencyRepository
+	ttl                   time.Duration
+}
+
+func NewIdempotencyService(r repository.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &Idempotency{
+		idempotencyRepository: r,
+		ttl:                   ttl,
+	}
+}
+
+// Begin claims the key for a request of the user in the household. It returns nil when the
+// request is the first with the key and has to run, or the response to replay
+// when it already ran. The key cannot come with another request, nor while
+// its first request is still running.
+func (i *Idempotency) Begin(ctx context.Context, key, requestHash string) (*models.IdempotentResponse, error) {
+	userID, householdID, err := idempotencyScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	reserved, err := i.idempotencyRepository.Reserve(models.IdempotencyKey{
+		Key:         key,
+		IDUser:      userID,
+		IDHousehold: householdID,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(i.ttl),
+	})
+	if err != nil || reserved {
+		return nil, err
+	}
+
+	stored, err := i.idempotencyRepository.Find(userID, householdID, key)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, models.Conflict("the request with this Idempotency-Key was just released, retry it")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, models.ErrIdempotencyKeyReused
+	}
+
+	if stored.Response == nil {
+		return nil, models.Conflict("a request with this Idempotency-Key is still running, retry it later")
+	}
+
+	return stored.Response, nil
+}
+
+func (i *Idempotency) Complete(ctx context.Context, key string, response models.IdempotentResponse) error {
+	userID, householdID, err := idempotencyScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	return i.idempotencyRepository.Complete(userID, householdID, key, response)
+}
+
+func (i *Idempotency) Release(ctx context.Context, key string) error {
+	userID, householdID, err := idempotencyScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	return i.idempotencyRepository.Release(userID, householdID, key)
+}
+
+// idempotencyScope returns whose keys a request uses: those of its user in its
+// household, or in no household on the routes not scoped to one.
+func idempotencyScope(ctx context.Context) (uuid.UUID, uuid.UUID, error) {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return uuid.Nil, uuid.Nil, models.Unauthorized("authentication required")
+	}
+
+	membership, _ := models.MembershipFrom(ctx)
+
+	return principal.User.ID, membership.IDHousehold, nil
+}
+
+// RunCleanup deletes the expired keys every interval until the process exits.
+func (i *Idempotency) RunCleanup(interval time.Duration) {
+	for {
+		deleted, err := i.idempotencyRepository.DeleteExpired()
+		if err != nil {
+			slog.Error(err.Error())
+		} else {
+			slog.Info("idempotency keys expired", "deleted", deleted)
+		}
+
+		time.Sleep(interval)
+	}
+}
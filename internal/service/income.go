@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type IncomeService interface {
+	CreateIncome(ctx context.Context, income models.Income) (models.Income, error)
+	UpdateIncome(ctx context.Context, income models.Income) error
+	DeleteIncome(ctx context.Context, id uuid.UUID, version int) error
+	FindIncomeByID(ctx context.Context, id uuid.UUID) (models.Income, error)
+	FindIncomeByMonth(ctx context.Context, month string) (models.IncomeResponseTotal, error)
+	FindAllIncomes(ctx context.Context) ([]models.Income, error)
+}
+
+type Income struct {
+	incomeRepository repository.IncomeRepository
+	auditRepository  repository.AuditRepository
+}
+
+func NewIncomeService(r repository.IncomeRepository, a repository.AuditRepository) IncomeService {
+	return &Income{
+		incomeRepository: r,
+		auditRepository:  a,
+	}
+}
+
+func (i *Income) CreateIncome(ctx context.Context, income models.Income) (models.Income, error) {
+	id, err := audited(ctx, i.auditRepository, "income", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return i.incomeRepository.Create(ctx, tx, income)
+	})
+	if err != nil {
+		return models.Income{}, err
+	}
+
+	return i.FindIncomeByID(ctx, id)
+}
+
+func (i *Income) UpdateIncome(ctx context.Context, income models.Income) error {
+	_, err := audited(ctx, i.auditRepository, "income", models.AuditUpdate, income.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		return income.ID, i.incomeRepository.Update(ctx, tx, income)
+	})
+
+	return err
+}
+
+func (i *Income) DeleteIncome(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, i.auditRepository, "income", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		return id, i.incomeRepository.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+func (i *Income) FindIncomeByID(ctx context.Context, id uuid.UUID) (models.Income, error) {
+	income, err := i.incomeRepository.FindByID(ctx, id)
+	if err != nil {
+		return models.Income{}, err
+	}
+
+	return income, nil
+}
+
+func (i *Income) FindIncomeByMonth(ctx context.Context, month string) (models.IncomeResponseTotal, error) {
+	incomes, err := i.incomeRepository.FindByMonth(ctx, month)
+	if err != nil {
+		return models.IncomeResponseTotal{}, err
+	}
+
+	return processIncomeResponse(incomes), nil
+}
+
+func (i *Income) FindAllIncomes(ctx context.Context) ([]models.Income, error) {
+	incomes, err := i.incomeRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return incomes, nil
+}
+
+func processIncomeResponse(incomes []models.Income) models.IncomeResponseTotal {
+	total := 0.0
+
+	for _, income := range incomes {
+		total += income.Amount
+	}
+
+	return models.IncomeResponseTotal{
+		Responses: incomes,
+		Quantity:  len(incomes),
+		Total:     total,
+	}
+}
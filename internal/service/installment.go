@@ -1,38 +1,47 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/repository"
+	"github.com/sagikazarmark/slog-shim"
 )
 
 type InstallmentService interface {
-	CreateInstallment(tx *sql.Tx, purchase models.Purchase) error
-	UpdateInstalment(id uuid.UUID) error
-	DeleteInstallment(purchaseID uuid.UUID) error
-	FindInstallmentByPurchaseID(id uuid.UUID) (models.InstallmentResponse, error)
-	FindInstallmentByMonth(month string) (models.InstallmentResponse, error)
-	FindInstallmentByNotPaid() (models.InstallmentResponse, error)
+	CreateInstallment(ctx context.Context, tx *sql.Tx, purchase models.Purchase) error
+	CancelInstallments(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID, amount float64) (float64, error)
+	CreateCredit(ctx context.Context, tx *sql.Tx, purchase models.Purchase, date string, amount float64, description string) error
+	UpdateInstalment(ctx context.Context, id uuid.UUID) error
+	DeleteInstallment(ctx context.Context, purchaseID uuid.UUID) error
+	FindInstallmentByPurchaseID(ctx context.Context, id uuid.UUID) (models.InstallmentResponse, error)
+	FindInstallmentByMonth(ctx context.Context, month string) (models.InstallmentResponse, error)
+	FindInstallmentByMonthAndPerson(ctx context.Context, month string, personID uuid.UUID) (models.InstallmentResponse, error)
+	FindInstallmentByNotPaid(ctx context.Context) (models.InstallmentResponse, error)
 }
 
 type Installment struct {
 	installmentRepository repository.InstallmentRepository
 	creditCardRepository  repository.CreditCardRepository
+	ledgerRepository      repository.LedgerRepository
+	auditRepository       repository.AuditRepository
 }
 
-func NewInstallmentService(r repository.InstallmentRepository, cc repository.CreditCardRepository) InstallmentService {
+func NewInstallmentService(r repository.InstallmentRepository, cc repository.CreditCardRepository, l repository.LedgerRepository, a repository.AuditRepository) InstallmentService {
 	return &Installment{
 		installmentRepository: r,
 		creditCardRepository:  cc,
+		ledgerRepository:      l,
+		auditRepository:       a,
 	}
 }
 
-func (i *Installment) CreateInstallment(tx *sql.Tx, purchase models.Purchase) error {
+func (i *Installment) CreateInstallment(ctx context.Context, tx *sql.Tx, purchase models.Purchase) error {
 	var (
 		installment = purchase.Installment
 		first       = true
@@ -42,45 +51,129 @@ func (i *Installment) CreateInstallment(tx *sql.Tx, purchase models.Purchase) er
 
 	slog.Info(fmt.Sprintf("purchaseID: %s", installment.PurchaseID.String()))
 
+	// Purchases debited straight from an account are not billed on an invoice.
+	if purchase.IDCreditCard == uuid.Nil {
+		return nil
+	}
+
+	installments := make([]models.Installment, 0, installment.Number)
+
 	for j := 1; j <= installment.Number; j++ {
 		installment.ID = uuid.New()
 		installment.Description = fmt.Sprintf("Parcela %d de %d", j, installment.Number)
 		installment.Value = purchase.Amount / float64(installment.Number)
 
-		month, err = calculeteDateNextInvoice(i, first, month, purchase.IDCreditCard)
+		month, err = calculeteDateNextInvoice(ctx, i, first, month, purchase.IDCreditCard)
 		if err != nil {
 			return err
 		}
 		installment.Month = month
 		installment.Paid = false
 
-		if err := i.installmentRepository.Create(tx, installment); err != nil {
-			return err
+		installments = append(installments, installment)
+	}
+
+	return i.installmentRepository.CreateMany(ctx, tx, installments)
+}
+
+// CancelInstallments reduces the unpaid installments of a purchase by amount,
+// latest first, deleting the ones reduced to zero. It returns the part of the
+// amount the installments could not absorb.
+func (i *Installment) CancelInstallments(ctx context.Context, tx *sql.Tx, purchaseID uuid.UUID, amount float64) (float64, error) {
+	installments, err := i.installmentRepository.FindUnpaidByPurchaseID(ctx, tx, purchaseID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, installment := range installments {
+		if amount <= 0 {
+			break
+		}
+
+		cancelled := math.Min(installment.Value, amount)
+		amount = roundCents(amount - cancelled)
+
+		if value := roundCents(installment.Value - cancelled); value > 0 {
+			err = i.installmentRepository.UpdateValue(ctx, tx, installment.ID, value)
+		} else {
+			err = i.installmentRepository.DeleteByID(ctx, tx, installment.ID)
+		}
+
+		if err != nil {
+			return 0, err
 		}
 	}
 
-	return nil
+	return amount, nil
 }
-func (i *Installment) UpdateInstalment(id uuid.UUID) error {
-	if err := i.installmentRepository.Update(id); err != nil {
-		return fmt.Errorf("error updating installment: %v", err)
+
+// CreateCredit adds a negative installment to the first invoice closing after
+// the date, so the credit is discounted from what the card owner pays.
+func (i *Installment) CreateCredit(ctx context.Context, tx *sql.Tx, purchase models.Purchase, date string, amount float64, description string) error {
+	month, err := calculeteDateNextInvoice(ctx, i, true, date, purchase.IDCreditCard)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	credit := models.Installment{
+		PurchaseID:  purchase.ID,
+		Description: description,
+		Number:      0,
+		Value:       -roundCents(amount),
+		Month:       month,
+		Paid:        false,
+	}
+
+	return i.installmentRepository.Create(ctx, tx, credit)
+}
+
+func (i *Installment) UpdateInstalment(ctx context.Context, id uuid.UUID) error {
+	tx, err := i.ledgerRepository.BeginTransaction()
+	if err != nil {
+		return models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	before, err := i.auditRepository.Snapshot(ctx, tx, "installment", id)
+	if err != nil {
+		i.ledgerRepository.Rollback(tx)
+
+		return err
+	}
+
+	installment, creditCardID, err := i.installmentRepository.Update(ctx, tx, id)
+	if err != nil {
+		i.ledgerRepository.Rollback(tx)
+
+		return fmt.Errorf("error updating installment: %w", err)
+	}
+
+	if err := NewLedgerService(i.ledgerRepository).RecordInstallmentPayment(ctx, tx, installment, creditCardID); err != nil {
+		i.ledgerRepository.Rollback(tx)
+
+		return err
+	}
+
+	if err := recordAudit(ctx, i.auditRepository, tx, "installment", models.AuditPay, id, before); err != nil {
+		i.ledgerRepository.Rollback(tx)
+
+		return err
+	}
+
+	return i.ledgerRepository.Commit(tx)
 }
 
-func (i *Installment) DeleteInstallment(purchaseID uuid.UUID) error {
-	if err := i.installmentRepository.Delete(purchaseID); err != nil {
-		return fmt.Errorf("error deleting installment: %v", err)
+func (i *Installment) DeleteInstallment(ctx context.Context, purchaseID uuid.UUID) error {
+	if err := i.installmentRepository.Delete(ctx, purchaseID); err != nil {
+		return fmt.Errorf("error deleting installment: %w", err)
 	}
 
 	return nil
 }
 
-func (i *Installment) FindInstallmentByPurchaseID(id uuid.UUID) (models.InstallmentResponse, error) {
-	installments, err := i.installmentRepository.FindByPurchaseID(id)
+func (i *Installment) FindInstallmentByPurchaseID(ctx context.Context, id uuid.UUID) (models.InstallmentResponse, error) {
+	installments, err := i.installmentRepository.FindByPurchaseID(ctx, id)
 	if err != nil {
-		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by purchaseID: %v", err)
+		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by purchaseID: %w", err)
 	}
 
 	response := processInstallmentResponse(installments)
@@ -89,10 +182,21 @@ func (i *Installment) FindInstallmentByPurchaseID(id uuid.UUID) (models.Installm
 
 }
 
-func (i *Installment) FindInstallmentByMonth(month string) (models.InstallmentResponse, error) {
-	installments, err := i.installmentRepository.FindByMonth(month)
+func (i *Installment) FindInstallmentByMonth(ctx context.Context, month string) (models.InstallmentResponse, error) {
+	installments, err := i.installmentRepository.FindByMonth(ctx, month)
+	if err != nil {
+		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by month: %w", err)
+	}
+
+	response := processInstallmentResponse(installments)
+
+	return response, nil
+}
+
+func (i *Installment) FindInstallmentByMonthAndPerson(ctx context.Context, month string, personID uuid.UUID) (models.InstallmentResponse, error) {
+	installments, err := i.installmentRepository.FindByMonthAndPerson(ctx, month, personID)
 	if err != nil {
-		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by month: %v", err)
+		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by month and person: %w", err)
 	}
 
 	response := processInstallmentResponse(installments)
@@ -100,10 +204,10 @@ func (i *Installment) FindInstallmentByMonth(month string) (models.InstallmentRe
 	return response, nil
 }
 
-func (i *Installment) FindInstallmentByNotPaid() (models.InstallmentResponse, error) {
-	installments, err := i.installmentRepository.FindByNotPaid()
+func (i *Installment) FindInstallmentByNotPaid(ctx context.Context) (models.InstallmentResponse, error) {
+	installments, err := i.installmentRepository.FindByNotPaid(ctx)
 	if err != nil {
-		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by not paid: %v", err)
+		return models.InstallmentResponse{}, fmt.Errorf("error finding installment by not paid: %w", err)
 	}
 
 	response := processInstallmentResponse(installments)
@@ -111,7 +215,7 @@ func (i *Installment) FindInstallmentByNotPaid() (models.InstallmentResponse, er
 	return response, nil
 }
 
-func calculeteDateNextInvoice(i *Installment, first bool, date string, id uuid.UUID) (string, error) {
+func calculeteDateNextInvoice(ctx context.Context, i *Installment, first bool, date string, id uuid.UUID) (string, error) {
 	var (
 		cc  models.CreditCard
 		err error
@@ -120,11 +224,11 @@ func calculeteDateNextInvoice(i *Installment, first bool, date string, id uuid.U
 
 	completeDate, err1 := time.Parse("2006-01-02", date)
 	if err1 != nil {
-		msg = fmt.Errorf("error parsing date: %v", err1)
+		msg = fmt.Errorf("error parsing date: %w", err1)
 	}
 
 	if first {
-		cc, err = i.creditCardRepository.FindByID(id)
+		cc, err = i.creditCardRepository.FindByID(ctx, id)
 		if err != nil {
 			return "", err
 		}
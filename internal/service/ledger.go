@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type LedgerService interface {
+	RecordPurchase(ctx context.Context, tx *sql.Tx, purchase models.Purchase) error
+	ReverseSource(ctx context.Context, tx *sql.Tx, sourceType string, sourceID uuid.UUID, description string) error
+	RecordInstallmentPayment(ctx context.Context, tx *sql.Tx, installment models.Installment, creditCardID uuid.UUID) error
+	RecordInvoicePayment(ctx context.Context, tx *sql.Tx, payment models.InvoicePayment) error
+	RecordRefund(ctx context.Context, tx *sql.Tx, purchase models.Purchase, refund models.Refund) error
+	TransferBalance(ctx context.Context, tx *sql.Tx, from, to string, sourceID uuid.UUID, description string) error
+	TrialBalance(ctx context.Context) (models.TrialBalance, error)
+	CheckIntegrity(ctx context.Context) (models.IntegrityResponse, error)
+}
+
+type Ledger struct {
+	ledgerRepository repository.LedgerRepository
+}
+
+func NewLedgerService(r repository.LedgerRepository) LedgerService {
+	return &Ledger{
+		ledgerRepository: r,
+	}
+}
+
+// RecordPurchase expenses the purchase against its purchase type and credits
+// the credit card liability, or the account it was debited from.
+func (l *Ledger) RecordPurchase(ctx context.Context, tx *sql.Tx, purchase models.Purchase) error {
+	source := models.LedgerAssetAccount(purchase.IDAccount.UUID)
+	if purchase.IDCreditCard != uuid.Nil {
+		source = models.LedgerCreditCardAccount(purchase.IDCreditCard)
+	}
+
+	return l.record(ctx, tx, models.JournalEntry{
+		Date:        purchase.Date,
+		Description: purchase.Description,
+		SourceType:  models.LedgerSourcePurchase,
+		SourceID:    purchase.ID,
+		Postings: []models.Posting{
+			{Account: models.LedgerExpenseAccount(purchase.IDPurchaseType), Amount: roundCents(purchase.Amount)},
+			{Account: source, Amount: -roundCents(purchase.Amount)},
+		},
+	})
+}
+
+// ReverseSource posts an entry cancelling everything previously posted for the
+// source. The journal is append only, so updates and deletions are recorded as
+// reversals instead of removing entries.
+func (l *Ledger) ReverseSource(ctx context.Context, tx *sql.Tx, sourceType string, sourceID uuid.UUID, description string) error {
+	net, err := l.ledgerRepository.FindNetBySource(ctx, tx, sourceType, sourceID)
+	if err != nil {
+		return err
+	}
+
+	if len(net) == 0 {
+		return nil
+	}
+
+	entry := models.JournalEntry{
+		Date:        time.Now().Format("2006-01-02"),
+		Description: description,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+	}
+
+	for _, posting := range net {
+		entry.Postings = append(entry.Postings, models.Posting{Account: posting.Account, Amount: -posting.Amount})
+	}
+
+	return l.record(ctx, tx, entry)
+}
+
+func (l *Ledger) RecordInstallmentPayment(ctx context.Context, tx *sql.Tx, installment models.Installment, creditCardID uuid.UUID) error {
+	return l.record(ctx, tx, models.JournalEntry{
+		Date:        time.Now().Format("2006-01-02"),
+		Description: installment.Description,
+		SourceType:  models.LedgerSourceInstallmentPayment,
+		SourceID:    installment.ID,
+		Postings: []models.Posting{
+			{Account: models.LedgerCreditCardAccount(creditCardID), Amount: roundCents(installment.Value)},
+			{Account: models.LedgerCashAccount, Amount: -roundCents(installment.Value)},
+		},
+	})
+}
+
+func (l *Ledger) RecordInvoicePayment(ctx context.Context, tx *sql.Tx, payment models.InvoicePayment) error {
+	return l.record(ctx, tx, models.JournalEntry{
+		Date:        payment.Date,
+		Description: fmt.Sprintf("Fatura %s", payment.Month),
+		SourceType:  models.LedgerSourceInvoicePayment,
+		SourceID:    payment.ID,
+		Postings: []models.Posting{
+			{Account: models.LedgerCreditCardAccount(payment.IDCreditCard), Amount: roundCents(payment.Amount)},
+			{Account: models.LedgerAssetAccount(payment.IDAccount), Amount: -roundCents(payment.Amount)},
+		},
+	})
+}
+
+// RecordRefund reverses the refunded part of the purchase expense back to the
+// credit card liability or to the account the purchase was debited from.
+func (l *Ledger) RecordRefund(ctx context.Context, tx *sql.Tx, purchase models.Purchase, refund models.Refund) error {
+	source := models.LedgerAssetAccount(purchase.IDAccount.UUID)
+	if purchase.IDCreditCard != uuid.Nil {
+		source = models.LedgerCreditCardAccount(purchase.IDCreditCard)
+	}
+
+	return l.record(ctx, tx, models.JournalEntry{
+		Date:        refund.Date,
+		Description: fmt.Sprintf("Estorno %s", purchase.Description),
+		SourceType:  models.LedgerSourceRefund,
+		SourceID:    refund.ID,
+		Postings: []models.Posting{
+			{Account: source, Amount: roundCents(refund.Amount)},
+			{Account: models.LedgerExpenseAccount(purchase.IDPurchaseType), Amount: -roundCents(refund.Amount)},
+		},
+	})
+}
+
+// TransferBalance moves everything posted to the account from into the account
+// to, used when the record behind the account is replaced by another one.
+func (l *Ledger) TransferBalance(ctx context.Context, tx *sql.Tx, from, to string, sourceID uuid.UUID, description string) error {
+	balance, err := l.ledgerRepository.FindAccountBalance(ctx, tx, from)
+	if err != nil {
+		return err
+	}
+
+	if roundCents(balance) == 0 {
+		return nil
+	}
+
+	return l.record(ctx, tx, models.JournalEntry{
+		Date:        time.Now().Format("2006-01-02"),
+		Description: description,
+		SourceType:  models.LedgerSourceReassign,
+		SourceID:    sourceID,
+		Postings: []models.Posting{
+			{Account: to, Amount: roundCents(balance)},
+			{Account: from, Amount: -roundCents(balance)},
+		},
+	})
+}
+
+func (l *Ledger) TrialBalance(ctx context.Context) (models.TrialBalance, error) {
+	lines, err := l.ledgerRepository.TrialBalance(ctx)
+	if err != nil {
+		return models.TrialBalance{}, err
+	}
+
+	response := models.TrialBalance{Lines: lines}
+
+	for _, line := range lines {
+		response.TotalDebit += line.Debit
+		response.TotalCredit += line.Credit
+	}
+
+	response.TotalDebit = roundCents(response.TotalDebit)
+	response.TotalCredit = roundCents(response.TotalCredit)
+	response.Balanced = response.TotalDebit == response.TotalCredit
+
+	return response, nil
+}
+
+// CheckIntegrity lists every drift between the ledger and the purchase and
+// installment tables, plus any entry whose postings do not balance.
+func (l *Ledger) CheckIntegrity(ctx context.Context) (models.IntegrityResponse, error) {
+	var response models.IntegrityResponse
+
+	checks := []func(context.Context) ([]models.LedgerDrift, error){
+		l.ledgerRepository.FindUnbalancedEntries,
+		l.ledgerRepository.FindPurchaseDrifts,
+		l.ledgerRepository.FindCreditCardDrifts,
+	}
+
+	for _, check := range checks {
+		drifts, err := check(ctx)
+		if err != nil {
+			return models.IntegrityResponse{}, err
+		}
+
+		response.Drifts = append(response.Drifts, drifts...)
+	}
+
+	response.Quantity = len(response.Drifts)
+	response.Healthy = response.Quantity == 0
+
+	return response, nil
+}
+
+func (l *Ledger) record(ctx context.Context, tx *sql.Tx, entry models.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.ledgerRepository.CreateEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/me/finance/internal/models"
+)
+
+// patched merges an RFC 7396 patch into the record as it is stored, giving the
+// record as if the client had sent it in full, so a patch goes through the
+// same validation and rules as a full update. A null in the patch clears the
+// field, as an omitted field would in a full update.
+func patched[T any](current T, patch []byte) (T, error) {
+	var record T
+
+	document, err := json.Marshal(current)
+	if err != nil {
+		return record, fmt.Errorf("error encoding record: %w", err)
+	}
+
+	merged, err := models.MergePatch(document, patch)
+	if err != nil {
+		return record, err
+	}
+
+	if err := json.Unmarshal(merged, &record); err != nil {
+		return record, models.Invalid("Error decoding merge patch: %v", err)
+	}
+
+	return record, nil
+}
@@ -1,55 +1,103 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/repository"
 )
 
 type PaymentTypeService interface {
-	CreatePaymentType(paymentType models.PaymentType) error
-	UpdatePaymentType(paymentType models.PaymentType) error
-	DeletePaymentType(id uuid.UUID) error
-	FindPaymentTypeByID(id uuid.UUID) (models.PaymentType, error)
-	FindAllPaymentTypes() ([]models.PaymentType, error)
+	CreatePaymentType(ctx context.Context, paymentType models.PaymentType) (models.PaymentType, error)
+	UpdatePaymentType(ctx context.Context, paymentType models.PaymentType) error
+	PatchPaymentType(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.PaymentType, error)
+	DeletePaymentType(ctx context.Context, id uuid.UUID, version int) error
+	ReassignPaymentType(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error)
+	FindPaymentTypeByID(ctx context.Context, id uuid.UUID) (models.PaymentType, error)
+	FindAllPaymentTypes(ctx context.Context) ([]models.PaymentType, error)
 }
 
 type PaymentType struct {
 	paymentTypeRepository repository.PaymentTypeRepository
+	referenceRepository   repository.ReferenceRepository
+	auditRepository       repository.AuditRepository
 }
 
-func NewPaymentTypeService(r repository.PaymentTypeRepository) PaymentTypeService {
+func NewPaymentTypeService(r repository.PaymentTypeRepository, ref repository.ReferenceRepository, a repository.AuditRepository) PaymentTypeService {
 	return &PaymentType{
 		paymentTypeRepository: r,
+		referenceRepository:   ref,
+		auditRepository:       a,
 	}
 }
 
-func (p *PaymentType) CreatePaymentType(paymentType models.PaymentType) error {
-	if err := p.paymentTypeRepository.Create(paymentType); err != nil {
-		return err
+func (p *PaymentType) CreatePaymentType(ctx context.Context, paymentType models.PaymentType) (models.PaymentType, error) {
+	id, err := audited(ctx, p.auditRepository, "payment_type", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return p.paymentTypeRepository.Create(ctx, tx, paymentType)
+	})
+	if err != nil {
+		return models.PaymentType{}, err
 	}
 
-	return nil
+	return p.FindPaymentTypeByID(ctx, id)
 }
 
-func (p *PaymentType) UpdatePaymentType(paymentType models.PaymentType) error {
-	if err := p.paymentTypeRepository.Update(paymentType); err != nil {
-		return err
-	}
+func (p *PaymentType) UpdatePaymentType(ctx context.Context, paymentType models.PaymentType) error {
+	_, err := audited(ctx, p.auditRepository, "payment_type", models.AuditUpdate, paymentType.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		return paymentType.ID, p.paymentTypeRepository.Update(ctx, tx, paymentType)
+	})
 
-	return nil
+	return err
 }
 
-func (p *PaymentType) DeletePaymentType(id uuid.UUID) error {
-	if err := p.paymentTypeRepository.Delete(id); err != nil {
-		return err
+func (p *PaymentType) PatchPaymentType(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.PaymentType, error) {
+	current, err := p.FindPaymentTypeByID(ctx, id)
+	if err != nil {
+		return models.PaymentType{}, err
+	}
+
+	paymentType, err := patched(current, patch)
+	if err != nil {
+		return models.PaymentType{}, err
 	}
 
-	return nil
+	paymentType.ID, paymentType.Version = id, version
+
+	if err := paymentType.Validate(false); err != nil {
+		return models.PaymentType{}, err
+	}
+
+	if err := p.UpdatePaymentType(ctx, paymentType); err != nil {
+		return models.PaymentType{}, err
+	}
+
+	return p.FindPaymentTypeByID(ctx, id)
+}
+
+func (p *PaymentType) DeletePaymentType(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, p.auditRepository, "payment_type", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		if err := checkUnused(ctx, tx, p.referenceRepository, "payment_type", "payment type", id); err != nil {
+			return id, err
+		}
+
+		return id, p.paymentTypeRepository.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+// ReassignPaymentType deletes the payment type after moving everything that
+// references it to another one, which is also how duplicates are merged.
+func (p *PaymentType) ReassignPaymentType(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error) {
+	return reassignAndDelete(ctx, p.auditRepository, p.referenceRepository, nil, "payment_type", id, to, nil, func(tx *sql.Tx) error {
+		return p.paymentTypeRepository.Delete(ctx, tx, id, version)
+	})
 }
 
-func (p *PaymentType) FindPaymentTypeByID(id uuid.UUID) (models.PaymentType, error) {
-	paymentType, err := p.paymentTypeRepository.FindByID(id)
+func (p *PaymentType) FindPaymentTypeByID(ctx context.Context, id uuid.UUID) (models.PaymentType, error) {
+	paymentType, err := p.paymentTypeRepository.FindByID(ctx, id)
 	if err != nil {
 		return models.PaymentType{}, err
 	}
@@ -57,8 +105,8 @@ func (p *PaymentType) FindPaymentTypeByID(id uuid.UUID) (models.PaymentType, err
 	return paymentType, nil
 }
 
-func (p *PaymentType) FindAllPaymentTypes() ([]models.PaymentType, error) {
-	paymentTypes, err := p.paymentTypeRepository.FindAll()
+func (p *PaymentType) FindAllPaymentTypes(ctx context.Context) ([]models.PaymentType, error) {
+	paymentTypes, err := p.paymentTypeRepository.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
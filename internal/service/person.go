@@ -1,55 +1,103 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/repository"
 )
 
 type PersonService interface {
-	CreatePerson(person models.Person) error
-	UpdatePerson(person models.Person) error
-	DeletePerson(id uuid.UUID) error
-	FindPersonByID(id uuid.UUID) (models.Person, error)
-	FindAllPersons() ([]models.Person, error)
+	CreatePerson(ctx context.Context, person models.Person) (models.Person, error)
+	UpdatePerson(ctx context.Context, person models.Person) error
+	PatchPerson(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.Person, error)
+	DeletePerson(ctx context.Context, id uuid.UUID, version int) error
+	ReassignPerson(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error)
+	FindPersonByID(ctx context.Context, id uuid.UUID) (models.Person, error)
+	FindAllPersons(ctx context.Context) ([]models.Person, error)
 }
 
 type Person struct {
-	repositoryPerson repository.PersonRepository
+	repositoryPerson    repository.PersonRepository
+	referenceRepository repository.ReferenceRepository
+	auditRepository     repository.AuditRepository
 }
 
-func NewPersonService(r repository.PersonRepository) PersonService {
+func NewPersonService(r repository.PersonRepository, ref repository.ReferenceRepository, a repository.AuditRepository) PersonService {
 	return &Person{
-		repositoryPerson: r,
+		repositoryPerson:    r,
+		referenceRepository: ref,
+		auditRepository:     a,
 	}
 }
 
-func (p *Person) CreatePerson(person models.Person) error {
-	if err := p.repositoryPerson.Create(person); err != nil {
-		return err
+func (p *Person) CreatePerson(ctx context.Context, person models.Person) (models.Person, error) {
+	id, err := audited(ctx, p.auditRepository, "person", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return p.repositoryPerson.Create(ctx, tx, person)
+	})
+	if err != nil {
+		return models.Person{}, err
 	}
 
-	return nil
+	return p.FindPersonByID(ctx, id)
 }
 
-func (p *Person) UpdatePerson(person models.Person) error {
-	if err := p.repositoryPerson.Update(person); err != nil {
-		return err
-	}
+func (p *Person) UpdatePerson(ctx context.Context, person models.Person) error {
+	_, err := audited(ctx, p.auditRepository, "person", models.AuditUpdate, person.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		return person.ID, p.repositoryPerson.Update(ctx, tx, person)
+	})
 
-	return nil
+	return err
 }
 
-func (p *Person) DeletePerson(id uuid.UUID) error {
-	if err := p.repositoryPerson.Delete(id); err != nil {
-		return err
+func (p *Person) PatchPerson(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.Person, error) {
+	current, err := p.FindPersonByID(ctx, id)
+	if err != nil {
+		return models.Person{}, err
+	}
+
+	person, err := patched(current, patch)
+	if err != nil {
+		return models.Person{}, err
 	}
 
-	return nil
+	person.ID, person.Version = id, version
+
+	if err := person.Validate(false); err != nil {
+		return models.Person{}, err
+	}
+
+	if err := p.UpdatePerson(ctx, person); err != nil {
+		return models.Person{}, err
+	}
+
+	return p.FindPersonByID(ctx, id)
+}
+
+func (p *Person) DeletePerson(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, p.auditRepository, "person", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		if err := checkUnused(ctx, tx, p.referenceRepository, "person", "person", id); err != nil {
+			return id, err
+		}
+
+		return id, p.repositoryPerson.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+// ReassignPerson deletes the person after moving everything that references it
+// to another one, which is also how duplicates are merged.
+func (p *Person) ReassignPerson(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error) {
+	return reassignAndDelete(ctx, p.auditRepository, p.referenceRepository, nil, "person", id, to, nil, func(tx *sql.Tx) error {
+		return p.repositoryPerson.Delete(ctx, tx, id, version)
+	})
 }
 
-func (p *Person) FindPersonByID(id uuid.UUID) (models.Person, error) {
-	person, err := p.repositoryPerson.FindByID(id)
+func (p *Person) FindPersonByID(ctx context.Context, id uuid.UUID) (models.Person, error) {
+	person, err := p.repositoryPerson.FindByID(ctx, id)
 	if err != nil {
 		return models.Person{}, err
 	}
@@ -57,8 +105,8 @@ func (p *Person) FindPersonByID(id uuid.UUID) (models.Person, error) {
 	return person, nil
 }
 
-func (p *Person) FindAllPersons() ([]models.Person, error) {
-	persons, err := p.repositoryPerson.FindAll()
+func (p *Person) FindAllPersons(ctx context.Context) ([]models.Person, error) {
+	persons, err := p.repositoryPerson.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
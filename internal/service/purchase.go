@@ -1,131 +1,605 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"log/slog"
+	"reflect"
 
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/repository"
+	"github.com/sagikazarmark/slog-shim"
 )
 
 type PurchaseService interface {
-	CreatePurchase(purchase models.Purchase) error
-	UpdatePurchase(purchase models.Purchase) error
-	DeletePurchase(id uuid.UUID) error
-	FindPurchaseByID(id uuid.UUID) (models.PurchaseResponse, error)
-	FindPurchaseByDate(date string) (models.PurchaseResponseTotal, error)
-	FindPurchaseByMonth(date string) (models.PurchaseResponseTotal, error)
-	FindPurchaseByPerson(id uuid.UUID) (models.PurchaseResponseTotal, error)
-	FindAllPurchases() ([]models.PurchaseResponse, error)
+	CreatePurchase(ctx context.Context, purchase models.Purchase) (models.PurchaseCreated, error)
+	UpdatePurchase(ctx context.Context, purchase models.Purchase) error
+	PatchPurchase(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.PurchaseResponse, error)
+	BatchPurchases(ctx context.Context, request models.PurchaseBatchRequest) (models.PurchaseBatchResponse, error)
+	DeletePurchase(ctx context.Context, id uuid.UUID, version int) error
+	RestorePurchase(ctx context.Context, id uuid.UUID) error
+	FindPurchaseByID(ctx context.Context, id uuid.UUID) (models.PurchaseResponse, error)
+	FindPurchaseByDate(ctx context.Context, date string) (models.PurchaseResponseTotal, error)
+	FindPurchaseByMonth(ctx context.Context, date string) (models.PurchaseResponseTotal, error)
+	FindPurchaseByPerson(ctx context.Context, id uuid.UUID) (models.PurchaseResponseTotal, error)
+	FindPurchaseByTags(ctx context.Context, tagIDs []uuid.UUID, matchAll bool) (models.PurchaseResponseTotal, error)
+	FindAllPurchases(ctx context.Context) ([]models.PurchaseResponse, error)
+	RefundPurchase(ctx context.Context, refund models.Refund) (models.Refund, error)
+	FindRefundsByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Refund, error)
 }
 
 type Purchase struct {
 	purchaseRepository    repository.PurchaseRepository
 	installmentRepository repository.InstallmentRepository
 	creditCardRepository  repository.CreditCardRepository
+	tagRepository         repository.TagRepository
+	shareRepository       repository.PurchaseShareRepository
+	ledgerRepository      repository.LedgerRepository
+	refundRepository      repository.RefundRepository
+	auditRepository       repository.AuditRepository
 }
 
-func NewPurchaseService(p repository.PurchaseRepository, i repository.InstallmentRepository, cc repository.CreditCardRepository) PurchaseService {
+func NewPurchaseService(p repository.PurchaseRepository, i repository.InstallmentRepository, cc repository.CreditCardRepository, t repository.TagRepository, s repository.PurchaseShareRepository, l repository.LedgerRepository, r repository.RefundRepository, a repository.AuditRepository) PurchaseService {
 	return &Purchase{
 		purchaseRepository:    p,
 		installmentRepository: i,
 		creditCardRepository:  cc,
+		tagRepository:         t,
+		shareRepository:       s,
+		ledgerRepository:      l,
+		refundRepository:      r,
+		auditRepository:       a,
 	}
 }
 
-func (p *Purchase) CreatePurchase(purchase models.Purchase) error {
+func (p *Purchase) CreatePurchase(ctx context.Context, purchase models.Purchase) (models.PurchaseCreated, error) {
 	tx, err := p.purchaseRepository.BeginTransaction()
 	if err != nil {
-		return fmt.Errorf("error on begin transaction: %v", err)
+		return models.PurchaseCreated{}, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
 	}
 
-	var savedID uuid.UUID
+	id, err := p.createPurchase(ctx, tx, purchase)
+	if err != nil {
+		p.purchaseRepository.Rollback(tx)
 
-	if savedID, err = p.purchaseRepository.Create(tx, purchase); err != nil {
-		return err
+		return models.PurchaseCreated{}, err
 	}
 
-	slog.Info(fmt.Sprintf("savedID: %s", savedID.String()))
+	if err := p.purchaseRepository.Commit(tx); err != nil {
+		return models.PurchaseCreated{}, fmt.Errorf("error on commit transaction: %w", err)
+	}
 
-	purchase.Installment.PurchaseID = savedID
+	created := models.PurchaseCreated{}
+
+	if created.PurchaseResponse, err = p.FindPurchaseByID(ctx, id); err != nil {
+		return models.PurchaseCreated{}, err
+	}
+
+	if created.Installments, err = p.installmentRepository.FindByPurchaseID(ctx, id); err != nil {
+		return models.PurchaseCreated{}, err
+	}
 
-	i := NewInstallmentService(p.installmentRepository, p.creditCardRepository)
+	return created, nil
+}
 
-	if err := i.CreateInstallment(tx, purchase); err != nil {
+func (p *Purchase) UpdatePurchase(ctx context.Context, purchase models.Purchase) error {
+	tx, err := p.purchaseRepository.BeginTransaction()
+	if err != nil {
+		return models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	if err := p.updatePurchase(ctx, tx, purchase); err != nil {
 		p.purchaseRepository.Rollback(tx)
 
 		return err
 	}
 
-	p.purchaseRepository.Commit(tx)
+	if err := p.purchaseRepository.Commit(tx); err != nil {
+		return fmt.Errorf("error on commit transaction: %w", err)
+	}
 
 	return nil
 }
 
-func (p *Purchase) UpdatePurchase(purchase models.Purchase) error {
+// PatchPurchase applies an RFC 7396 merge patch to the purchase as it would be
+// sent to UpdatePurchase, so the fields the patch leaves out keep their value
+// instead of being zeroed. The shares are kept as the amounts they were saved
+// with, as their rounded percentages may not add up to 100; when the patch
+// changes the amount but not the shares, they are scaled to the new amount.
+func (p *Purchase) PatchPurchase(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.PurchaseResponse, error) {
+	current, err := p.purchaseRequest(ctx, id)
+	if err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
+	request, err := patched(current, patch)
+	if err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
+	if request.Amount != current.Amount && request.SplitType == current.SplitType && reflect.DeepEqual(request.Shares, current.Shares) {
+		request.Shares = scaleShares(current.Shares, current.Amount, request.Amount)
+	}
+
+	request.ID, request.Version = id, version
+
+	purchase, err := request.ToEntity()
+	if err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
+	// A purchase whose installments were all cancelled by refunds keeps no
+	// plan, and its installments are not rebuilt, so unless the patch sets
+	// one there is no number of installments to check.
+	validate := purchase.Validate
+	if current.InstallmentNumber == 0 && request.InstallmentNumber == 0 {
+		validate = purchase.ValidateWithoutPlan
+	}
+
+	if err := validate(); err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
+	if err := p.UpdatePurchase(ctx, purchase); err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
+	return p.FindPurchaseByID(ctx, id)
+}
+
+// purchaseRequest rebuilds from what is stored the request the purchase would
+// be saved with.
+func (p *Purchase) purchaseRequest(ctx context.Context, id uuid.UUID) (models.PurchaseRequest, error) {
+	purchase, err := p.purchaseRepository.FindEntity(ctx, id)
+	if err != nil {
+		return models.PurchaseRequest{}, err
+	}
+
+	tags, err := p.tagRepository.FindPurchaseTagIDs(ctx, id)
+	if err != nil {
+		return models.PurchaseRequest{}, err
+	}
+
+	shares, err := p.shareRepository.FindByPurchaseID(ctx, id)
+	if err != nil {
+		return models.PurchaseRequest{}, err
+	}
+
+	installments, err := p.installmentRepository.FindByPurchaseID(ctx, id)
+	if err != nil {
+		return models.PurchaseRequest{}, err
+	}
+
+	var first string
+
+	request := models.PurchaseRequest{
+		ID:             purchase.ID,
+		Description:    purchase.Description,
+		Amount:         purchase.Amount,
+		Date:           purchase.Date,
+		Place:          purchase.Place,
+		Paid:           purchase.Paid,
+		IDPaymentType:  purchase.IDPaymentType,
+		IDCreditCard:   purchase.IDCreditCard,
+		IDPurchaseType: purchase.IDPurchaseType,
+		IDPerson:       purchase.IDPerson,
+		IDAccount:      purchase.IDAccount,
+		Tags:           tags,
+		Shares:         shares,
+		Version:        purchase.Version,
+	}
+
+	if len(shares) > 0 {
+		request.SplitType = models.SplitAmount
+	}
+
+	// Every installment of the plan holds the number of installments of the
+	// purchase; the credits of refunds are numbered 0. A cancelling refund
+	// lowers or deletes the last unpaid installments, so the value is the one
+	// of the first month, and a fully cancelled purchase has none left.
+	for _, installment := range installments {
+		if installment.Number > request.InstallmentNumber {
+			request.InstallmentNumber = installment.Number
+		}
+
+		if installment.Number > 0 && (request.Installment == 0 || installment.Month < first) {
+			request.Installment, first = installment.Value, installment.Month
+		}
+	}
+
+	return request, nil
+}
+
+// scaleShares returns the amounts of the shares of a purchase of amount from
+// for a purchase of amount to, in the same proportions. The last share takes
+// the rounding difference, as in ResolveShares.
+func scaleShares(shares []models.PurchaseShare, from, to float64) []models.PurchaseShare {
+	scaled := make([]models.PurchaseShare, len(shares))
+
+	var allocated float64
+
+	for i, share := range shares {
+		share.Amount = roundCents(share.Amount * to / from)
+		if i == len(shares)-1 {
+			share.Amount = roundCents(to - allocated)
+		}
+
+		allocated += share.Amount
+		scaled[i] = share
+	}
+
+	return scaled
+}
+
+// BatchPurchases creates the purchases without a version, under the id the
+// client chose when there is one, and updates the others, all in one
+// transaction. In the atomic mode the first failure rolls every
+// purchase back; in the best effort mode each purchase runs in a savepoint
+// of its own, so the failed ones are skipped and the rest are kept.
+func (p *Purchase) BatchPurchases(ctx context.Context, request models.PurchaseBatchRequest) (models.PurchaseBatchResponse, error) {
+	if err := request.Validate(); err != nil {
+		return models.PurchaseBatchResponse{}, err
+	}
+
+	response := models.PurchaseBatchResponse{
+		Mode:    request.Mode,
+		Results: make([]models.PurchaseBatchResult, len(request.Purchases)),
+	}
+
 	tx, err := p.purchaseRepository.BeginTransaction()
 	if err != nil {
-		return fmt.Errorf("error on begin transaction: %v", err)
+		return models.PurchaseBatchResponse{}, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	bestEffort := request.Mode == models.BatchModeBestEffort
+
+	for i, item := range request.Purchases {
+		result := &response.Results[i]
+		result.Index = i
+
+		if response.Failed > 0 && !bestEffort {
+			result.Status = models.BatchStatusSkipped
+			continue
+		}
+
+		if bestEffort {
+			if err := p.purchaseRepository.Savepoint(tx); err != nil {
+				p.purchaseRepository.Rollback(tx)
+
+				return models.PurchaseBatchResponse{}, err
+			}
+		}
+
+		var id uuid.UUID
+
+		id, result.Status, result.Err = p.batchPurchase(ctx, tx, item)
+		result.ID = uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+
+		if result.Err == nil {
+			response.Succeeded++
+
+			if bestEffort {
+				if err := p.purchaseRepository.ReleaseSavepoint(tx); err != nil {
+					p.purchaseRepository.Rollback(tx)
+
+					return models.PurchaseBatchResponse{}, err
+				}
+			}
+
+			continue
+		}
+
+		response.Failed++
+
+		if bestEffort {
+			if err := p.purchaseRepository.RollbackToSavepoint(tx); err != nil {
+				p.purchaseRepository.Rollback(tx)
+
+				return models.PurchaseBatchResponse{}, err
+			}
+		}
+	}
+
+	if response.Failed > 0 && !bestEffort {
+		p.purchaseRepository.Rollback(tx)
+
+		for i := range response.Results {
+			if response.Results[i].Err == nil && response.Results[i].Status != models.BatchStatusSkipped {
+				response.Results[i].Status = models.BatchStatusRolledBack
+			}
+		}
+
+		response.Succeeded = 0
+
+		return response, nil
+	}
+
+	if err := p.purchaseRepository.Commit(tx); err != nil {
+		return models.PurchaseBatchResponse{}, fmt.Errorf("error on commit transaction: %w", err)
+	}
+
+	return response, nil
+}
+
+// batchPurchase creates or updates one purchase of a batch, returning its id
+// and what was done with it.
+func (p *Purchase) batchPurchase(ctx context.Context, tx *sql.Tx, request models.PurchaseRequest) (uuid.UUID, string, error) {
+	purchase, err := request.ToEntity()
+	if err != nil {
+		return request.ID, models.BatchStatusFailed, err
+	}
+
+	if err := purchase.Validate(); err != nil {
+		return request.ID, models.BatchStatusFailed, err
+	}
+
+	if purchase.Version == 0 {
+		id, err := p.createPurchase(ctx, tx, purchase)
+		if err != nil {
+			return purchase.ID, models.BatchStatusFailed, err
+		}
+
+		return id, models.BatchStatusCreated, nil
+	}
+
+	if err := p.updatePurchase(ctx, tx, purchase); err != nil {
+		return purchase.ID, models.BatchStatusFailed, err
+	}
+
+	return purchase.ID, models.BatchStatusUpdated, nil
+}
+
+func (p *Purchase) createPurchase(ctx context.Context, tx *sql.Tx, purchase models.Purchase) (uuid.UUID, error) {
+	if err := purchase.ResolveShares(); err != nil {
+		return uuid.Nil, err
+	}
+
+	savedID, err := p.purchaseRepository.Create(ctx, tx, purchase)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	slog.Info(fmt.Sprintf("savedID: %s", savedID.String()))
+
+	purchase.ID = savedID
+	purchase.Installment.PurchaseID = savedID
+
+	if err := p.tagRepository.SetPurchaseTags(ctx, tx, savedID, purchase.Tags); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := p.shareRepository.Save(ctx, tx, savedID, purchase.Shares); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := NewLedgerService(p.ledgerRepository).RecordPurchase(ctx, tx, purchase); err != nil {
+		return uuid.Nil, err
+	}
+
+	i := NewInstallmentService(p.installmentRepository, p.creditCardRepository, p.ledgerRepository, p.auditRepository)
+
+	if err := i.CreateInstallment(ctx, tx, purchase); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := recordAudit(ctx, p.auditRepository, tx, "purchase", models.AuditCreate, savedID, nil); err != nil {
+		return uuid.Nil, err
+	}
+
+	return savedID, nil
+}
+
+func (p *Purchase) updatePurchase(ctx context.Context, tx *sql.Tx, purchase models.Purchase) error {
+	if err := purchase.ResolveShares(); err != nil {
+		return err
+	}
+
+	settled, err := p.settled(ctx, tx, purchase)
+	if err != nil {
+		return err
+	}
+
+	before, err := p.auditRepository.Snapshot(ctx, tx, "purchase", purchase.ID)
+	if err != nil {
+		return err
 	}
 
-	if err := p.purchaseRepository.Update(tx, purchase); err != nil {
+	if err := p.purchaseRepository.Update(ctx, tx, purchase); err != nil {
 		return err
 	}
 
 	purchase.Installment.PurchaseID = purchase.ID
 
-	if err := p.installmentRepository.Delete(purchase.ID); err != nil {
+	if err := p.tagRepository.SetPurchaseTags(ctx, tx, purchase.ID, purchase.Tags); err != nil {
+		return err
+	}
+
+	if err := p.shareRepository.Save(ctx, tx, purchase.ID, purchase.Shares); err != nil {
+		return err
+	}
+
+	ledger := NewLedgerService(p.ledgerRepository)
+
+	if err := ledger.ReverseSource(ctx, tx, models.LedgerSourcePurchase, purchase.ID, "Estorno por alteração"); err != nil {
+		return err
+	}
+
+	if err := ledger.RecordPurchase(ctx, tx, purchase); err != nil {
+		return err
+	}
+
+	// The installments of a settled purchase are kept as they are, with the
+	// payments and the credits of its refunds.
+	if !settled {
+		if err := p.installmentRepository.DeleteByPurchaseID(ctx, tx, purchase.ID); err != nil {
+			return err
+		}
+
+		i := NewInstallmentService(p.installmentRepository, p.creditCardRepository, p.ledgerRepository, p.auditRepository)
+
+		if err := i.CreateInstallment(ctx, tx, purchase); err != nil {
+			return err
+		}
+	}
+
+	return recordAudit(ctx, p.auditRepository, tx, "purchase", models.AuditUpdate, purchase.ID, before)
+}
+
+// settled tells whether the purchase was refunded or has installments paid,
+// locking it against new refunds. Its amount, date, card, account and number
+// of installments can no longer change then, as its installments would have
+// to be rebuilt.
+func (p *Purchase) settled(ctx context.Context, tx *sql.Tx, purchase models.Purchase) (bool, error) {
+	refunded, err := p.refundRepository.TotalByPurchaseID(ctx, tx, purchase.ID)
+	if err != nil {
+		return false, err
+	}
+
+	paid, err := p.installmentRepository.HasPaidByPurchaseID(ctx, tx, purchase.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if refunded == 0 && !paid {
+		return false, nil
+	}
+
+	current, err := p.purchaseRequest(ctx, purchase.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if current.Amount != purchase.Amount ||
+		current.Date != purchase.Date ||
+		current.IDCreditCard != purchase.IDCreditCard ||
+		current.IDAccount != purchase.IDAccount ||
+		(purchase.IDCreditCard != uuid.Nil && current.InstallmentNumber != 0 && current.InstallmentNumber != purchase.Installment.Number) {
+		return false, models.Conflict("the amount, date, card, account and installments of a refunded or paid purchase cannot change")
+	}
+
+	return true, nil
+}
+
+func (p *Purchase) DeletePurchase(ctx context.Context, id uuid.UUID, version int) error {
+	tx, err := p.purchaseRepository.BeginTransaction()
+	if err != nil {
+		return models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	before, err := p.auditRepository.Snapshot(ctx, tx, "purchase", id)
+	if err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return err
+	}
+
+	if err := NewLedgerService(p.ledgerRepository).ReverseSource(ctx, tx, models.LedgerSourcePurchase, id, "Estorno por exclusão"); err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return err
+	}
+
+	if err := p.purchaseRepository.Delete(ctx, tx, id, version); err != nil {
 		p.purchaseRepository.Rollback(tx)
 
 		return err
 	}
 
-	i := NewInstallmentService(p.installmentRepository, p.creditCardRepository)
+	if err := p.installmentRepository.Trash(ctx, tx, id); err != nil {
+		p.purchaseRepository.Rollback(tx)
 
-	if err := i.CreateInstallment(tx, purchase); err != nil {
+		return err
+	}
+
+	if err := recordAudit(ctx, p.auditRepository, tx, "purchase", models.AuditDelete, id, before); err != nil {
 		p.purchaseRepository.Rollback(tx)
 
 		return err
 	}
 
-	p.purchaseRepository.Commit(tx)
+	if err := p.purchaseRepository.Commit(tx); err != nil {
+		return fmt.Errorf("error on commit transaction: %w", err)
+	}
 
 	return nil
 }
 
-func (p *Purchase) DeletePurchase(id uuid.UUID) error {
+// RestorePurchase takes a purchase and its installments out of the trash and
+// records the purchase in the ledger again.
+func (p *Purchase) RestorePurchase(ctx context.Context, id uuid.UUID) error {
 	tx, err := p.purchaseRepository.BeginTransaction()
 	if err != nil {
-		return fmt.Errorf("error on begin transaction: %v", err)
+		return models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
 	}
 
-	if err := p.purchaseRepository.Delete(tx, id); err != nil {
+	before, err := p.auditRepository.Snapshot(ctx, tx, "purchase", id)
+	if err != nil {
+		p.purchaseRepository.Rollback(tx)
+
 		return err
 	}
 
-	if err := p.installmentRepository.Delete(id); err != nil {
+	if err := p.installmentRepository.Restore(ctx, tx, id); err != nil {
 		p.purchaseRepository.Rollback(tx)
 
 		return err
 	}
 
-	p.purchaseRepository.Commit(tx)
+	purchase, err := p.purchaseRepository.Restore(ctx, tx, id)
+	if err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return err
+	}
+
+	if err := NewLedgerService(p.ledgerRepository).RecordPurchase(ctx, tx, purchase); err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return err
+	}
+
+	if err := recordAudit(ctx, p.auditRepository, tx, "purchase", models.AuditRestore, id, before); err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return err
+	}
+
+	if err := p.purchaseRepository.Commit(tx); err != nil {
+		return fmt.Errorf("error on commit transaction: %w", err)
+	}
 
 	return nil
 }
 
-func (p *Purchase) FindPurchaseByID(id uuid.UUID) (models.PurchaseResponse, error) {
-	purchase, err := p.purchaseRepository.FindByID(id)
+func (p *Purchase) FindPurchaseByID(ctx context.Context, id uuid.UUID) (models.PurchaseResponse, error) {
+	purchase, err := p.purchaseRepository.FindByID(ctx, id)
 	if err != nil {
 		return models.PurchaseResponse{}, err
 	}
 
+	if purchase.Shares, err = p.shareRepository.FindByPurchaseID(ctx, id); err != nil {
+		return models.PurchaseResponse{}, err
+	}
+
+	purchase.NetAmount = roundCents(purchase.Amount - purchase.Refunded)
+
 	return purchase, err
 }
 
-func (p *Purchase) FindPurchaseByDate(date string) (models.PurchaseResponseTotal, error) {
-	purchases, err := p.purchaseRepository.FindByDate(date)
+func (p *Purchase) FindPurchaseByDate(ctx context.Context, date string) (models.PurchaseResponseTotal, error) {
+	purchases, err := p.purchaseRepository.FindByDate(ctx, date)
+	if err != nil {
+		return models.PurchaseResponseTotal{}, err
+	}
+
+	response := processPurchaseResponse(purchases)
+
+	return response, err
+}
+
+func (p *Purchase) FindPurchaseByMonth(ctx context.Context, date string) (models.PurchaseResponseTotal, error) {
+	purchases, err := p.purchaseRepository.FindByMonth(ctx, date)
 	if err != nil {
 		return models.PurchaseResponseTotal{}, err
 	}
@@ -135,8 +609,8 @@ func (p *Purchase) FindPurchaseByDate(date string) (models.PurchaseResponseTotal
 	return response, err
 }
 
-func (p *Purchase) FindPurchaseByMonth(date string) (models.PurchaseResponseTotal, error) {
-	purchases, err := p.purchaseRepository.FindByMonth(date)
+func (p *Purchase) FindPurchaseByPerson(ctx context.Context, personID uuid.UUID) (models.PurchaseResponseTotal, error) {
+	purchases, err := p.purchaseRepository.FindByPerson(ctx, personID)
 	if err != nil {
 		return models.PurchaseResponseTotal{}, err
 	}
@@ -146,8 +620,8 @@ func (p *Purchase) FindPurchaseByMonth(date string) (models.PurchaseResponseTota
 	return response, err
 }
 
-func (p *Purchase) FindPurchaseByPerson(personID uuid.UUID) (models.PurchaseResponseTotal, error) {
-	purchases, err := p.purchaseRepository.FindByPerson(personID)
+func (p *Purchase) FindPurchaseByTags(ctx context.Context, tagIDs []uuid.UUID, matchAll bool) (models.PurchaseResponseTotal, error) {
+	purchases, err := p.purchaseRepository.FindByTags(ctx, tagIDs, matchAll)
 	if err != nil {
 		return models.PurchaseResponseTotal{}, err
 	}
@@ -157,8 +631,8 @@ func (p *Purchase) FindPurchaseByPerson(personID uuid.UUID) (models.PurchaseResp
 	return response, err
 }
 
-func (p *Purchase) FindAllPurchases() ([]models.PurchaseResponse, error) {
-	purchases, err := p.purchaseRepository.FindAll()
+func (p *Purchase) FindAllPurchases(ctx context.Context) ([]models.PurchaseResponse, error) {
+	purchases, err := p.purchaseRepository.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -169,8 +643,9 @@ func (p *Purchase) FindAllPurchases() ([]models.PurchaseResponse, error) {
 func processPurchaseResponse(purchases []models.PurchaseResponse) models.PurchaseResponseTotal {
 	total := 0.0
 
-	for _, purchase := range purchases {
-		total += purchase.Amount
+	for i := range purchases {
+		purchases[i].NetAmount = roundCents(purchases[i].Amount - purchases[i].Refunded)
+		total += purchases[i].NetAmount
 	}
 
 	response := models.PurchaseResponseTotal{
@@ -181,3 +656,102 @@ func processPurchaseResponse(purchases []models.PurchaseResponse) models.Purchas
 
 	return response
 }
+
+// RefundPurchase records a full refund when the amount is zero, or a partial
+// one, and gives the money back on the card invoices or to the account the
+// purchase was debited from.
+func (p *Purchase) RefundPurchase(ctx context.Context, refund models.Refund) (models.Refund, error) {
+	tx, err := p.purchaseRepository.BeginTransaction()
+	if err != nil {
+		return models.Refund{}, models.Unavailable(fmt.Errorf("error on begin transaction: %w", err))
+	}
+
+	refunded, err := p.refundRepository.TotalByPurchaseID(ctx, tx, refund.IDPurchase)
+	if err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, err
+	}
+
+	// Read once the purchase is locked, so its amount cannot change meanwhile.
+	purchase, err := p.purchaseRepository.FindEntity(ctx, refund.IDPurchase)
+	if err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, err
+	}
+
+	available := roundCents(purchase.Amount - refunded)
+
+	if refund.Amount == 0 {
+		refund.Amount = available
+	}
+
+	if available <= 0 {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, models.Conflict("the purchase was already fully refunded")
+	}
+
+	if refund.Amount > available {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, models.Invalid("the refund cannot be greater than %.2f", available)
+	}
+
+	if refund.ID, err = p.refundRepository.Create(ctx, tx, refund); err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, err
+	}
+
+	if purchase.IDCreditCard != uuid.Nil {
+		i := NewInstallmentService(p.installmentRepository, p.creditCardRepository, p.ledgerRepository, p.auditRepository)
+
+		credit := refund.Amount
+		if refund.Mode == models.RefundModeCancel {
+			if credit, err = i.CancelInstallments(ctx, tx, purchase.ID, credit); err != nil {
+				p.purchaseRepository.Rollback(tx)
+
+				return models.Refund{}, err
+			}
+		}
+
+		if credit > 0 {
+			description := fmt.Sprintf("Estorno %s", purchase.Description)
+
+			if err := i.CreateCredit(ctx, tx, purchase, refund.Date, credit, description); err != nil {
+				p.purchaseRepository.Rollback(tx)
+
+				return models.Refund{}, err
+			}
+		}
+	}
+
+	if err := NewLedgerService(p.ledgerRepository).RecordRefund(ctx, tx, purchase, refund); err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, err
+	}
+
+	if err := recordAudit(ctx, p.auditRepository, tx, "purchase_refund", models.AuditCreate, refund.ID, nil); err != nil {
+		p.purchaseRepository.Rollback(tx)
+
+		return models.Refund{}, err
+	}
+
+	if err := p.purchaseRepository.Commit(tx); err != nil {
+		return models.Refund{}, fmt.Errorf("error on commit transaction: %w", err)
+	}
+
+	return refund, nil
+}
+
+func (p *Purchase) FindRefundsByPurchaseID(ctx context.Context, id uuid.UUID) ([]models.Refund, error) {
+	refunds, err := p.refundRepository.FindByPurchaseID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
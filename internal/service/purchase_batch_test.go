@@ -0,0 +1,95 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+// batchRequest has a valid purchase, one without an amount and another valid
+// one.
+func batchRequest(mode string) models.PurchaseBatchRequest {
+	purchase := func(amount float64) models.PurchaseRequest {
+		return models.PurchaseRequest{
+			Description:    "Groceries",
+			Amount:         amount,
+			Date:           "2024-03-10",
+			IDPaymentType:  uuid.New(),
+			IDPurchaseType: uuid.New(),
+			IDPerson:       uuid.New(),
+			IDAccount:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		}
+	}
+
+	return models.PurchaseBatchRequest{Mode: mode, Purchases: []models.PurchaseRequest{purchase(80), purchase(0), purchase(20)}}
+}
+
+func batchStatuses(response models.PurchaseBatchResponse) []string {
+	var statuses []string
+	for _, result := range response.Results {
+		statuses = append(statuses, result.Status)
+	}
+
+	return statuses
+}
+
+func TestBatchPurchasesAtomicRollsBackOnFailure(t *testing.T) {
+	p, store := newFakePurchaseService()
+
+	response, err := p.BatchPurchases(testContext(), batchRequest(models.BatchModeAtomic))
+	if err != nil {
+		t.Fatalf("running the batch: %v", err)
+	}
+
+	want := []string{models.BatchStatusRolledBack, models.BatchStatusFailed, models.BatchStatusSkipped}
+	if got := batchStatuses(response); !reflect.DeepEqual(got, want) {
+		t.Errorf("got statuses %v, want %v", got, want)
+	}
+
+	if response.Succeeded != 0 || response.Failed != 1 {
+		t.Errorf("got %d succeeded and %d failed, want 0 and 1", response.Succeeded, response.Failed)
+	}
+
+	if store.purchases.rolledBack != 1 || store.purchases.committed != 0 {
+		t.Errorf("rolled back %d and committed %d times, want a rollback only", store.purchases.rolledBack, store.purchases.committed)
+	}
+
+	if store.purchases.savepoints != 0 {
+		t.Errorf("an atomic batch took %d savepoints", store.purchases.savepoints)
+	}
+}
+
+func TestBatchPurchasesBestEffortKeepsTheRest(t *testing.T) {
+	p, store := newFakePurchaseService()
+
+	response, err := p.BatchPurchases(testContext(), batchRequest(models.BatchModeBestEffort))
+	if err != nil {
+		t.Fatalf("running the batch: %v", err)
+	}
+
+	want := []string{models.BatchStatusCreated, models.BatchStatusFailed, models.BatchStatusCreated}
+	if got := batchStatuses(response); !reflect.DeepEqual(got, want) {
+		t.Errorf("got statuses %v, want %v", got, want)
+	}
+
+	if response.Succeeded != 2 || response.Failed != 1 {
+		t.Errorf("got %d succeeded and %d failed, want 2 and 1", response.Succeeded, response.Failed)
+	}
+
+	// Each purchase runs in a savepoint, released when it succeeds and rolled
+	// back to when it fails.
+	if store.purchases.savepoints != 3 || store.purchases.released != 2 || store.purchases.restarted != 1 {
+		t.Errorf("got %d savepoints, %d released and %d rolled back to, want 3, 2 and 1",
+			store.purchases.savepoints, store.purchases.released, store.purchases.restarted)
+	}
+
+	if store.purchases.committed != 1 || store.purchases.rolledBack != 0 {
+		t.Errorf("committed %d and rolled back %d times, want a commit only", store.purchases.committed, store.purchases.rolledBack)
+	}
+
+	if len(store.purchases.purchases) != 2 {
+		t.Errorf("saved %d purchases, want 2", len(store.purchases.purchases))
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+)
+
+func refundedPurchase(store fakePurchaseStore) models.Purchase {
+	purchase := models.Purchase{
+		ID:             uuid.New(),
+		Description:    "Notebook",
+		Amount:         300,
+		Date:           "2024-03-10",
+		IDPaymentType:  uuid.New(),
+		IDCreditCard:   uuid.New(),
+		IDPurchaseType: uuid.New(),
+		IDPerson:       uuid.New(),
+		Version:        1,
+	}
+
+	store.purchases.purchases[purchase.ID] = purchase
+	store.installments.installments = []models.Installment{
+		{ID: uuid.New(), PurchaseID: purchase.ID, Number: 3, Value: 100, Month: "2024-04-01", Paid: true},
+		{ID: uuid.New(), PurchaseID: purchase.ID, Number: 3, Value: 100, Month: "2024-05-01"},
+		{ID: uuid.New(), PurchaseID: purchase.ID, Number: 0, Value: -50, Month: "2024-05-01"},
+	}
+	store.refunds.refunded[purchase.ID] = 150
+
+	purchase.Installment.Number = 3
+
+	return purchase
+}
+
+func TestUpdateRefundedPurchaseKeepsInstallments(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := refundedPurchase(store)
+
+	purchase.Description = "Notebook for work"
+
+	if err := p.updatePurchase(testContext(), nil, purchase); err != nil {
+		t.Fatalf("updating the description of a refunded purchase: %v", err)
+	}
+
+	if store.purchases.updated != 1 {
+		t.Errorf("purchase updated %d times, want 1", store.purchases.updated)
+	}
+
+	if store.installments.deleted != 0 {
+		t.Errorf("installments of a refunded purchase were deleted")
+	}
+
+	if len(store.installments.installments) != 3 {
+		t.Errorf("got %d installments, want the paid one, the unpaid one and the credit", len(store.installments.installments))
+	}
+}
+
+func TestUpdateRefundedPurchaseRejectsBillingChanges(t *testing.T) {
+	tests := map[string]func(*models.Purchase){
+		"amount":       func(p *models.Purchase) { p.Amount = 400 },
+		"date":         func(p *models.Purchase) { p.Date = "2024-03-11" },
+		"credit card":  func(p *models.Purchase) { p.IDCreditCard = uuid.New() },
+		"installments": func(p *models.Purchase) { p.Installment.Number = 6 },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, store := newFakePurchaseService()
+			purchase := refundedPurchase(store)
+
+			change(&purchase)
+
+			err := p.updatePurchase(testContext(), nil, purchase)
+
+			var conflict *models.ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("got %v, want a conflict", err)
+			}
+
+			if store.purchases.updated != 0 || store.installments.deleted != 0 {
+				t.Errorf("the purchase or its installments changed")
+			}
+		})
+	}
+}
+
+func TestUpdatePurchaseRebuildsInstallments(t *testing.T) {
+	p, store := newFakePurchaseService()
+
+	purchase := models.Purchase{
+		ID:             uuid.New(),
+		Description:    "Groceries",
+		Amount:         80,
+		Date:           "2024-03-10",
+		IDPaymentType:  uuid.New(),
+		IDPurchaseType: uuid.New(),
+		IDPerson:       uuid.New(),
+		IDAccount:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		Version:        1,
+	}
+	store.purchases.purchases[purchase.ID] = purchase
+
+	purchase.Amount = 90
+
+	if err := p.updatePurchase(testContext(), nil, purchase); err != nil {
+		t.Fatalf("updating the amount: %v", err)
+	}
+
+	if store.installments.deleted != 1 {
+		t.Errorf("installments deleted %d times, want 1", store.installments.deleted)
+	}
+}
+
+func TestRefundPurchaseCannotExceedTheAmount(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := refundedPurchase(store)
+
+	refund := models.Refund{IDPurchase: purchase.ID, Amount: 150.01, Date: "2024-04-15", Mode: models.RefundModeCredit}
+
+	_, err := p.RefundPurchase(testContext(), refund)
+
+	var invalid *models.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+
+	if store.refunds.refunded[purchase.ID] != 150 {
+		t.Errorf("refunded %.2f, want 150", store.refunds.refunded[purchase.ID])
+	}
+}
+
+func TestRefundPurchaseAlreadyFullyRefunded(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := refundedPurchase(store)
+	store.refunds.refunded[purchase.ID] = 300
+
+	_, err := p.RefundPurchase(testContext(), models.Refund{IDPurchase: purchase.ID, Date: "2024-04-15"})
+
+	var conflict *models.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("got %v, want a conflict", err)
+	}
+}
+
+func sharedPurchase(store fakePurchaseStore) models.Purchase {
+	purchase := models.Purchase{
+		ID:             uuid.New(),
+		Description:    "Pizza",
+		Amount:         7,
+		Date:           "2024-03-10",
+		IDPaymentType:  uuid.New(),
+		IDPurchaseType: uuid.New(),
+		IDPerson:       uuid.New(),
+		IDAccount:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		SplitType:      models.SplitEqual,
+		Shares:         []models.PurchaseShare{{IDPerson: uuid.New()}, {IDPerson: uuid.New()}, {IDPerson: uuid.New()}},
+		Version:        1,
+	}
+
+	if err := purchase.ResolveShares(); err != nil {
+		panic(err)
+	}
+
+	store.purchases.purchases[purchase.ID] = purchase
+	store.shares.shares[purchase.ID] = purchase.Shares
+
+	return purchase
+}
+
+func shareAmounts(shares []models.PurchaseShare) []float64 {
+	var amounts []float64
+	for _, share := range shares {
+		amounts = append(amounts, share.Amount)
+	}
+
+	return amounts
+}
+
+func TestPatchPurchaseKeepsSharesThatDoNotAddUpTo100Percent(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := sharedPurchase(store)
+
+	// 7 split in three is 2.33, 2.33 and 2.34: 33.29%, 33.29% and 33.43%.
+	if got := shareAmounts(purchase.Shares); !reflect.DeepEqual(got, []float64{2.33, 2.33, 2.34}) {
+		t.Fatalf("got shares %v", got)
+	}
+
+	if _, err := p.PatchPurchase(testContext(), purchase.ID, []byte(`{"description":"Pizza night"}`), 1); err != nil {
+		t.Fatalf("patching the description: %v", err)
+	}
+
+	if got := shareAmounts(store.shares.shares[purchase.ID]); !reflect.DeepEqual(got, []float64{2.33, 2.33, 2.34}) {
+		t.Errorf("got shares %v, want them untouched", got)
+	}
+}
+
+func TestPatchPurchaseScalesSharesToTheAmount(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := sharedPurchase(store)
+
+	if _, err := p.PatchPurchase(testContext(), purchase.ID, []byte(`{"amount":14}`), 1); err != nil {
+		t.Fatalf("patching the amount: %v", err)
+	}
+
+	if got := shareAmounts(store.shares.shares[purchase.ID]); !reflect.DeepEqual(got, []float64{4.66, 4.66, 4.68}) {
+		t.Errorf("got shares %v, want them scaled to 14", got)
+	}
+}
+
+func TestPatchPurchaseFullyCancelledByRefunds(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := refundedPurchase(store)
+
+	// A cancelling refund of the whole amount deleted every unpaid
+	// installment, and none was paid.
+	store.installments.installments = nil
+	store.refunds.refunded[purchase.ID] = 300
+
+	if _, err := p.PatchPurchase(testContext(), purchase.ID, []byte(`{"place":"Mall"}`), 1); err != nil {
+		t.Fatalf("patching the place: %v", err)
+	}
+
+	if got := store.purchases.purchases[purchase.ID].Place; got != "Mall" {
+		t.Errorf("got place %q, want Mall", got)
+	}
+
+	if len(store.installments.installments) != 0 || store.installments.deleted != 0 {
+		t.Errorf("the installments of the purchase were rebuilt")
+	}
+}
+
+func TestPatchPurchaseReportsAFailedCommit(t *testing.T) {
+	p, store := newFakePurchaseService()
+	purchase := sharedPurchase(store)
+
+	store.purchases.commitErr = errors.New("connection reset")
+
+	if _, err := p.PatchPurchase(testContext(), purchase.ID, []byte(`{"description":"Pizza night"}`), 1); err == nil {
+		t.Errorf("a failed commit was reported as a success")
+	}
+}
+
+func TestCreatePurchaseReportsAFailedCommit(t *testing.T) {
+	p, store := newFakePurchaseService()
+
+	store.purchases.commitErr = errors.New("connection reset")
+
+	purchase := models.Purchase{
+		Description:    "Groceries",
+		Amount:         80,
+		Date:           "2024-03-10",
+		IDPaymentType:  uuid.New(),
+		IDPurchaseType: uuid.New(),
+		IDPerson:       uuid.New(),
+		IDAccount:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+	}
+
+	if _, err := p.CreatePurchase(testContext(), purchase); err == nil {
+		t.Errorf("a failed commit was reported as a success")
+	}
+}
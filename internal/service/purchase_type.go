@@ -1,55 +1,116 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/me/finance/internal/models"
 	"github.com/me/finance/internal/repository"
 )
 
 type PurchaseTypeUseCase interface {
-	CreatePurchaseType(pt models.PurchaseType) error
-	UpdatePurchaseType(pt models.PurchaseType) error
-	DeletePurchaseType(id uuid.UUID) error
-	FindPurchaseTypeByID(id uuid.UUID) (models.PurchaseType, error)
-	FindAllPurchaseTypes() ([]models.PurchaseType, error)
+	CreatePurchaseType(ctx context.Context, pt models.PurchaseType) (models.PurchaseType, error)
+	UpdatePurchaseType(ctx context.Context, pt models.PurchaseType) error
+	PatchPurchaseType(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.PurchaseType, error)
+	DeletePurchaseType(ctx context.Context, id uuid.UUID, version int) error
+	ReassignPurchaseType(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error)
+	FindPurchaseTypeByID(ctx context.Context, id uuid.UUID) (models.PurchaseType, error)
+	FindAllPurchaseTypes(ctx context.Context) ([]models.PurchaseType, error)
+	FindPurchaseTypeTree(ctx context.Context) ([]models.PurchaseType, error)
 }
 
 type PurchaseType struct {
-	repository repository.RepositoryPurchaseType
+	repository          repository.RepositoryPurchaseType
+	referenceRepository repository.ReferenceRepository
+	ledgerRepository    repository.LedgerRepository
+	auditRepository     repository.AuditRepository
 }
 
-func NewPurchaseTypeService(r repository.RepositoryPurchaseType) PurchaseTypeUseCase {
+func NewPurchaseTypeService(r repository.RepositoryPurchaseType, ref repository.ReferenceRepository, l repository.LedgerRepository, a repository.AuditRepository) PurchaseTypeUseCase {
 	return &PurchaseType{
-		repository: r,
+		repository:          r,
+		referenceRepository: ref,
+		ledgerRepository:    l,
+		auditRepository:     a,
 	}
 }
 
-func (p *PurchaseType) CreatePurchaseType(pt models.PurchaseType) error {
-	if err := p.repository.Create(pt); err != nil {
-		return err
+func (p *PurchaseType) CreatePurchaseType(ctx context.Context, pt models.PurchaseType) (models.PurchaseType, error) {
+	id, err := audited(ctx, p.auditRepository, "purchase_type", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		if err := p.validateParent(ctx, tx, pt); err != nil {
+			return uuid.Nil, err
+		}
+
+		return p.repository.Create(ctx, tx, pt)
+	})
+	if err != nil {
+		return models.PurchaseType{}, err
 	}
 
-	return nil
+	return p.FindPurchaseTypeByID(ctx, id)
 }
 
-func (p *PurchaseType) UpdatePurchaseType(pt models.PurchaseType) error {
-	if err := p.repository.Update(pt); err != nil {
-		return err
-	}
+func (p *PurchaseType) UpdatePurchaseType(ctx context.Context, pt models.PurchaseType) error {
+	_, err := audited(ctx, p.auditRepository, "purchase_type", models.AuditUpdate, pt.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		if err := p.validateParent(ctx, tx, pt); err != nil {
+			return pt.ID, err
+		}
+
+		return pt.ID, p.repository.Update(ctx, tx, pt)
+	})
 
-	return nil
+	return err
 }
 
-func (p *PurchaseType) DeletePurchaseType(id uuid.UUID) error {
-	if err := p.repository.Delete(id); err != nil {
-		return err
+func (p *PurchaseType) PatchPurchaseType(ctx context.Context, id uuid.UUID, patch []byte, version int) (models.PurchaseType, error) {
+	current, err := p.FindPurchaseTypeByID(ctx, id)
+	if err != nil {
+		return models.PurchaseType{}, err
+	}
+
+	pt, err := patched(current, patch)
+	if err != nil {
+		return models.PurchaseType{}, err
 	}
 
-	return nil
+	pt.ID, pt.Version = id, version
+
+	if err := pt.Validate(false); err != nil {
+		return models.PurchaseType{}, err
+	}
+
+	if err := p.UpdatePurchaseType(ctx, pt); err != nil {
+		return models.PurchaseType{}, err
+	}
+
+	return p.FindPurchaseTypeByID(ctx, id)
 }
 
-func (p *PurchaseType) FindPurchaseTypeByID(id uuid.UUID) (models.PurchaseType, error) {
-	purchaseType, err := p.repository.FindByID(id)
+func (p *PurchaseType) DeletePurchaseType(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, p.auditRepository, "purchase_type", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		if err := checkUnused(ctx, tx, p.referenceRepository, "purchase_type", "purchase type", id); err != nil {
+			return id, err
+		}
+
+		return id, p.repository.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+// ReassignPurchaseType deletes the purchase type after moving everything that
+// references it to another one, which is also how duplicates are merged.
+func (p *PurchaseType) ReassignPurchaseType(ctx context.Context, id, to uuid.UUID, version int) (models.ReassignResponse, error) {
+	return reassignAndDelete(ctx, p.auditRepository, p.referenceRepository, p.ledgerRepository, "purchase_type", id, to, models.LedgerExpenseAccount, func(tx *sql.Tx) error {
+		return p.repository.Delete(ctx, tx, id, version)
+	})
+}
+
+func (p *PurchaseType) FindPurchaseTypeByID(ctx context.Context, id uuid.UUID) (models.PurchaseType, error) {
+	purchaseType, err := p.repository.FindByID(ctx, id)
 	if err != nil {
 		return models.PurchaseType{}, err
 	}
@@ -57,11 +118,90 @@ func (p *PurchaseType) FindPurchaseTypeByID(id uuid.UUID) (models.PurchaseType,
 	return purchaseType, nil
 }
 
-func (p *PurchaseType) FindAllPurchaseTypes() ([]models.PurchaseType, error) {
-	purchaseTypes, err := p.repository.FindAll()
+func (p *PurchaseType) FindAllPurchaseTypes(ctx context.Context) ([]models.PurchaseType, error) {
+	purchaseTypes, err := p.repository.FindAll(ctx)
 	if err != nil {
 		return []models.PurchaseType{}, err
 	}
 
 	return purchaseTypes, nil
 }
+
+func (p *PurchaseType) FindPurchaseTypeTree(ctx context.Context) ([]models.PurchaseType, error) {
+	purchaseTypes, err := p.repository.FindAll(ctx)
+	if err != nil {
+		return []models.PurchaseType{}, err
+	}
+
+	return buildPurchaseTypeTree(purchaseTypes), nil
+}
+
+// validateParent checks that the parent exists and walks its ancestors to make
+// sure pt is not among them, which would turn the hierarchy into a cycle. It
+// runs in the transaction of the change and locks each ancestor it reads, so
+// two concurrent re-parents cannot each miss the cycle the other one closes.
+func (p *PurchaseType) validateParent(ctx context.Context, tx *sql.Tx, pt models.PurchaseType) error {
+	if !pt.IDParent.Valid {
+		return nil
+	}
+
+	visited := map[uuid.UUID]bool{}
+	parentID := pt.IDParent.UUID
+
+	for {
+		if parentID == pt.ID {
+			return models.InvalidField("id_parent", "the parent purchase type would create a cycle")
+		}
+
+		if visited[parentID] {
+			return fmt.Errorf("the purchase type hierarchy already has a cycle")
+		}
+		visited[parentID] = true
+
+		grandparentID, err := p.repository.FindParentForUpdate(ctx, tx, parentID)
+		if errors.Is(err, models.ErrNotFound) && parentID == pt.IDParent.UUID {
+			return models.InvalidField("id_parent", "the parent purchase type does not exist")
+		}
+
+		if err != nil {
+			return fmt.Errorf("error finding parent purchase type: %w", err)
+		}
+
+		if !grandparentID.Valid {
+			return nil
+		}
+
+		parentID = grandparentID.UUID
+	}
+}
+
+func buildPurchaseTypeTree(purchaseTypes []models.PurchaseType) []models.PurchaseType {
+	children := map[uuid.UUID][]models.PurchaseType{}
+	exists := map[uuid.UUID]bool{}
+
+	for _, pt := range purchaseTypes {
+		exists[pt.ID] = true
+	}
+
+	var roots []models.PurchaseType
+
+	for _, pt := range purchaseTypes {
+		if pt.IDParent.Valid && exists[pt.IDParent.UUID] {
+			children[pt.IDParent.UUID] = append(children[pt.IDParent.UUID], pt)
+			continue
+		}
+
+		roots = append(roots, pt)
+	}
+
+	var attach func(nodes []models.PurchaseType) []models.PurchaseType
+	attach = func(nodes []models.PurchaseType) []models.PurchaseType {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+
+		return nodes
+	}
+
+	return attach(roots)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+// checkUnused fails with a models.InUseError while live records still
+// reference the row, so reference data is never deleted from under them.
+func checkUnused(ctx context.Context, tx *sql.Tx, r repository.ReferenceRepository, table, entity string, id uuid.UUID) error {
+	dependents, err := r.CountDependents(ctx, tx, table, id)
+	if err != nil {
+		return err
+	}
+
+	if dependents.Total() > 0 {
+		return &models.InUseError{Entity: entity, ID: id, Dependents: dependents}
+	}
+
+	return nil
+}
+
+// reassign moves every reference to from onto to, in the transaction that
+// deletes from. For the records that have an account in the ledger, ledgerAccount
+// names it and its balance is transferred along with the references.
+func reassign(ctx context.Context, tx *sql.Tx, r repository.ReferenceRepository, l repository.LedgerRepository, table string, from, to uuid.UUID, ledgerAccount func(uuid.UUID) string) (models.ReassignResponse, error) {
+	moved, err := r.Reassign(ctx, tx, table, from, to)
+	if err != nil {
+		return models.ReassignResponse{}, err
+	}
+
+	if ledgerAccount != nil {
+		description := fmt.Sprintf("Reatribuição de %s", table)
+		if err := NewLedgerService(l).TransferBalance(ctx, tx, ledgerAccount(from), ledgerAccount(to), from, description); err != nil {
+			return models.ReassignResponse{}, err
+		}
+	}
+
+	return models.ReassignResponse{From: from, To: to, Moved: moved}, nil
+}
+
+// reassignAndDelete runs reassign and then delete in one audited transaction.
+func reassignAndDelete(ctx context.Context, a repository.AuditRepository, r repository.ReferenceRepository, l repository.LedgerRepository, table string, from, to uuid.UUID, ledgerAccount func(uuid.UUID) string, delete func(tx *sql.Tx) error) (models.ReassignResponse, error) {
+	var response models.ReassignResponse
+
+	_, err := audited(ctx, a, table, models.AuditReassign, from, func(tx *sql.Tx) (uuid.UUID, error) {
+		var err error
+		if response, err = reassign(ctx, tx, r, l, table, from, to, ledgerAccount); err != nil {
+			return from, err
+		}
+
+		return from, delete(tx)
+	})
+	if err != nil {
+		return models.ReassignResponse{}, err
+	}
+
+	return response, nil
+}
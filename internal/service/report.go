@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type ReportService interface {
+	TotalsByTag(ctx context.Context, month string) (models.ReportTagResponse, error)
+	TotalsByPurchaseType(ctx context.Context, month string) (models.ReportPurchaseTypeResponse, error)
+	MonthlyBalance(ctx context.Context, month string) (models.MonthlyBalance, error)
+}
+
+type Report struct {
+	reportRepository repository.ReportRepository
+	incomeRepository repository.IncomeRepository
+}
+
+func NewReportService(r repository.ReportRepository, i repository.IncomeRepository) ReportService {
+	return &Report{
+		reportRepository: r,
+		incomeRepository: i,
+	}
+}
+
+func (r *Report) TotalsByTag(ctx context.Context, month string) (models.ReportTagResponse, error) {
+	totals, err := r.reportRepository.TotalsByTag(ctx, month)
+	if err != nil {
+		return models.ReportTagResponse{}, fmt.Errorf("error finding totals by tag: %w", err)
+	}
+
+	return models.ReportTagResponse{Responses: totals}, nil
+}
+
+// TotalsByPurchaseType returns the purchase type tree where each node's total
+// includes the amounts of all of its subcategories.
+func (r *Report) TotalsByPurchaseType(ctx context.Context, month string) (models.ReportPurchaseTypeResponse, error) {
+	totals, err := r.reportRepository.TotalsByPurchaseType(ctx, month)
+	if err != nil {
+		return models.ReportPurchaseTypeResponse{}, fmt.Errorf("error finding totals by purchase type: %w", err)
+	}
+
+	response := models.ReportPurchaseTypeResponse{Responses: rollUpPurchaseTypeTotals(totals)}
+
+	for _, total := range response.Responses {
+		response.Total += total.Total
+	}
+
+	return response, nil
+}
+
+func rollUpPurchaseTypeTotals(totals []models.PurchaseTypeTotal) []models.PurchaseTypeTotal {
+	children := map[uuid.UUID][]models.PurchaseTypeTotal{}
+	exists := map[uuid.UUID]bool{}
+
+	for _, total := range totals {
+		exists[total.ID] = true
+	}
+
+	var roots []models.PurchaseTypeTotal
+
+	for _, total := range totals {
+		if total.IDParent.Valid && exists[total.IDParent.UUID] {
+			children[total.IDParent.UUID] = append(children[total.IDParent.UUID], total)
+			continue
+		}
+
+		roots = append(roots, total)
+	}
+
+	var rollUp func(nodes []models.PurchaseTypeTotal) []models.PurchaseTypeTotal
+	rollUp = func(nodes []models.PurchaseTypeTotal) []models.PurchaseTypeTotal {
+		for i := range nodes {
+			nodes[i].Children = rollUp(children[nodes[i].ID])
+			nodes[i].Total = nodes[i].Amount
+
+			for _, child := range nodes[i].Children {
+				nodes[i].Total += child.Total
+				nodes[i].Quantity += child.Quantity
+			}
+		}
+
+		return nodes
+	}
+
+	return rollUp(roots)
+}
+
+// MonthlyBalance returns the income received in the month minus the expenses
+// due in it.
+func (r *Report) MonthlyBalance(ctx context.Context, month string) (models.MonthlyBalance, error) {
+	incomes, err := r.incomeRepository.FindByMonth(ctx, month)
+	if err != nil {
+		return models.MonthlyBalance{}, fmt.Errorf("error finding incomes by month: %w", err)
+	}
+
+	expenses, err := r.reportRepository.ExpensesByMonth(ctx, month)
+	if err != nil {
+		return models.MonthlyBalance{}, fmt.Errorf("error finding expenses by month: %w", err)
+	}
+
+	income := processIncomeResponse(incomes).Total
+
+	return models.MonthlyBalance{
+		Month:    month,
+		Income:   roundCents(income),
+		Expenses: roundCents(expenses),
+		Net:      roundCents(income - expenses),
+	}, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type SettlementService interface {
+	CreateSettlement(ctx context.Context, settlement models.Settlement) (models.Settlement, error)
+	DeleteSettlement(ctx context.Context, id uuid.UUID) error
+	FindSettlementByID(ctx context.Context, id uuid.UUID) (models.Settlement, error)
+	FindAllSettlements(ctx context.Context) ([]models.Settlement, error)
+	FindBalances(ctx context.Context) (models.BalanceResponse, error)
+	FindSettlementPlan(ctx context.Context) (models.SettlementPlanResponse, error)
+}
+
+type Settlement struct {
+	settlementRepository repository.SettlementRepository
+	auditRepository      repository.AuditRepository
+}
+
+func NewSettlementService(r repository.SettlementRepository, a repository.AuditRepository) SettlementService {
+	return &Settlement{
+		settlementRepository: r,
+		auditRepository:      a,
+	}
+}
+
+func (s *Settlement) CreateSettlement(ctx context.Context, settlement models.Settlement) (models.Settlement, error) {
+	id, err := audited(ctx, s.auditRepository, "settlement", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return s.settlementRepository.Create(ctx, tx, settlement)
+	})
+	if err != nil {
+		return models.Settlement{}, err
+	}
+
+	return s.FindSettlementByID(ctx, id)
+}
+
+func (s *Settlement) DeleteSettlement(ctx context.Context, id uuid.UUID) error {
+	_, err := audited(ctx, s.auditRepository, "settlement", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		return id, s.settlementRepository.Delete(ctx, tx, id)
+	})
+
+	return err
+}
+
+func (s *Settlement) FindSettlementByID(ctx context.Context, id uuid.UUID) (models.Settlement, error) {
+	settlement, err := s.settlementRepository.FindByID(ctx, id)
+	if err != nil {
+		return models.Settlement{}, err
+	}
+
+	return settlement, nil
+}
+
+func (s *Settlement) FindAllSettlements(ctx context.Context) ([]models.Settlement, error) {
+	settlements, err := s.settlementRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return settlements, nil
+}
+
+// FindBalances nets the debts between every pair of persons and sums them into
+// a balance per person: positive balances are owed to the person, negative
+// balances are owed by the person.
+func (s *Settlement) FindBalances(ctx context.Context) (models.BalanceResponse, error) {
+	debts, err := s.settlementRepository.FindDebts(ctx)
+	if err != nil {
+		return models.BalanceResponse{}, fmt.Errorf("error finding debts: %w", err)
+	}
+
+	debtsBetween := netDebts(debts)
+
+	return models.BalanceResponse{
+		Balances: personBalances(debtsBetween),
+		Debts:    debtsBetween,
+	}, nil
+}
+
+// FindSettlementPlan returns the transfers needed to zero every balance,
+// matching the largest debtor with the largest creditor at each step, which
+// needs at most one transfer less than the number of persons involved.
+func (s *Settlement) FindSettlementPlan(ctx context.Context) (models.SettlementPlanResponse, error) {
+	balances, err := s.FindBalances(ctx)
+	if err != nil {
+		return models.SettlementPlanResponse{}, err
+	}
+
+	var debtors, creditors []models.PersonBalance
+
+	for _, balance := range balances.Balances {
+		if balance.Balance < 0 {
+			balance.Balance = -balance.Balance
+			debtors = append(debtors, balance)
+		} else if balance.Balance > 0 {
+			creditors = append(creditors, balance)
+		}
+	}
+
+	var response models.SettlementPlanResponse
+
+	for len(debtors) > 0 && len(creditors) > 0 {
+		sortByBalance(debtors)
+		sortByBalance(creditors)
+
+		amount := roundCents(math.Min(debtors[0].Balance, creditors[0].Balance))
+
+		response.Transfers = append(response.Transfers, models.Transfer{
+			IDFrom: debtors[0].IDPerson,
+			From:   debtors[0].Person,
+			IDTo:   creditors[0].IDPerson,
+			To:     creditors[0].Person,
+			Amount: amount,
+		})
+		response.Total += amount
+
+		debtors[0].Balance = roundCents(debtors[0].Balance - amount)
+		creditors[0].Balance = roundCents(creditors[0].Balance - amount)
+
+		if debtors[0].Balance <= 0 {
+			debtors = debtors[1:]
+		}
+
+		if creditors[0].Balance <= 0 {
+			creditors = creditors[1:]
+		}
+	}
+
+	response.Quantity = len(response.Transfers)
+	response.Total = roundCents(response.Total)
+
+	return response, nil
+}
+
+func netDebts(debts []models.Debt) []models.Debt {
+	type pair struct{ a, b uuid.UUID }
+
+	var (
+		keys  []pair
+		net   = map[pair]*models.Debt{}
+		order = func(d models.Debt) (pair, float64) {
+			if d.IDDebtor.String() < d.IDCreditor.String() {
+				return pair{d.IDDebtor, d.IDCreditor}, d.Amount
+			}
+
+			return pair{d.IDCreditor, d.IDDebtor}, -d.Amount
+		}
+	)
+
+	for _, debt := range debts {
+		key, amount := order(debt)
+
+		if _, ok := net[key]; !ok {
+			keys = append(keys, key)
+			net[key] = &models.Debt{IDDebtor: key.a, IDCreditor: key.b}
+		}
+
+		if key.a == debt.IDDebtor {
+			net[key].Debtor, net[key].Creditor = debt.Debtor, debt.Creditor
+		} else {
+			net[key].Debtor, net[key].Creditor = debt.Creditor, debt.Debtor
+		}
+
+		net[key].Amount += amount
+	}
+
+	var result []models.Debt
+
+	for _, key := range keys {
+		debt := *net[key]
+		debt.Amount = roundCents(debt.Amount)
+
+		if debt.Amount == 0 {
+			continue
+		}
+
+		if debt.Amount < 0 {
+			debt.IDDebtor, debt.IDCreditor = debt.IDCreditor, debt.IDDebtor
+			debt.Debtor, debt.Creditor = debt.Creditor, debt.Debtor
+			debt.Amount = -debt.Amount
+		}
+
+		result = append(result, debt)
+	}
+
+	return result
+}
+
+func personBalances(debts []models.Debt) []models.PersonBalance {
+	var (
+		ids      []uuid.UUID
+		balances = map[uuid.UUID]*models.PersonBalance{}
+	)
+
+	add := func(id uuid.UUID, name string, amount float64) {
+		if _, ok := balances[id]; !ok {
+			ids = append(ids, id)
+			balances[id] = &models.PersonBalance{IDPerson: id, Person: name}
+		}
+
+		balances[id].Balance += amount
+	}
+
+	for _, debt := range debts {
+		add(debt.IDCreditor, debt.Creditor, debt.Amount)
+		add(debt.IDDebtor, debt.Debtor, -debt.Amount)
+	}
+
+	var result []models.PersonBalance
+
+	for _, id := range ids {
+		balance := *balances[id]
+		balance.Balance = roundCents(balance.Balance)
+		result = append(result, balance)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Person < result[j].Person
+	})
+
+	return result
+}
+
+func sortByBalance(balances []models.PersonBalance) {
+	sort.SliceStable(balances, func(i, j int) bool {
+		return balances[i].Balance > balances[j].Balance
+	})
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
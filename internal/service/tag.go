@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+)
+
+type TagService interface {
+	CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	UpdateTag(ctx context.Context, tag models.Tag) error
+	DeleteTag(ctx context.Context, id uuid.UUID, version int) error
+	FindTagByID(ctx context.Context, id uuid.UUID) (models.Tag, error)
+	FindAllTags(ctx context.Context) ([]models.Tag, error)
+}
+
+type Tag struct {
+	tagRepository   repository.TagRepository
+	auditRepository repository.AuditRepository
+}
+
+func NewTagService(r repository.TagRepository, a repository.AuditRepository) TagService {
+	return &Tag{
+		tagRepository:   r,
+		auditRepository: a,
+	}
+}
+
+func (t *Tag) CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	id, err := audited(ctx, t.auditRepository, "tag", models.AuditCreate, uuid.Nil, func(tx *sql.Tx) (uuid.UUID, error) {
+		return t.tagRepository.Create(ctx, tx, tag)
+	})
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	return t.FindTagByID(ctx, id)
+}
+
+func (t *Tag) UpdateTag(ctx context.Context, tag models.Tag) error {
+	_, err := audited(ctx, t.auditRepository, "tag", models.AuditUpdate, tag.ID, func(tx *sql.Tx) (uuid.UUID, error) {
+		return tag.ID, t.tagRepository.Update(ctx, tx, tag)
+	})
+
+	return err
+}
+
+func (t *Tag) DeleteTag(ctx context.Context, id uuid.UUID, version int) error {
+	_, err := audited(ctx, t.auditRepository, "tag", models.AuditDelete, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		return id, t.tagRepository.Delete(ctx, tx, id, version)
+	})
+
+	return err
+}
+
+func (t *Tag) FindTagByID(ctx context.Context, id uuid.UUID) (models.Tag, error) {
+	tag, err := t.tagRepository.FindByID(ctx, id)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (t *Tag) FindAllTags(ctx context.Context) ([]models.Tag, error) {
+	tags, err := t.tagRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
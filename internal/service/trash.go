@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/me/finance/internal/models"
+	"github.com/me/finance/internal/repository"
+	"github.com/sagikazarmark/slog-shim"
+)
+
+type TrashService interface {
+	FindTrash(ctx context.Context) ([]models.TrashItem, error)
+	Restore(ctx context.Context, entity string, id uuid.UUID) error
+	Purge(ctx context.Context, retention time.Duration) (models.PurgeResponse, error)
+	RunPurge(retention, interval time.Duration)
+}
+
+type Trash struct {
+	trashRepository     repository.TrashRepository
+	purchaseService     PurchaseService
+	auditRepository     repository.AuditRepository
+	householdRepository repository.HouseholdRepository
+}
+
+func NewTrashService(r repository.TrashRepository, p PurchaseService, a repository.AuditRepository, h repository.HouseholdRepository) TrashService {
+	return &Trash{
+		trashRepository:     r,
+		purchaseService:     p,
+		auditRepository:     a,
+		householdRepository: h,
+	}
+}
+
+func (t *Trash) FindTrash(ctx context.Context) ([]models.TrashItem, error) {
+	items, err := t.trashRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Restore takes a record out of the trash. Purchases go through the purchase
+// service so their installments and ledger entry come back with them.
+func (t *Trash) Restore(ctx context.Context, entity string, id uuid.UUID) error {
+	table, err := models.TrashTable(entity)
+	if err != nil {
+		return err
+	}
+
+	if entity == models.TrashPurchases {
+		return t.purchaseService.RestorePurchase(ctx, id)
+	}
+
+	_, err = audited(ctx, t.auditRepository, table, models.AuditRestore, id, func(tx *sql.Tx) (uuid.UUID, error) {
+		return id, t.trashRepository.Restore(ctx, tx, table, id)
+	})
+
+	return err
+}
+
+// Purge removes for good everything trashed longer ago than the retention,
+// keeping the records other records still reference and the purchases with a
+// refund or ledger history.
+func (t *Trash) Purge(ctx context.Context, retention time.Duration) (models.PurgeResponse, error) {
+	response := models.PurgeResponse{
+		Before:  time.Now().Add(-retention),
+		Purged:  map[string]int64{},
+		Skipped: map[string]int64{},
+	}
+
+	for _, table := range models.TrashTables {
+		ids, err := t.trashRepository.FindPurgeable(ctx, table.Table, response.Before)
+		if err != nil {
+			return models.PurgeResponse{}, err
+		}
+
+		for _, id := range ids {
+			_, err := audited(ctx, t.auditRepository, table.Table, models.AuditPurge, id, func(tx *sql.Tx) (uuid.UUID, error) {
+				return id, t.trashRepository.Purge(ctx, tx, table.Table, id)
+			})
+
+			if errors.Is(err, repository.ErrReferenced) {
+				response.Skipped[table.Entity]++
+				continue
+			}
+
+			if err != nil {
+				return models.PurgeResponse{}, err
+			}
+
+			response.Purged[table.Entity]++
+		}
+	}
+
+	return response, nil
+}
+
+// RunPurge purges the trash of every household every interval until the
+// process exits.
+func (t *Trash) RunPurge(retention, interval time.Duration) {
+	ctx := models.WithAuditMetadata(context.Background(), models.AuditMetadata{Actor: models.AuditSystem})
+
+	for {
+		households, err := t.householdRepository.FindAllIDs()
+		if err != nil {
+			slog.Error(err.Error())
+		}
+
+		for _, householdID := range households {
+			response, err := t.Purge(models.WithHousehold(ctx, householdID), retention)
+			if err != nil {
+				slog.Error(err.Error(), "household", householdID)
+				continue
+			}
+
+			slog.Info("trash purged", "household", householdID, "purged", response.Purged, "skipped", response.Skipped)
+		}
+
+		time.Sleep(interval)
+	}
+}